@@ -0,0 +1,124 @@
+package art
+
+import (
+	"math"
+)
+
+// Defines a monoid over the values of a tree, whose aggregates are kept on every inner node
+// so that Aggregate can combine the values of a range of keys without visiting all of their leaves.
+// Combine must be associative, and Identity must be its identity element,
+// but Combine need not be commutative: aggregates are always combined in key order.
+type Aggregator interface {
+	// Returns the aggregate of no values.
+	Identity() interface{}
+
+	// Returns the aggregate of the single passed in value.
+	Lift(value interface{}) interface{}
+
+	// Returns the aggregate of the values aggregated by a, followed by the values aggregated by b.
+	Combine(a, b interface{}) interface{}
+}
+
+// Aggregates numeric values into their float64 sum.  Values that are not numeric are ignored.
+type SumAggregator struct{}
+
+func (SumAggregator) Identity() interface{} { return float64(0) }
+
+func (SumAggregator) Lift(value interface{}) interface{} {
+	if f, ok := toFloat64(value); ok {
+		return f
+	}
+
+	return float64(0)
+}
+
+func (SumAggregator) Combine(a, b interface{}) interface{} { return a.(float64) + b.(float64) }
+
+// Aggregates numeric values into their float64 minimum, which is +Inf if there are none.
+// Values that are not numeric are ignored.
+type MinAggregator struct{}
+
+func (MinAggregator) Identity() interface{} { return math.Inf(1) }
+
+func (MinAggregator) Lift(value interface{}) interface{} {
+	if f, ok := toFloat64(value); ok {
+		return f
+	}
+
+	return math.Inf(1)
+}
+
+func (MinAggregator) Combine(a, b interface{}) interface{} { return math.Min(a.(float64), b.(float64)) }
+
+// Aggregates numeric values into their float64 maximum, which is -Inf if there are none.
+// Values that are not numeric are ignored.
+type MaxAggregator struct{}
+
+func (MaxAggregator) Identity() interface{} { return math.Inf(-1) }
+
+func (MaxAggregator) Lift(value interface{}) interface{} {
+	if f, ok := toFloat64(value); ok {
+		return f
+	}
+
+	return math.Inf(-1)
+}
+
+func (MaxAggregator) Combine(a, b interface{}) interface{} { return math.Max(a.(float64), b.(float64)) }
+
+// Returns the passed in value as a float64, and whether or not it is of a numeric type.
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+	}
+
+	return 0, false
+}
+
+// Returns the aggregate of the values of the current node or the leaves below it.
+// Inner nodes that do not carry an aggregate have theirs computed from their children.
+func (n *ArtNode) subtreeAggregate(agg Aggregator) interface{} {
+	if n.IsLeaf() {
+		return agg.Lift(n.leaf().value)
+	}
+
+	if augment := n.augment(); augment != nil {
+		return augment.aggregate
+	}
+
+	return n.childAggregate(agg)
+}
+
+// Returns the aggregate of the children of the current inner node, combined in key order.
+func (n *ArtNode) childAggregate(agg Aggregator) interface{} {
+	result := agg.Identity()
+	n.eachChild(func(key byte, child *ArtNode) {
+		result = agg.Combine(result, child.subtreeAggregate(agg))
+	})
+
+	return result
+}
@@ -0,0 +1,181 @@
+package art
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// Aggregates string values into the list of them in key order, which is not commutative.
+type listAggregator struct{}
+
+func (listAggregator) Identity() interface{} { return []string{} }
+
+func (listAggregator) Lift(value interface{}) interface{} { return []string{value.(string)} }
+
+func (listAggregator) Combine(a, b interface{}) interface{} {
+	return append(append([]string{}, a.([]string)...), b.([]string)...)
+}
+
+// Returns a random bound for a range query over the passed in keys, which is sometimes nil,
+// sometimes one of the keys, and sometimes falls between them.
+func randomBound(r *rand.Rand, keys [][]byte) []byte {
+	switch r.Intn(4) {
+	case 0:
+		return nil
+	case 1:
+		return keys[r.Intn(len(keys))]
+	default:
+	}
+
+	key := keys[r.Intn(len(keys))]
+	return append(append([]byte{}, key[:r.Intn(len(key)+1)]...), byte('a'+r.Intn(26)))
+}
+
+// Returns whether or not the passed in key lies within the passed in bounds, where nil is unbounded.
+func inRange(key, lo, hi []byte) bool {
+	return (lo == nil || bytes.Compare(key, lo) >= 0) && (hi == nil || bytes.Compare(key, hi) < 0)
+}
+
+func TestAggregateMatchesBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(41))
+	words := loadAsset(t, "test/assets/words.txt")
+	r.Shuffle(len(words), func(i, j int) { words[i], words[j] = words[j], words[i] })
+	words = words[:20000]
+
+	aggregators := []Aggregator{SumAggregator{}, MinAggregator{}, MaxAggregator{}}
+	modes := []PrefixMode{PREFIX_HYBRID, PREFIX_OPTIMISTIC, PREFIX_PESSIMISTIC}
+
+	for _, agg := range aggregators {
+		for _, mode := range modes {
+			tree := NewArtTreeWithOptions(Options{Aggregator: agg, PrefixMode: mode, Arena: mode == PREFIX_HYBRID})
+			values := map[string]int{}
+
+			for i, word := range words {
+				tree.Insert(word, i)
+				values[string(word)] = i
+			}
+
+			// Remove and overwrite keys so that nodes shrink and aggregates change in place.
+			for i := 0; i < len(words)/2; i++ {
+				word := words[r.Intn(len(words))]
+				if r.Intn(2) == 0 {
+					tree.Remove(word)
+					delete(values, string(word))
+				} else {
+					tree.Insert(word, -i)
+					values[string(word)] = -i
+				}
+			}
+
+			remaining := [][]byte{}
+			for _, word := range words {
+				if _, ok := values[string(word)]; ok {
+					remaining = append(remaining, word)
+				}
+			}
+
+			for i := 0; i < 100; i++ {
+				lo, hi := randomBound(r, words), randomBound(r, words)
+
+				expected := agg.Identity()
+				for _, word := range remaining {
+					if inRange(word, lo, hi) {
+						expected = agg.Combine(expected, agg.Lift(values[string(word)]))
+					}
+				}
+
+				if actual := tree.Aggregate(lo, hi); actual != expected {
+					t.Errorf("Expected %v for [%q, %q) in mode %d, got %v", expected, lo, hi, mode, actual)
+				}
+			}
+		}
+	}
+}
+
+// Aggregates should be combined in key order, across every type of inner node.
+func TestAggregateCombinesInKeyOrder(t *testing.T) {
+	words := loadAsset(t, "test/assets/words.txt")
+	r := rand.New(rand.NewSource(1))
+
+	keys := [][]byte{}
+	tree := NewArtTreeWithOptions(Options{Aggregator: listAggregator{}})
+	for i := 0; i < 1500; i++ {
+		word := words[r.Intn(len(words))]
+		keys = append(keys, word)
+		tree.Insert(word, string(word))
+	}
+
+	for i := 0; i < 500; i++ {
+		word := keys[r.Intn(len(keys))]
+		tree.Remove(word)
+	}
+
+	sorted := []string{}
+	tree.Each(func(n *ArtNode) {
+		if n.IsLeaf() {
+			sorted = append(sorted, n.Value().(string))
+		}
+	})
+
+	if !sort.StringsAreSorted(sorted) {
+		t.Fatal("Expected leaves in key order")
+	}
+
+	if actual := tree.Aggregate(nil, nil); !reflect.DeepEqual(actual, sorted) {
+		t.Errorf("Expected the aggregate of the whole tree to list %d values in key order", len(sorted))
+	}
+
+	for i := 0; i < 500; i++ {
+		lo, hi := randomBound(r, keys), randomBound(r, keys)
+
+		expected := []string{}
+		for _, value := range sorted {
+			if inRange([]byte(value), lo, hi) {
+				expected = append(expected, value)
+			}
+		}
+
+		if actual := tree.Aggregate(lo, hi); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("Unexpected aggregate for [%q, %q): %v, expected %v", lo, hi, actual, expected)
+		}
+	}
+}
+
+func TestAggregateWithoutAggregator(t *testing.T) {
+	tree := NewArtTree()
+	tree.Insert([]byte("hello"), 1)
+
+	if tree.Aggregate(nil, nil) != nil {
+		t.Error("Expected no aggregate for a tree without an Aggregator")
+	}
+
+	tree = NewArtTreeWithOptions(Options{Aggregator: MinAggregator{}})
+	if actual := tree.Aggregate(nil, nil); actual != math.Inf(1) {
+		t.Errorf("Expected the identity for an empty tree, got %v", actual)
+	}
+
+	tree.Insert([]byte("a"), 3)
+	tree.Insert([]byte("b"), "three")
+	tree.Insert([]byte("c"), uint8(2))
+
+	if actual := tree.Aggregate([]byte("a"), []byte("c")); actual != float64(3) {
+		t.Errorf("Expected non-numeric values to be ignored, got %v", actual)
+	}
+}
+
+func BenchmarkAggregateWords(b *testing.B) {
+	words := loadAsset(b, "test/assets/words.txt")
+	tree := NewArtTreeWithOptions(Options{Aggregator: SumAggregator{}})
+	for i, word := range words {
+		tree.Insert(word, i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.Aggregate(words[i%len(words)], words[(i*7919)%len(words)])
+	}
+}
@@ -0,0 +1,226 @@
+package art
+
+import (
+	"unsafe"
+)
+
+const (
+	// The number of bytes that are reserved at once for each slab of nodes and key bytes.
+	ARENA_SLAB_SIZE = 32 << 10
+)
+
+// Defines a per-tree slab allocator for ArtNodes.
+// Rather than allocating every node individually, nodes are carved out of slabs
+// that hold many nodes of the same type, and the bytes of leaf keys are carved
+// out of shared byte slabs.  Nodes that are released by grow(), shrink() and RemoveChild
+// are zeroed and kept on a free list for their type so that later insertions can reuse them.
+// Since the arena owns every slab, dropping the tree releases all of its nodes at once.
+//
+// A nil arena is valid, and simply allocates every node from the heap.
+type nodeArena struct {
+	leaves   []artLeaf
+	node4s   []node4
+	node16s  []node16
+	node48s  []node48
+	node256s []node256
+	keyBytes []byte
+
+	freeLeaves   []*artLeaf
+	freeNode4s   []*node4
+	freeNode16s  []*node16
+	freeNode48s  []*node48
+	freeNode256s []*node256
+}
+
+// Creates and returns a new, empty arena.
+func newNodeArena() *nodeArena {
+	return &nodeArena{}
+}
+
+// Returns the number of elements of the passed in size that fit in a single slab.
+func slabLen(size uintptr) int {
+	if n := int(ARENA_SLAB_SIZE / size); n > 1 {
+		return n
+	}
+
+	return 1
+}
+
+// Returns a new leaf node with a copy of the passed in key and the passed in value.
+func (a *nodeArena) newLeaf(key []byte, value interface{}) *ArtNode {
+	if a == nil {
+		return NewLeafNode(key, value)
+	}
+
+	var l *artLeaf
+	if last := len(a.freeLeaves) - 1; last >= 0 {
+		l = a.freeLeaves[last]
+		a.freeLeaves = a.freeLeaves[:last]
+	} else {
+		if len(a.leaves) == 0 {
+			a.leaves = make([]artLeaf, slabLen(unsafe.Sizeof(artLeaf{})))
+		}
+		l = &a.leaves[0]
+		a.leaves = a.leaves[1:]
+	}
+
+	l.nodeType = LEAF
+	l.key = a.copyKey(key)
+	l.value = value
+
+	return &l.ArtNode
+}
+
+// Returns a copy of the passed in key whose bytes are carved out of the current key slab.
+// Keys that are too large to share a slab, or that are copied by a nil arena, are allocated on their own.
+func (a *nodeArena) copyKey(key []byte) []byte {
+	if a == nil || len(key) > ARENA_SLAB_SIZE/8 {
+		newKey := make([]byte, len(key))
+		copy(newKey, key)
+		return newKey
+	}
+
+	if len(key) > len(a.keyBytes) {
+		a.keyBytes = make([]byte, ARENA_SLAB_SIZE)
+	}
+
+	newKey := a.keyBytes[:len(key):len(key)]
+	copy(newKey, key)
+	a.keyBytes = a.keyBytes[len(key):]
+
+	return newKey
+}
+
+// Returns a new, empty inner node of type NODE4.
+func (a *nodeArena) newNode4() *ArtNode {
+	if a == nil {
+		return NewNode4()
+	}
+
+	var n *node4
+	if last := len(a.freeNode4s) - 1; last >= 0 {
+		n = a.freeNode4s[last]
+		a.freeNode4s = a.freeNode4s[:last]
+	} else {
+		if len(a.node4s) == 0 {
+			a.node4s = make([]node4, slabLen(unsafe.Sizeof(node4{})))
+		}
+		n = &a.node4s[0]
+		a.node4s = a.node4s[1:]
+	}
+
+	n.nodeType = NODE4
+	return &n.ArtNode
+}
+
+// Returns a new, empty inner node of type NODE16.
+func (a *nodeArena) newNode16() *ArtNode {
+	if a == nil {
+		return NewNode16()
+	}
+
+	var n *node16
+	if last := len(a.freeNode16s) - 1; last >= 0 {
+		n = a.freeNode16s[last]
+		a.freeNode16s = a.freeNode16s[:last]
+	} else {
+		if len(a.node16s) == 0 {
+			a.node16s = make([]node16, slabLen(unsafe.Sizeof(node16{})))
+		}
+		n = &a.node16s[0]
+		a.node16s = a.node16s[1:]
+	}
+
+	n.nodeType = NODE16
+	return &n.ArtNode
+}
+
+// Returns a new, empty inner node of type NODE48.
+func (a *nodeArena) newNode48() *ArtNode {
+	if a == nil {
+		return NewNode48()
+	}
+
+	var n *node48
+	if last := len(a.freeNode48s) - 1; last >= 0 {
+		n = a.freeNode48s[last]
+		a.freeNode48s = a.freeNode48s[:last]
+	} else {
+		if len(a.node48s) == 0 {
+			a.node48s = make([]node48, slabLen(unsafe.Sizeof(node48{})))
+		}
+		n = &a.node48s[0]
+		a.node48s = a.node48s[1:]
+	}
+
+	n.nodeType = NODE48
+	return &n.ArtNode
+}
+
+// Returns a new, empty inner node of type NODE256.
+func (a *nodeArena) newNode256() *ArtNode {
+	if a == nil {
+		return NewNode256()
+	}
+
+	var n *node256
+	if last := len(a.freeNode256s) - 1; last >= 0 {
+		n = a.freeNode256s[last]
+		a.freeNode256s = a.freeNode256s[:last]
+	} else {
+		if len(a.node256s) == 0 {
+			a.node256s = make([]node256, slabLen(unsafe.Sizeof(node256{})))
+		}
+		n = &a.node256s[0]
+		a.node256s = a.node256s[1:]
+	}
+
+	n.nodeType = NODE256
+	return &n.ArtNode
+}
+
+// Releases the passed in node back to the free list for its type.
+// The node is zeroed so that it no longer references its children or value,
+// and must not be used by the caller afterwards.
+// The bytes of a released leaf's key are not reused until the whole arena is dropped.
+// Shared nodes may still be referenced by another tree, so they are left as they are.
+func (a *nodeArena) free(n *ArtNode) {
+	if a == nil || n == nil || n.flags&sharedFlag != 0 {
+		return
+	}
+
+	// Inner nodes with optional attributes are allocated from the heap, so they are simply dropped.
+	if n.nodeType != LEAF && n.flags&innerAugmentFlag != 0 {
+		return
+	}
+
+	switch n.nodeType {
+	case LEAF:
+		// Leaves with optional attributes are allocated from the heap, so they are simply dropped.
+		if n.flags&leafExtFlag != 0 {
+			*(*extLeaf)(unsafe.Pointer(n)) = extLeaf{}
+			return
+		}
+
+		l := n.leaf()
+		*l = artLeaf{}
+		a.freeLeaves = append(a.freeLeaves, l)
+	case NODE4:
+		n4 := n.node4()
+		*n4 = node4{}
+		a.freeNode4s = append(a.freeNode4s, n4)
+	case NODE16:
+		n16 := n.node16()
+		*n16 = node16{}
+		a.freeNode16s = append(a.freeNode16s, n16)
+	case NODE48:
+		n48 := n.node48()
+		*n48 = node48{}
+		a.freeNode48s = append(a.freeNode48s, n48)
+	case NODE256:
+		n256 := n.node256()
+		*n256 = node256{}
+		a.freeNode256s = append(a.freeNode256s, n256)
+	default:
+	}
+}
@@ -0,0 +1,151 @@
+package art
+
+import (
+	"bytes"
+	"testing"
+)
+
+// A nil arena should fall back to allocating nodes from the heap.
+func TestNilArenaAllocatesFromHeap(t *testing.T) {
+	var a *nodeArena
+
+	leaf := a.newLeaf([]byte("art"), "tree")
+	if !leaf.IsLeaf() || leaf.Value() != "tree" {
+		t.Error("Unexpected leaf allocated by nil arena")
+	}
+
+	nodes := []*ArtNode{a.newNode4(), a.newNode16(), a.newNode48(), a.newNode256()}
+	expectedTypes := []uint8{NODE4, NODE16, NODE48, NODE256}
+
+	for i := range nodes {
+		if nodes[i].nodeType != expectedTypes[i] {
+			t.Error("Unexpected node type allocated by nil arena")
+		}
+	}
+
+	// Releasing to a nil arena should be a no-op
+	a.free(leaf)
+}
+
+// Keys copied into the arena should not be able to overwrite each other when appended to.
+func TestArenaCopyKeyIsIsolated(t *testing.T) {
+	a := newNodeArena()
+
+	first := a.newLeaf([]byte("first"), nil).Key()
+	second := a.newLeaf([]byte("second"), nil).Key()
+
+	_ = append(first, 'x')
+
+	if bytes.Compare(second, []byte("second")) != 0 {
+		t.Error("Appending to one arena key should not modify another")
+	}
+}
+
+// Nodes released to the arena should be zeroed and handed out again before a new slab is used.
+func TestArenaReusesReleasedNodes(t *testing.T) {
+	a := newNodeArena()
+
+	n := a.newNode4()
+	n = n.AddChild('a', a.newLeaf([]byte("a"), nil))
+	a.free(n)
+
+	if len(a.freeNode4s) != 1 {
+		t.Error("Expected released node to be kept on the free list")
+	}
+
+	reused := a.newNode4()
+	if reused != n {
+		t.Error("Expected released node to be reused")
+	}
+
+	if reused.inner().size != 0 || reused.children()[0] != nil {
+		t.Error("Expected reused node to be zeroed")
+	}
+}
+
+// Growing and shrinking through an arena should release the replaced nodes to their free lists.
+func TestArenaGrowAndShrinkReleaseNodes(t *testing.T) {
+	tree := NewArtTreeWithOptions(Options{Arena: true})
+	a := tree.arena
+
+	n := a.newNode4()
+	for i := 0; i < NODE4MAX+1; i++ {
+		n = n.addChild(tree, byte(i), a.newLeaf([]byte{byte(i)}, nil))
+	}
+
+	if n.nodeType != NODE16 || len(a.freeNode4s) != 1 {
+		t.Error("Expected Node4 to be released after growing to a Node16")
+	}
+
+	n = n.removeChild(tree, 0)
+
+	if n.nodeType != NODE4 || len(a.freeNode16s) != 1 || len(a.freeNode4s) != 0 {
+		t.Error("Expected Node16 to be released after shrinking to a reused Node4")
+	}
+}
+
+// A tree that allocates from an arena should behave exactly like one that does not.
+func TestArenaTreeInsertSearchAndRemoveManyWords(t *testing.T) {
+	tree := NewArtTreeWithOptions(Options{Arena: true})
+	words := loadAsset(t, "test/assets/words.txt")
+
+	for _, word := range words {
+		tree.Insert(word, word)
+	}
+
+	if tree.size != int64(len(words)) {
+		t.Error("Unexpected tree size after inserting many words")
+	}
+
+	for _, word := range words {
+		res := tree.Search(word)
+		if res == nil || bytes.Compare(res.([]byte), word) != 0 {
+			t.Errorf("Incorrect value for node %v.", word)
+		}
+	}
+
+	for i, word := range words {
+		tree.Remove(word)
+
+		if i%20000 == 0 {
+			mustValidate(t, tree)
+		}
+	}
+
+	if tree.size != 0 || tree.root != nil {
+		t.Error("Tree is expected to be empty after removing many words")
+	}
+
+	if len(tree.arena.freeLeaves) != len(words) {
+		t.Error("Expected every removed leaf to be released to the arena")
+	}
+
+	// Reinserting should reuse the released leaves rather than allocating new ones.
+	for i, word := range words {
+		tree.Insert(word, word)
+
+		if i%20000 == 0 {
+			mustValidate(t, tree)
+		}
+	}
+
+	if len(tree.arena.freeLeaves) != 0 {
+		t.Error("Expected every released leaf to be reused")
+	}
+
+	for _, word := range words {
+		if tree.Search(word) == nil {
+			t.Errorf("Did not find entry for key: %v", word)
+		}
+	}
+}
+
+// Reports the memory usage of a tree containing every UUID in uuid.txt, allocated from an arena.
+func BenchmarkInsertUUIDsArenaBytesPerKey(b *testing.B) {
+	benchmarkInsertMemory(b, "test/assets/uuid.txt", Options{Arena: true})
+}
+
+// Reports the memory usage of a tree containing every word in words.txt, allocated from an arena.
+func BenchmarkInsertWordsArenaBytesPerKey(b *testing.B) {
+	benchmarkInsertMemory(b, "test/assets/words.txt", Options{Arena: true})
+}
@@ -0,0 +1,165 @@
+package art
+
+import (
+	"bytes"
+)
+
+// Defines a position within an ArtTree that moves over its leaves in key order, skipping leaves that have expired.
+// The cursor keeps the path from the root to its current leaf, so moving to the next leaf
+// only revisits the inner nodes above it, and it can be repositioned at any key with Seek.
+//
+// A cursor must not be used after the tree has been modified, other than by being repositioned.
+type Cursor struct {
+	tree  *ArtTree
+	stack []cursorFrame
+	node  *ArtNode
+
+	// Whether or not the cursor has been positioned by First, Seek or Next.
+	positioned bool
+}
+
+// Defines an inner node on the path of a cursor, along with the smallest key byte
+// whose child has not been visited yet.
+type cursorFrame struct {
+	node *ArtNode
+	next int
+}
+
+// Returns a new cursor over the current tree, which is positioned at its first leaf by the first call to Next.
+func (t *ArtTree) Cursor() *Cursor {
+	return &Cursor{tree: t}
+}
+
+// Returns the leaf the cursor is positioned at, or nil if it is not positioned at one.
+func (c *Cursor) Node() *ArtNode {
+	return c.node
+}
+
+// Positions the cursor at the first leaf of the tree, and returns whether or not there is one.
+func (c *Cursor) First() bool {
+	c.reset()
+
+	if c.tree.root == nil {
+		return false
+	}
+
+	return c.skipExpired(c.descend(c.tree.root))
+}
+
+// Positions the cursor at the first leaf whose key is at least the passed in key,
+// and returns whether or not there is one.  The KeyTransform of the tree is applied to the key.
+func (c *Cursor) Seek(key []byte) bool {
+	return c.seek(c.tree.indexKey(key))
+}
+
+// Moves the cursor to the next leaf in key order, or to the first leaf if the cursor has not been positioned yet,
+// and returns whether or not there is one.
+func (c *Cursor) Next() bool {
+	if !c.positioned {
+		return c.First()
+	}
+
+	return c.skipExpired(c.advance())
+}
+
+// Moves the cursor past the leaves that have expired, if it was positioned at one,
+// and returns whether or not it is still positioned at a leaf.
+func (c *Cursor) skipExpired(found bool) bool {
+	for found && c.tree.expired(c.node) {
+		found = c.advance()
+	}
+
+	return found
+}
+
+// Moves the cursor to the next leaf in key order, and returns whether or not there is one.
+func (c *Cursor) advance() bool {
+	for len(c.stack) > 0 {
+		top := &c.stack[len(c.stack)-1]
+		if key, child := top.node.nextChild(top.next); child != nil {
+			top.next = int(key) + 1
+			return c.descend(child)
+		}
+
+		c.stack = c.stack[:len(c.stack)-1]
+	}
+
+	c.node = nil
+	return false
+}
+
+// Clears the path of the cursor, and marks it as positioned.
+func (c *Cursor) reset() {
+	c.stack = c.stack[:0]
+	c.node = nil
+	c.positioned = true
+}
+
+// Positions the cursor at the minimum leaf below the passed in node, pushing every inner node on the way.
+func (c *Cursor) descend(n *ArtNode) bool {
+	for !n.IsLeaf() {
+		key, child := n.nextChild(0)
+		c.stack = append(c.stack, cursorFrame{node: n, next: int(key) + 1})
+		n = child
+	}
+
+	c.node = n
+	return true
+}
+
+// Positions the cursor at the first leaf whose key is at least the passed in indexed key, and that has not expired.
+func (c *Cursor) seek(key []byte) bool {
+	return c.skipExpired(c.position(key))
+}
+
+// Positions the cursor at the first leaf whose key is at least the passed in indexed key.
+// Subtrees whose compressed paths are less than the key are skipped,
+// and the cursor moves to the minimum leaf of the first subtree whose compressed path is greater.
+func (c *Cursor) position(key []byte) bool {
+	c.reset()
+
+	current := c.tree.root
+	depth := 0
+
+	for current != nil {
+		if current.IsLeaf() {
+			if bytes.Compare(current.leaf().key, key) >= 0 {
+				c.node = current
+				return true
+			}
+
+			return c.advance()
+		}
+
+		// Keys that end here are a prefix of every key below the node.
+		if depth >= len(key) {
+			return c.descend(current)
+		}
+
+		inner := current.inner()
+		if inner.prefixLen > 0 {
+			path := current.compressedPath(depth)
+			end := min(depth+len(path), len(key))
+
+			if cmp := bytes.Compare(path[:end-depth], key[depth:end]); cmp < 0 {
+				return c.advance()
+			} else if cmp > 0 || end == len(key) {
+				return c.descend(current)
+			}
+
+			depth += len(path)
+		}
+
+		c.stack = append(c.stack, cursorFrame{node: current, next: int(key[depth]) + 1})
+
+		next := current.findChild(key[depth])
+		if next == nil {
+			return c.advance()
+		}
+
+		current = *next
+		depth++
+	}
+
+	return false
+}
@@ -0,0 +1,100 @@
+package art
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// Returns the keys of every leaf of the passed in tree, in key order.
+func sortedLeafKeys(tree *ArtTree) [][]byte {
+	keys := [][]byte{}
+	tree.Each(func(n *ArtNode) {
+		if n.IsLeaf() {
+			keys = append(keys, n.Key())
+		}
+	})
+
+	return keys
+}
+
+// Iterating with a cursor should visit every leaf in key order, and Seek should find the first leaf
+// at or after any key, in every prefix mode.
+func TestCursorMatchesSortedKeys(t *testing.T) {
+	modes := []PrefixMode{PREFIX_HYBRID, PREFIX_OPTIMISTIC, PREFIX_PESSIMISTIC}
+	words := loadAsset(t, "test/assets/words.txt")
+
+	for _, mode := range modes {
+		r := rand.New(rand.NewSource(43))
+		tree := NewArtTreeWithOptions(Options{PrefixMode: mode})
+		for _, word := range words {
+			if r.Intn(4) != 0 {
+				tree.Insert(word, word)
+			}
+		}
+
+		sorted := sortedLeafKeys(tree)
+
+		c := tree.Cursor()
+		i := 0
+		for c.Next() {
+			if i >= len(sorted) || !bytes.Equal(c.Node().Key(), sorted[i]) {
+				t.Fatalf("Unexpected key %q at %d in mode %d", c.Node().Key(), i, mode)
+			}
+			i++
+		}
+
+		if i != len(sorted) || c.Node() != nil || c.Next() {
+			t.Errorf("Expected the cursor to visit %d keys and stay exhausted in mode %d, visited %d", len(sorted), mode, i)
+		}
+
+		for i := 0; i < 5000; i++ {
+			word := words[r.Intn(len(words))]
+			query := append([]byte{}, word[:r.Intn(len(word))]...)
+			if r.Intn(2) == 0 {
+				query = append(query, byte('a'+r.Intn(26)))
+			}
+
+			indexed := append(append([]byte{}, query...), 0)
+			position := sort.Search(len(sorted), func(i int) bool { return bytes.Compare(sorted[i], indexed) >= 0 })
+
+			if found := c.Seek(query); found != (position < len(sorted)) {
+				t.Fatalf("Unexpected result of seeking %q in mode %d: %v", query, mode, found)
+			} else if !found {
+				continue
+			}
+
+			if !bytes.Equal(c.Node().Key(), sorted[position]) {
+				t.Fatalf("Expected seeking %q to find %q in mode %d, found %q", query, sorted[position], mode, c.Node().Key())
+			}
+
+			// The cursor moves on from the position it was sought to.
+			if position+1 < len(sorted) && (!c.Next() || !bytes.Equal(c.Node().Key(), sorted[position+1])) {
+				t.Fatalf("Expected %q after %q in mode %d", sorted[position+1], sorted[position], mode)
+			}
+		}
+	}
+}
+
+func TestCursorOnEmptyTree(t *testing.T) {
+	c := NewArtTree().Cursor()
+
+	if c.First() || c.Seek([]byte("a")) || c.Next() || c.Node() != nil {
+		t.Error("Expected a cursor over an empty tree to never be positioned at a leaf")
+	}
+}
+
+func BenchmarkCursorWords(b *testing.B) {
+	tree := NewArtTree()
+	for _, word := range loadAsset(b, "test/assets/words.txt") {
+		tree.Insert(word, word)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c := tree.Cursor()
+		for c.Next() {
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package art
+
+import (
+	"bytes"
+	"reflect"
+)
+
+// Calls the passed in callback for every key that differs between the passed in trees, in key order:
+// with a nil leaf from the first tree for keys that were added in the second tree,
+// with a nil leaf from the second tree for keys that were removed from it,
+// and with the leaves of both trees for keys whose values changed.
+//
+// Both trees are walked together, node by node, and subtrees that both trees have in common are skipped
+// without visiting their leaves: subtrees that are the same node in both trees, and,
+// if both trees keep hashes with the same HashValue, subtrees with the same hash.
+// Without hashes, the values of leaves are compared with reflect.DeepEqual.
+// Leaves that have expired are treated as if they had already been removed from their trees,
+// so subtrees that hold any of them are always compared leaf by leaf.
+// Both trees must index their keys the same way.
+func Diff(a, b *ArtTree, callback func(before, after *ArtNode)) {
+	d := &differ{a: a, b: b, expiredA: a.expiredKeys(), expiredB: b.expiredKeys(), callback: callback}
+	if a.options.HashValue != nil && b.options.HashValue != nil {
+		d.hashValue = a.options.HashValue
+	}
+
+	var pathA, pathB []byte
+	if a.root != nil {
+		pathA = a.root.remainingPath(0)
+	}
+
+	if b.root != nil {
+		pathB = b.root.remainingPath(0)
+	}
+
+	d.diff(a.root, pathA, b.root, pathB, 0)
+}
+
+// Defines the state of a walk of Diff.
+type differ struct {
+	a, b      *ArtTree
+	callback  func(before, after *ArtNode)
+	hashValue func(value interface{}) []byte
+
+	// The indexed keys of the leaves of each tree that have expired, in key order.
+	expiredA [][]byte
+	expiredB [][]byte
+}
+
+// Reports the differences between the passed in nodes, whose remaining paths from the passed in depth are also passed in.
+func (d *differ) diff(a *ArtNode, pathA []byte, b *ArtNode, pathB []byte, depth int) {
+	if a != nil && a.IsLeaf() && d.a.expired(a) {
+		a = nil
+	}
+
+	if b != nil && b.IsLeaf() && d.b.expired(b) {
+		b = nil
+	}
+
+	if a == nil || b == nil {
+		d.each(a, true)
+		d.each(b, false)
+		return
+	}
+
+	// Skip the subtrees that are the same in both trees, unless they hold leaves that have expired in either of them.
+	if bytes.Equal(pathA, pathB) && !d.expiredBelow(a, depth+len(pathA)) {
+		if a == b {
+			return
+		}
+
+		if d.hashValue != nil && !a.IsLeaf() && !b.IsLeaf() && a.subtreeHash(d.hashValue) == b.subtreeHash(d.hashValue) {
+			return
+		}
+	}
+
+	mismatch := 0
+	for mismatch < len(pathA) && mismatch < len(pathB) && pathA[mismatch] == pathB[mismatch] {
+		mismatch++
+	}
+
+	if a.IsLeaf() && b.IsLeaf() && mismatch == len(pathA) && mismatch == len(pathB) {
+		if !d.valuesEqual(a, b) {
+			d.callback(a, b)
+		}
+
+		return
+	}
+
+	// Otherwise, the children of both nodes below their common path are compared by their key bytes.
+	depth += mismatch
+	keysA, childrenA := setChildren(a, pathA, mismatch)
+	keysB, childrenB := setChildren(b, pathB, mismatch)
+
+	i, j := 0, 0
+	for i < len(keysA) || j < len(keysB) {
+		switch {
+		case j == len(keysB) || (i < len(keysA) && keysA[i] < keysB[j]):
+			d.each(childrenA[i].node, true)
+			i++
+
+		case i == len(keysA) || keysB[j] < keysA[i]:
+			d.each(childrenB[j].node, false)
+			j++
+
+		default:
+			d.diff(childrenA[i].node, childrenA[i].pathAt(depth+1), childrenB[j].node, childrenB[j].pathAt(depth+1), depth+1)
+			i++
+			j++
+		}
+	}
+}
+
+// Reports every leaf below the passed in node as removed from the first tree if removed is set,
+// or as added to the second tree otherwise.
+func (d *differ) each(n *ArtNode, removed bool) {
+	if n == nil {
+		return
+	}
+
+	if !n.IsLeaf() {
+		n.eachChild(func(key byte, child *ArtNode) {
+			d.each(child, removed)
+		})
+	} else if removed && !d.a.expired(n) {
+		d.callback(n, nil)
+	} else if !removed && !d.b.expired(n) {
+		d.callback(nil, n)
+	}
+}
+
+// Returns whether or not either tree holds a leaf that has expired below the passed in node,
+// whose keys share their first length bytes.
+func (d *differ) expiredBelow(n *ArtNode, length int) bool {
+	if len(d.expiredA) == 0 && len(d.expiredB) == 0 {
+		return false
+	}
+
+	prefix := n.Minimum().leaf().key[:length]
+	return len(keysWithPrefix(d.expiredA, prefix)) > 0 || len(keysWithPrefix(d.expiredB, prefix)) > 0
+}
+
+// Returns whether or not the values of the passed in leaves are equal,
+// by their encodings if both trees keep hashes, and by reflect.DeepEqual otherwise.
+func (d *differ) valuesEqual(a, b *ArtNode) bool {
+	if d.hashValue != nil {
+		return bytes.Equal(d.hashValue(a.leaf().value), d.hashValue(b.leaf().value))
+	}
+
+	return reflect.DeepEqual(a.leaf().value, b.leaf().value)
+}
@@ -0,0 +1,169 @@
+package art
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// Defines a difference reported by Diff, for comparison with the expected differences.
+type diffEntry struct {
+	key           string
+	before, after interface{}
+}
+
+// Returns the differences reported by Diff between the passed in trees.
+func collectDiff(a, b *ArtTree) []diffEntry {
+	entries := []diffEntry{}
+	Diff(a, b, func(before, after *ArtNode) {
+		entry := diffEntry{}
+		if before != nil {
+			entry.key, entry.before = string(before.Key()), before.Value()
+		}
+
+		if after != nil {
+			entry.key, entry.after = string(after.Key()), after.Value()
+		}
+
+		entries = append(entries, entry)
+	})
+
+	return entries
+}
+
+// Encodes values for hashing, counting the number of values it encodes.
+func countingHashValue(count *int) func(value interface{}) []byte {
+	return func(value interface{}) []byte {
+		*count++
+		return []byte(fmt.Sprint(value))
+	}
+}
+
+func TestDiffMatchesBruteForce(t *testing.T) {
+	words := loadAsset(t, "test/assets/words.txt")[:50000]
+
+	for _, hashed := range []bool{false, true} {
+		r := rand.New(rand.NewSource(44))
+
+		count := 0
+		options := Options{PrefixMode: PREFIX_HYBRID}
+		otherOptions := Options{PrefixMode: PREFIX_OPTIMISTIC}
+		if hashed {
+			options.HashValue = countingHashValue(&count)
+			otherOptions.HashValue = countingHashValue(&count)
+		}
+
+		a := NewArtTreeWithOptions(options)
+		b := NewArtTreeWithOptions(otherOptions)
+		expected := []diffEntry{}
+
+		for i, word := range words {
+			key := string(word) + "\x00"
+
+			switch r.Intn(1000) {
+			case 0:
+				a.Insert(word, i)
+				expected = append(expected, diffEntry{key, i, nil})
+			case 1:
+				b.Insert(word, i)
+				expected = append(expected, diffEntry{key, nil, i})
+			case 2:
+				a.Insert(word, i)
+				b.Insert(word, -i)
+				expected = append(expected, diffEntry{key, i, -i})
+			default:
+				a.Insert(word, i)
+				b.Insert(word, i)
+			}
+		}
+
+		sort.Slice(expected, func(i, j int) bool { return expected[i].key < expected[j].key })
+
+		count = 0
+		actual := collectDiff(a, b)
+
+		if len(actual) != len(expected) {
+			t.Fatalf("Expected %d differences, got %d", len(expected), len(actual))
+		}
+
+		for i := range expected {
+			if actual[i] != expected[i] {
+				t.Errorf("Expected %v at %d, got %v", expected[i], i, actual[i])
+			}
+		}
+
+		// Hashes let the walk skip every subtree without a difference, so only a fraction of the values are encoded.
+		if hashed && count > len(words)/10 {
+			t.Errorf("Expected the walk to skip unchanged subtrees, but %d values were encoded", count)
+		}
+
+		if len(collectDiff(a, a)) != 0 || len(collectDiff(b, b)) != 0 {
+			t.Error("Expected no differences between a tree and itself")
+		}
+	}
+}
+
+// Trees holding the same keys and values should have the same hashes, however they were built,
+// and any difference should change the hash of their roots.
+func TestSubtreeHashesDependOnlyOnContents(t *testing.T) {
+	words := loadAsset(t, "test/assets/words.txt")[:20000]
+	hashValue := func(value interface{}) []byte { return []byte(fmt.Sprint(value)) }
+
+	a := NewArtTreeWithOptions(Options{HashValue: hashValue})
+	b := NewArtTreeWithOptions(Options{HashValue: hashValue, PrefixMode: PREFIX_PESSIMISTIC})
+
+	for i, word := range words {
+		a.Insert(word, i)
+	}
+
+	// Build the second tree in reverse, with keys that are removed again along the way.
+	for i := len(words) - 1; i >= 0; i-- {
+		b.Insert(words[i], i)
+		b.Insert(append([]byte("extra/"), words[i]...), i)
+		b.Remove(append([]byte("extra/"), words[i]...))
+	}
+
+	if a.root.subtreeHash(hashValue) != b.root.subtreeHash(hashValue) {
+		t.Fatal("Expected trees with the same contents to have the same root hash")
+	}
+
+	if a.root.subtreeHash(hashValue) != a.root.childHash(hashValue) {
+		t.Error("Expected the kept root hash to match the hash computed from its children")
+	}
+
+	b.Insert(words[0], -1)
+	if a.root.subtreeHash(hashValue) == b.root.subtreeHash(hashValue) {
+		t.Error("Expected a changed value to change the root hash")
+	}
+
+	b.Insert(words[0], 0)
+	b.Remove(words[1])
+	if a.root.subtreeHash(hashValue) == b.root.subtreeHash(hashValue) {
+		t.Error("Expected a removed key to change the root hash")
+	}
+}
+
+func TestDiffOfEmptyTrees(t *testing.T) {
+	a := NewArtTree()
+	b := NewArtTree()
+
+	if len(collectDiff(a, b)) != 0 {
+		t.Error("Expected no differences between empty trees")
+	}
+
+	b.Insert([]byte("apple"), []byte("red"))
+	if diff := collectDiff(a, b); len(diff) != 1 || diff[0].key != "apple\x00" || diff[0].before != nil {
+		t.Errorf("Expected apple to be added, got %v", diff)
+	}
+
+	a.Insert([]byte("apple"), []byte("red"))
+	if diff := collectDiff(a, b); len(diff) != 0 {
+		t.Errorf("Expected equal byte slices to be equal values, got %v", diff)
+	}
+
+	if diff := collectDiff(b, NewArtTree()); len(diff) != 1 || !bytes.Equal(diff[0].before.([]byte), []byte("red")) {
+		t.Errorf("Expected apple to be removed, got %v", diff)
+	}
+}
@@ -0,0 +1,183 @@
+package art
+
+import (
+	"bytes"
+	"encoding/binary"
+	"unsafe"
+)
+
+// Decides which keys a tree evicts once it holds more keys or bytes than its Options allow.
+type EvictionPolicy uint8
+
+const (
+	// Evict the key that was least recently inserted or found by Search.
+	EVICT_LRU EvictionPolicy = iota
+
+	// Evict the key that was inserted or found by Search the fewest times,
+	// and the least recently used among keys that were used equally often.
+	EVICT_LFU
+)
+
+// Defines how often and how recently a key of a bounded tree has been used.
+// Uses are counted by insertions and Search hits, and ordered by a counter of the tree,
+// so that the order does not depend on its Clock.
+type leafUsage struct {
+	uses uint64
+	used uint64
+}
+
+// Returns whether or not the tree limits the number of keys or bytes it holds.
+func (t *ArtTree) bounded() bool {
+	return t.options.MaxEntries > 0 || t.options.MaxBytes > 0
+}
+
+// Returns whether or not the tree holds more keys or bytes than its Options allow.
+func (t *ArtTree) overCapacity() bool {
+	return (t.options.MaxEntries > 0 && t.size > int64(t.options.MaxEntries)) ||
+		(t.options.MaxBytes > 0 && t.bytes > t.options.MaxBytes)
+}
+
+// Returns the usage of the current leaf, or nil if it is not kept by a bounded tree.
+func (n *ArtNode) usage() *leafUsage {
+	if ext := n.ext(); ext != nil {
+		return ext.usage
+	}
+
+	return nil
+}
+
+// Returns the estimated number of bytes held by a leaf with the passed in indexed key, value and optional attributes:
+// the leaf itself, its keys and usage, and its value as estimated by the ValueSize of the tree.
+// Inner nodes are not counted, since they are shared between keys.
+func (t *ArtTree) entryBytes(key []byte, value interface{}, ext *leafExt) int64 {
+	size := int64(unsafe.Sizeof(artLeaf{})) + int64(len(key))
+
+	if ext != nil {
+		size = int64(unsafe.Sizeof(extLeaf{})) + int64(len(key)) + int64(len(ext.originalKey))
+		if ext.usage != nil {
+			size += int64(unsafe.Sizeof(leafUsage{}))
+		}
+	}
+
+	if t.options.ValueSize != nil {
+		size += int64(t.options.ValueSize(value))
+	}
+
+	return size
+}
+
+// Records a use of the passed in leaf of a bounded tree, moving it within the usage index of the tree.
+func (t *ArtTree) touch(n *ArtNode) {
+	u := n.usage()
+	if u == nil {
+		return
+	}
+
+	key := n.leaf().key
+	t.usage.removeHelper(t.usage.root, &t.usage.root, t.usageKey(u, key), 0)
+
+	t.ticks++
+	u.uses, u.used = u.uses+1, t.ticks
+	t.usage.insertHelper(t.usage.root, &t.usage.root, t.usageKey(u, key), nil, nil, 0)
+}
+
+// Replaces the passed in usage of the passed in indexed key within the usage index of the tree, if it is not nil,
+// by the other passed in usage, which is counted as a use following the first one if it is not nil.
+func (t *ArtTree) updateUsage(key []byte, from *leafUsage, to *leafUsage) {
+	if from != nil {
+		t.usage.removeHelper(t.usage.root, &t.usage.root, t.usageKey(from, key), 0)
+	}
+
+	if to == nil {
+		return
+	}
+
+	if from != nil {
+		to.uses = from.uses
+	}
+
+	if t.usage == nil {
+		t.usage = NewArtTree()
+	}
+
+	t.ticks++
+	to.uses, to.used = to.uses+1, t.ticks
+	t.usage.insertHelper(t.usage.root, &t.usage.root, t.usageKey(to, key), nil, nil, 0)
+}
+
+// Returns the key of the passed in indexed key within the usage index, which orders keys by the passed in usage
+// as decided by the Eviction policy of the tree: by their last use, or by their number of uses followed by their last use.
+// The usage is encoded in big endian, so that the byte order of the keys follows the order of the usages.
+func (t *ArtTree) usageKey(u *leafUsage, key []byte) []byte {
+	entry := make([]byte, t.usagePrefixLen(), t.usagePrefixLen()+len(key))
+
+	if t.options.Eviction == EVICT_LFU {
+		binary.BigEndian.PutUint64(entry, u.uses)
+		binary.BigEndian.PutUint64(entry[8:], u.used)
+	} else {
+		binary.BigEndian.PutUint64(entry, u.used)
+	}
+
+	return append(entry, key...)
+}
+
+// Returns the number of bytes the usage of a key takes up in front of the key within the usage index.
+func (t *ArtTree) usagePrefixLen() int {
+	if t.options.Eviction == EVICT_LFU {
+		return 16
+	}
+
+	return 8
+}
+
+// Removes keys from a tree that holds more keys or bytes than its Options allow until it no longer does,
+// starting with the keys that have expired, and then following the Eviction policy of the tree.
+// Keys are removed like any other key, after which they are passed to the OnEvict callback of the tree.
+// The passed in indexed key, which was just inserted, is never evicted.
+func (t *ArtTree) evict(inserted []byte) {
+	if !t.overCapacity() {
+		return
+	}
+
+	t.Sweep()
+
+	for t.overCapacity() {
+		c := t.usage.Cursor()
+
+		found := c.First()
+		for found && bytes.Equal(c.Node().leaf().key[t.usagePrefixLen():], inserted) {
+			found = c.Next()
+		}
+
+		if !found {
+			return
+		}
+
+		key := c.Node().leaf().key[t.usagePrefixLen():]
+		n := t.searchHelper(t.root, key, 0)
+		originalKey, value := append([]byte{}, n.OriginalKey()...), n.leaf().value
+
+		t.remove(key)
+
+		if t.options.OnEvict != nil {
+			t.options.OnEvict(originalKey, value)
+		}
+	}
+}
+
+// Rebuilds the usage index and the estimated bytes of a bounded tree from its leaves,
+// for trees that were built without inserting them.  Every leaf must carry a usage of its own,
+// and is counted as if it had been inserted once, in key order.
+func (t *ArtTree) indexUsage() {
+	t.usage = nil
+	t.bytes = 0
+
+	t.eachHelper(t.root, func(n *ArtNode) {
+		if !n.IsLeaf() {
+			return
+		}
+
+		t.updateUsage(n.leaf().key, nil, n.usage())
+		t.bytes += t.entryBytes(n.leaf().key, n.leaf().value, n.ext())
+	})
+}
@@ -0,0 +1,213 @@
+package art
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// Records the keys passed to the OnEvict callback of a tree.
+type evictionLog struct {
+	keys []string
+}
+
+func (l *evictionLog) onEvict(key []byte, value interface{}) {
+	l.keys = append(l.keys, string(key))
+}
+
+// The least recently inserted or found key should be evicted once the tree is full.
+func TestEvictLRU(t *testing.T) {
+	log := &evictionLog{}
+	tree := NewArtTreeWithOptions(Options{MaxEntries: 3, OnEvict: log.onEvict})
+
+	tree.Insert([]byte("apple"), 1)
+	tree.Insert([]byte("banana"), 2)
+	tree.Insert([]byte("cherry"), 3)
+	tree.Search([]byte("apple"))
+	tree.Insert([]byte("date"), 4)
+
+	tree.Insert([]byte("cherry"), 5)
+	tree.Insert([]byte("elderberry"), 6)
+
+	checkKeys(t, "Evicted", log.keys, []string{"banana\x00", "apple\x00"})
+
+	keys, _ := treeEntries(tree)
+	checkKeys(t, "Remaining", keys, []string{"cherry\x00", "date\x00", "elderberry\x00"})
+
+	if tree.size != 3 || tree.usage.size != 3 {
+		t.Errorf("Expected 3 keys and 3 usages, got %d and %d", tree.size, tree.usage.size)
+	}
+}
+
+// The least frequently used key should be evicted once the tree is full,
+// and the least recently used among keys that were used equally often.
+func TestEvictLFU(t *testing.T) {
+	log := &evictionLog{}
+	tree := NewArtTreeWithOptions(Options{MaxEntries: 3, Eviction: EVICT_LFU, OnEvict: log.onEvict})
+
+	tree.Insert([]byte("apple"), 1)
+	tree.Insert([]byte("banana"), 2)
+	tree.Insert([]byte("cherry"), 3)
+	tree.Search([]byte("apple"))
+	tree.Search([]byte("apple"))
+	tree.Search([]byte("banana"))
+	tree.Insert([]byte("cherry"), 4)
+	tree.Search([]byte("cherry"))
+
+	// Banana and cherry have both been used twice, and banana was used less recently.
+	tree.Insert([]byte("date"), 5)
+
+	// The key that was just inserted is never evicted by its own insertion, even though it has been used the least.
+	tree.Insert([]byte("elderberry"), 6)
+
+	checkKeys(t, "Evicted", log.keys, []string{"banana\x00", "date\x00"})
+
+	keys, _ := treeEntries(tree)
+	checkKeys(t, "Remaining", keys, []string{"apple\x00", "cherry\x00", "elderberry\x00"})
+}
+
+// Random insertions, removals and searches should evict the same keys as a list ordered by recency.
+func TestEvictLRUMatchesBruteForce(t *testing.T) {
+	modes := []PrefixMode{PREFIX_HYBRID, PREFIX_OPTIMISTIC, PREFIX_PESSIMISTIC}
+	words := loadAsset(t, "test/assets/words.txt")[:2000]
+	r := rand.New(rand.NewSource(48))
+
+	for _, mode := range modes {
+		log := &evictionLog{}
+		tree := NewArtTreeWithOptions(Options{PrefixMode: mode, MaxEntries: 100, OnEvict: log.onEvict})
+
+		recency := []string{}
+		values := map[string]interface{}{}
+		drop := func(key string) {
+			for i, other := range recency {
+				if other == key {
+					recency = append(recency[:i], recency[i+1:]...)
+					return
+				}
+			}
+		}
+
+		use := func(key string) {
+			drop(key)
+			recency = append(recency, key)
+		}
+
+		expected := []string{}
+		for i := 0; i < 20000; i++ {
+			word := words[r.Intn(len(words))]
+
+			switch r.Intn(4) {
+			case 0:
+				tree.Remove(word)
+				delete(values, string(word))
+				drop(string(word))
+			case 1:
+				found := tree.Search(word)
+				if value, ok := values[string(word)]; ok {
+					use(string(word))
+					if found != value {
+						t.Fatalf("Expected %q = %v, got %v", word, value, found)
+					}
+				} else if found != nil {
+					t.Fatalf("Expected %q to have been evicted", word)
+				}
+			default:
+				tree.Insert(word, i)
+				values[string(word)] = i
+				use(string(word))
+
+				if len(recency) > 100 {
+					expected = append(expected, recency[0]+"\x00")
+					delete(values, recency[0])
+					recency = recency[1:]
+				}
+			}
+		}
+
+		checkKeys(t, "Evicted", log.keys, expected)
+
+		if tree.size != int64(len(values)) || tree.usage.size != tree.size {
+			t.Errorf("Expected %d keys and usages, got %d and %d", len(values), tree.size, tree.usage.size)
+		}
+
+		checkSubtreeCounts(t, tree.root)
+
+		if err := tree.Validate(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// Trees bounded by bytes should keep the estimated bytes of their leaves within the limit,
+// and report evictions to their subscriptions like any other removal.
+func TestEvictByBytes(t *testing.T) {
+	words := loadAsset(t, "test/assets/words.txt")[:5000]
+	valueSize := func(value interface{}) int { return len(value.(string)) }
+	log := &evictionLog{}
+	tree := NewArtTreeWithOptions(Options{MaxBytes: 4096, ValueSize: valueSize, KeyTransform: bytes.ToLower, OnEvict: log.onEvict})
+
+	deleted := 0
+	tree.Watch(nil, func(event WatchEvent) {
+		if event.Type == WATCH_DELETE {
+			deleted++
+		}
+	})
+
+	for i, word := range words {
+		tree.Insert(word, string(word))
+
+		if tree.bytes > 4096 {
+			t.Fatalf("Expected at most 4096 bytes after %d insertions, got %d", i+1, tree.bytes)
+		}
+
+		if i%500 == 0 {
+			var total int64
+			tree.Each(func(n *ArtNode) {
+				if n.IsLeaf() {
+					total += tree.entryBytes(n.leaf().key, n.leaf().value, n.ext())
+				}
+			})
+
+			if total != tree.bytes {
+				t.Fatalf("Expected the estimated bytes to be %d, got %d", total, tree.bytes)
+			}
+		}
+	}
+
+	if deleted == 0 || deleted != len(log.keys) {
+		t.Errorf("Expected %d deletions to be reported, got %d", len(log.keys), deleted)
+	}
+
+	// A single key larger than the limit is still kept, since the key that was just inserted is never evicted.
+	large := string(make([]byte, 8192))
+	tree.Insert([]byte("large"), large)
+	if tree.size != 1 || tree.Search([]byte("large")) != large {
+		t.Error("Expected only the large key to be kept")
+	}
+}
+
+// The results of set operations between bounded trees should be bounded as well, with usages of their own.
+func TestSetOperationsKeepBounds(t *testing.T) {
+	a := NewArtTreeWithOptions(Options{MaxEntries: 3})
+	b := NewArtTree()
+
+	a.Insert([]byte("apple"), 1)
+	a.Insert([]byte("banana"), 2)
+	b.Insert([]byte("cherry"), 3)
+	b.Insert([]byte("date"), 4)
+
+	union := a.Union(b, nil)
+	if union.size != 3 || union.usage.size != 3 {
+		t.Fatalf("Expected the union to be evicted down to 3 keys, got %d keys and %d usages", union.size, union.usage.size)
+	}
+
+	union.Search([]byte("banana"))
+	if a.Search([]byte("apple")) != 1 || a.usage.size != 2 {
+		t.Error("Expected the first tree to be unchanged by uses of the union")
+	}
+
+	union.Insert([]byte("elderberry"), 5)
+	if union.Search([]byte("banana")) != 2 || union.size != 3 {
+		t.Error("Expected the most recently used key of the union to be kept")
+	}
+}
@@ -0,0 +1,205 @@
+package art
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+const (
+	// The bytes that begin the hashed encodings of leaves and inner nodes,
+	// which keep the encoding of a leaf from ever being mistaken for that of an inner node.
+	HASH_LEAF  = 0x00
+	HASH_INNER = 0x01
+)
+
+// Returns the hash of the keys and encoded values of the current node or the leaves below it.
+// The hash of a leaf covers its entire key and encoded value, while the hash of an inner node
+// covers the key byte and hash of each of its children, in key order.
+// Since the leaves below a node determine the shape of the subtree below it,
+// two subtrees with the same hash hold the same keys and values.
+// Inner nodes that do not carry a hash have theirs computed from their children.
+func (n *ArtNode) subtreeHash(hashValue func(value interface{}) []byte) [sha256.Size]byte {
+	if n.IsLeaf() {
+		l := n.leaf()
+
+		var length [binary.MaxVarintLen64]byte
+		h := sha256.New()
+		h.Write([]byte{HASH_LEAF})
+		h.Write(length[:binary.PutUvarint(length[:], uint64(len(l.key)))])
+		h.Write(l.key)
+		h.Write(hashValue(l.value))
+
+		var sum [sha256.Size]byte
+		h.Sum(sum[:0])
+		return sum
+	}
+
+	if augment := n.augment(); augment != nil {
+		return augment.hash
+	}
+
+	return n.childHash(hashValue)
+}
+
+// Returns the hash of the children of the current inner node, combined in key order.
+func (n *ArtNode) childHash(hashValue func(value interface{}) []byte) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write([]byte{HASH_INNER})
+
+	n.eachChild(func(key byte, child *ArtNode) {
+		hash := child.subtreeHash(hashValue)
+		h.Write([]byte{key})
+		h.Write(hash[:])
+	})
+
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+// Returns the hash of the current node as if the leaves with the passed in indexed keys had already been removed,
+// along with whether or not any leaf below the node remains.  The keys must be in key order, and must all be below
+// the node, which starts at the passed in depth.  Since the hash of an inner node does not cover its compressed path,
+// an inner node with a single remaining child has the hash of that child, as it would once the others were removed.
+// Only the nodes along the paths to the removed keys are hashed again.
+func (n *ArtNode) liveHash(hashValue func(value interface{}) []byte, depth int, removed [][]byte) ([sha256.Size]byte, bool) {
+	if len(removed) == 0 {
+		return n.subtreeHash(hashValue), true
+	}
+
+	if n.IsLeaf() {
+		return [sha256.Size]byte{}, false
+	}
+
+	keys, hashes := n.liveChildHashes(hashValue, depth, removed)
+	switch len(keys) {
+	case 0:
+		return [sha256.Size]byte{}, false
+	case 1:
+		return hashes[0], true
+	default:
+	}
+
+	return combineChildHashes(keys, hashes), true
+}
+
+// Returns the key bytes and hashes of the children of the current inner node that have any leaf below them
+// once the leaves with the passed in indexed keys are removed, as for liveHash.
+func (n *ArtNode) liveChildHashes(hashValue func(value interface{}) []byte, depth int, removed [][]byte) ([]byte, [][sha256.Size]byte) {
+	depth += int(n.inner().prefixLen)
+
+	keys := []byte{}
+	hashes := [][sha256.Size]byte{}
+	n.eachChild(func(key byte, child *ArtNode) {
+		// The removed keys are in key order, so those below each child follow those below the previous children.
+		below := 0
+		for below < len(removed) && removed[below][depth] == key {
+			below++
+		}
+
+		if hash, ok := child.liveHash(hashValue, depth+1, removed[:below]); ok {
+			keys = append(keys, key)
+			hashes = append(hashes, hash)
+		}
+
+		removed = removed[below:]
+	})
+
+	return keys, hashes
+}
+
+// Returns the hash of an inner node with children under the passed in key bytes that have the passed in hashes.
+func combineChildHashes(keys []byte, hashes [][sha256.Size]byte) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write([]byte{HASH_INNER})
+
+	for i, key := range keys {
+		h.Write([]byte{key})
+		h.Write(hashes[i][:])
+	}
+
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+// Returns the hash of every key and encoded value in the tree, as kept by trees configured with a HashValue,
+// which is the same for every tree that holds the same keys and values.  Keys that have expired are left out,
+// so the hash does not change when they are swept.
+// Empty trees and trees without a HashValue return the zero hash.
+func (t *ArtTree) RootHash() [sha256.Size]byte {
+	if t.root == nil || t.options.HashValue == nil {
+		return [sha256.Size]byte{}
+	}
+
+	hash, _ := t.root.liveHash(t.options.HashValue, 0, t.expiredKeys())
+	return hash
+}
+
+// Describes the subtree of a tree that holds every key that starts with a prefix,
+// as exchanged by replicas reconciling their trees.
+type SubtreeSummary struct {
+	// The bytes that every key in the subtree starts with, which extend the prefix the subtree was found with
+	// by the compressed path below it.  For a subtree that holds a single key, this is the entire indexed key.
+	Path []byte
+
+	// The hash of every key and encoded value in the subtree.
+	Hash [sha256.Size]byte
+
+	// The key bytes that follow the path of the subtree, along with the hash of the subtree below each of them,
+	// in key order.  Subtrees that hold a single key do not have any children.
+	Keys   []byte
+	Hashes [][sha256.Size]byte
+}
+
+// Returns a summary of the subtree that holds every key that starts with the passed in indexed prefix,
+// or nil if there are none or the tree does not have a HashValue.  Unlike other queries,
+// the KeyTransform of the tree is not applied to the prefix and its null bytes are not escaped,
+// since it is taken from the path of another summary.
+func (t *ArtTree) Summary(prefix []byte) *SubtreeSummary {
+	if t.options.HashValue == nil {
+		return nil
+	}
+
+	n, depth := t.indexedPrefixRoot(prefix)
+	if n == nil {
+		return nil
+	}
+
+	// Keys that have expired are left out, and so are the inner nodes that only have a single child without them,
+	// so that the summary describes the subtree as it will be once they are swept.
+	removed := keysWithPrefix(t.expiredKeys(), prefix)
+	path := append([]byte{}, prefix[:depth]...)
+
+	for {
+		if n.IsLeaf() {
+			if len(removed) > 0 {
+				return nil
+			}
+
+			return &SubtreeSummary{Path: append([]byte{}, n.leaf().key...), Hash: n.subtreeHash(t.options.HashValue)}
+		}
+
+		path = append(path, n.compressedPath(depth)...)
+		keys, hashes := n.liveChildHashes(t.options.HashValue, depth, removed)
+
+		switch len(keys) {
+		case 0:
+			return nil
+		case 1:
+			n = *n.findChild(keys[0])
+			path = append(path, keys[0])
+			depth = len(path)
+			removed = keysWithPrefix(removed, path)
+			continue
+		default:
+		}
+
+		summary := &SubtreeSummary{Path: path, Hash: combineChildHashes(keys, hashes), Keys: keys, Hashes: hashes}
+		if len(removed) == 0 {
+			summary.Hash = n.subtreeHash(t.options.HashValue)
+		}
+
+		return summary
+	}
+}
@@ -0,0 +1,221 @@
+package art
+
+import (
+	"bytes"
+	"container/heap"
+)
+
+// Defines the options that can be used to configure a new MergeIterator.
+// The zero value iterates over every key of every tree.
+type MergeOptions struct {
+	// Only visit keys that start with the passed in prefix.
+	Prefix []byte
+
+	// Only visit keys that are at least Start and less than End.
+	// A nil Start or End leaves the range unbounded on that side.
+	Start []byte
+	End   []byte
+
+	// Returns the value of a key that is in more than one of the trees,
+	// which is called with the leaves that hold it, in the order of the trees.
+	// The passed in slice is reused by the iterator, so it must not be retained.
+	// Defaults to nil, which keeps the value of the first of those trees.
+	Resolve func(nodes []*ArtNode) interface{}
+}
+
+// Defines an iterator that merges the keys of several trees into a single stream in key order,
+// such as the shards of a partitioned index.  The iterator keeps a cursor on each tree in a heap
+// ordered by their current keys, so moving to the next key only compares the keys of the cursors
+// that were positioned at the previous one.
+//
+// Each tree applies its own KeyTransform to the bounds, and the iterator orders the leaves by their indexed keys,
+// so the trees should index their keys the same way.  The trees must not be modified during the iteration.
+type MergeIterator struct {
+	options MergeOptions
+	sources []*mergeSource
+	heap    mergeHeap
+
+	// Whether or not the iterator has been positioned by Seek or Next.
+	positioned bool
+
+	// The sources whose cursors are positioned at the current key, the leaves that hold it,
+	// in the order of the trees, and its resolved value.
+	current []*mergeSource
+	nodes   []*ArtNode
+	value   interface{}
+}
+
+// Defines a tree that is merged by a MergeIterator, along with the bounds of the iteration
+// as they are indexed by the tree.
+type mergeSource struct {
+	cursor *Cursor
+	index  int
+
+	start  []byte
+	end    []byte
+	prefix []byte
+}
+
+// Creates and returns a new iterator over the keys of the passed in trees,
+// configured by the passed in options.  The iterator is positioned at its first key by the first call to Next.
+func NewMergeIterator(trees []*ArtTree, options MergeOptions) *MergeIterator {
+	it := &MergeIterator{options: options}
+
+	for i, t := range trees {
+		source := &mergeSource{cursor: t.Cursor(), index: i}
+
+		if options.Prefix != nil {
+			source.prefix = t.indexPrefix(options.Prefix)
+			source.start = t.indexKey(options.Prefix)
+		}
+
+		if options.Start != nil {
+			if start := t.indexKey(options.Start); bytes.Compare(start, source.start) > 0 {
+				source.start = start
+			}
+		}
+
+		if options.End != nil {
+			source.end = t.indexKey(options.End)
+		}
+
+		it.sources = append(it.sources, source)
+	}
+
+	return it
+}
+
+// Returns the current key of the iterator as it is indexed by the trees, or nil if it is not positioned at one.
+func (it *MergeIterator) Key() []byte {
+	if len(it.nodes) == 0 {
+		return nil
+	}
+
+	return it.nodes[0].Key()
+}
+
+// Returns the value of the current key of the iterator, as decided by the resolver of the iterator
+// if the key is in more than one of the trees, or nil if it is not positioned at one.
+func (it *MergeIterator) Value() interface{} {
+	return it.value
+}
+
+// Returns the leaves that hold the current key of the iterator, in the order of the trees.
+// The returned slice is reused by the iterator, so it is only valid until the iterator moves.
+func (it *MergeIterator) Nodes() []*ArtNode {
+	return it.nodes
+}
+
+// Positions the iterator at the first key within its bounds that is at least the passed in key,
+// and returns whether or not there is one.
+func (it *MergeIterator) Seek(key []byte) bool {
+	it.positioned = true
+	it.heap = it.heap[:0]
+
+	for _, source := range it.sources {
+		target := source.start
+		if key != nil {
+			if indexed := source.cursor.tree.indexKey(key); bytes.Compare(indexed, target) > 0 {
+				target = indexed
+			}
+		}
+
+		var found bool
+		if target == nil {
+			found = source.cursor.First()
+		} else {
+			found = source.cursor.seek(target)
+		}
+
+		if found && source.inBounds() {
+			it.heap = append(it.heap, source)
+		}
+	}
+
+	heap.Init(&it.heap)
+	return it.pop()
+}
+
+// Moves the iterator to the next key in key order, or to the first key if the iterator has not been positioned yet,
+// and returns whether or not there is one.
+func (it *MergeIterator) Next() bool {
+	if !it.positioned {
+		return it.Seek(nil)
+	}
+
+	// Move every cursor that was positioned at the current key past it.
+	for _, source := range it.current {
+		if source.cursor.Next() && source.inBounds() {
+			heap.Push(&it.heap, source)
+		}
+	}
+
+	return it.pop()
+}
+
+// Takes every cursor that is positioned at the smallest key off the heap, and resolves the value of that key.
+func (it *MergeIterator) pop() bool {
+	it.current = it.current[:0]
+	it.nodes = it.nodes[:0]
+	it.value = nil
+
+	if len(it.heap) == 0 {
+		return false
+	}
+
+	first := heap.Pop(&it.heap).(*mergeSource)
+	it.current = append(it.current, first)
+
+	for len(it.heap) > 0 && bytes.Equal(it.heap[0].cursor.node.leaf().key, first.cursor.node.leaf().key) {
+		it.current = append(it.current, heap.Pop(&it.heap).(*mergeSource))
+	}
+
+	for _, source := range it.current {
+		it.nodes = append(it.nodes, source.cursor.node)
+	}
+
+	if len(it.nodes) > 1 && it.options.Resolve != nil {
+		it.value = it.options.Resolve(it.nodes)
+	} else {
+		it.value = first.cursor.node.leaf().value
+	}
+
+	return true
+}
+
+// Returns whether or not the leaf the cursor of the source is positioned at lies within the bounds of the iteration.
+// Since the cursor moves in key order, the source is exhausted once it does not.
+func (s *mergeSource) inBounds() bool {
+	key := s.cursor.node.leaf().key
+
+	if s.prefix != nil && !bytes.HasPrefix(key, s.prefix) {
+		return false
+	}
+
+	return s.end == nil || bytes.Compare(key, s.end) < 0
+}
+
+// Defines a min-heap of the sources of a MergeIterator, ordered by the keys their cursors are positioned at,
+// and by the order of their trees among equal keys, for use with container/heap.
+type mergeHeap []*mergeSource
+
+func (h mergeHeap) Len() int { return len(h) }
+
+func (h mergeHeap) Less(i, j int) bool {
+	if cmp := bytes.Compare(h[i].cursor.node.leaf().key, h[j].cursor.node.leaf().key); cmp != 0 {
+		return cmp < 0
+	}
+
+	return h[i].index < h[j].index
+}
+
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *mergeHeap) Push(x interface{}) { *h = append(*h, x.(*mergeSource)) }
+
+func (h *mergeHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
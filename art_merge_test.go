@@ -0,0 +1,145 @@
+package art
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// Returns the keys of the passed in entries that lie within the passed in bounds, in key order,
+// where the keys are indexed and nil bounds are unbounded.
+func mergeExpectedKeys(entries map[string]int, prefix, start, end []byte) []string {
+	keys := []string{}
+	for key := range entries {
+		if !bytes.HasPrefix([]byte(key), prefix) || !inRange([]byte(key), start, end) {
+			continue
+		}
+
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// Merging trees should visit the union of their keys in key order, resolving keys that are in several of them,
+// within every combination of bounds.
+func TestMergeIteratorMatchesBruteForce(t *testing.T) {
+	words := loadAsset(t, "test/assets/words.txt")
+	r := rand.New(rand.NewSource(43))
+
+	trees := []*ArtTree{
+		NewArtTreeWithOptions(Options{PrefixMode: PREFIX_HYBRID}),
+		NewArtTreeWithOptions(Options{PrefixMode: PREFIX_OPTIMISTIC, Arena: true}),
+		NewArtTreeWithOptions(Options{PrefixMode: PREFIX_PESSIMISTIC}),
+	}
+
+	// Every key maps to the sum of the values it has in each tree, which is what the resolver computes.
+	entries := map[string]int{}
+	counts := map[string]int{}
+	for _, word := range words[:30000] {
+		for i, tree := range trees {
+			if r.Intn(3) == 0 {
+				tree.Insert(word, i+1)
+				entries[string(word)+"\x00"] += i + 1
+				counts[string(word)+"\x00"]++
+			}
+		}
+	}
+
+	resolve := func(nodes []*ArtNode) interface{} {
+		sum := 0
+		for _, n := range nodes {
+			sum += n.Value().(int)
+		}
+		return sum
+	}
+
+	for i := 0; i < 100; i++ {
+		var prefix []byte
+		if r.Intn(2) == 0 {
+			word := words[r.Intn(30000)]
+			prefix = word[:r.Intn(min(len(word), 3))]
+		}
+
+		start, end := randomBound(r, words[:30000]), randomBound(r, words[:30000])
+
+		var indexedStart, indexedEnd []byte
+		if start != nil {
+			indexedStart = append(append([]byte{}, start...), 0)
+		}
+
+		if end != nil {
+			indexedEnd = append(append([]byte{}, end...), 0)
+		}
+
+		expected := mergeExpectedKeys(entries, prefix, indexedStart, indexedEnd)
+
+		it := NewMergeIterator(trees, MergeOptions{Prefix: prefix, Start: start, End: end, Resolve: resolve})
+		j := 0
+		for it.Next() {
+			if j >= len(expected) || string(it.Key()) != expected[j] {
+				t.Fatalf("Unexpected key %q at %d with prefix %q in [%q, %q)", it.Key(), j, prefix, start, end)
+			}
+
+			if it.Value() != entries[expected[j]] || len(it.Nodes()) != counts[expected[j]] {
+				t.Fatalf("Unexpected value %v from %d trees for %q", it.Value(), len(it.Nodes()), it.Key())
+			}
+			j++
+		}
+
+		if j != len(expected) {
+			t.Fatalf("Expected %d keys with prefix %q in [%q, %q), got %d", len(expected), prefix, start, end, j)
+		}
+
+		// Seeking moves every tree to the key, without leaving the bounds.
+		if len(expected) > 0 {
+			target := expected[r.Intn(len(expected))]
+			if !it.Seek([]byte(target[:len(target)-1])) || string(it.Key()) != target {
+				t.Errorf("Expected seeking %q to find it, found %q", target, it.Key())
+			}
+		}
+
+		if it.Seek([]byte{}) != (len(expected) > 0) || (len(expected) > 0 && string(it.Key()) != expected[0]) {
+			t.Errorf("Expected seeking before the bounds to find the first key within them")
+		}
+	}
+}
+
+// Without a resolver, keys in several trees take the value of the first of them.
+func TestMergeIteratorKeepsFirstValue(t *testing.T) {
+	a := NewArtTree()
+	b := NewArtTree()
+	a.Insert([]byte("apple"), "a")
+	b.Insert([]byte("apple"), "b")
+	b.Insert([]byte("banana"), "b")
+
+	it := NewMergeIterator([]*ArtTree{b, a}, MergeOptions{})
+
+	if !it.Next() || string(it.Key()) != "apple\x00" || it.Value() != "b" || len(it.Nodes()) != 2 {
+		t.Errorf("Unexpected first entry %q = %v", it.Key(), it.Value())
+	}
+
+	if !it.Next() || string(it.Key()) != "banana\x00" || it.Value() != "b" || len(it.Nodes()) != 1 {
+		t.Errorf("Unexpected second entry %q = %v", it.Key(), it.Value())
+	}
+
+	if it.Next() || it.Key() != nil || it.Value() != nil {
+		t.Error("Expected the iterator to be exhausted")
+	}
+}
+
+func BenchmarkMergeIteratorWords(b *testing.B) {
+	trees := []*ArtTree{NewArtTree(), NewArtTree(), NewArtTree(), NewArtTree()}
+	for i, word := range loadAsset(b, "test/assets/words.txt") {
+		trees[i%len(trees)].Insert(word, word)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		it := NewMergeIterator(trees, MergeOptions{})
+		for it.Next() {
+		}
+	}
+}
@@ -3,7 +3,9 @@ package art
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"sort"
+	"unsafe"
 )
 
 const (
@@ -32,35 +34,156 @@ const (
 	NODE256MIN = 49
 	NODE256MAX = 256
 
+	// The default number of bytes of each compressed path that are stored in its inner node,
+	// which is also the number of bytes that fit inline without a separate allocation.
 	MAX_PREFIX_LEN = 10
 )
 
-// Defines a single ArtNode and its attributes.
+// Defines the header that is shared by every ArtNode.
+// Leaves and inner nodes are stored in distinct structures that begin with this header,
+// so that leaves do not pay for the attributes of inner nodes and vice versa.
+// The nodeType of the header determines which structure backs a particular ArtNode,
+// and its flags describe optional trailing attributes of that structure.
 type ArtNode struct {
-	// Internal Node Attributes
-	keys      []byte
-	children  []*ArtNode
+	nodeType uint8
+	flags    uint8
+}
+
+const (
+	// Set on leaves that are backed by an extLeaf rather than an artLeaf.
+	leafExtFlag = 1 << iota
+
+	// Set on inner nodes that are backed by an augmented structure rather than a plain one.
+	innerAugmentFlag
+
+	// Set on nodes that more than one tree, or more than one parent, may reference, such as the subtrees
+	// that set operations adopt.  Such nodes, and the nodes below them, are never changed or released:
+	// a tree that changes them replaces them by a copy of its own first.
+	sharedFlag
+)
+
+// Defines the attributes of a leaf node.
+type artLeaf struct {
+	ArtNode
+	key   []byte
+	value interface{}
+}
+
+// Defines the optional attributes of a leaf that only some trees make use of.
+type leafExt struct {
+	// The key that was passed to Insert, before the KeyTransform of the tree was applied.
+	originalKey []byte
+
+	// The score that was passed to InsertWithScore, as encoded by scoreKey, or zero if there is none.
+	score uint64
+
+	// The time the leaf expires at, as passed to InsertWithTTL, in nanoseconds since the Unix epoch,
+	// or zero if it does not expire.
+	expires int64
+
+	// How often and how recently the leaf has been used, for trees that evict keys, or nil for any other tree.
+	usage *leafUsage
+}
+
+// Defines a leaf node that carries optional attributes.
+// Since the header flags record whether a leaf has them,
+// leaves of trees that do not make use of them do not pay for them.
+type extLeaf struct {
+	artLeaf
+	ext leafExt
+}
+
+// Defines the attributes that are shared by all inner nodes.
+// The prefix holds the leading bytes of the compressed path, up to the prefix capacity of the tree,
+// while prefixLen holds the length of the entire compressed path.
+// Prefixes that fit within MAX_PREFIX_LEN bytes are stored inline, so they do not require an allocation,
+// while longer prefixes are stored in a separate buffer that is reused for as long as it is large enough.
+//
+// The count holds the number of leaves below the node, and the maxScore holds the highest score
+// of any leaf below the node, as encoded by scoreKey, or zero if none of them have a score.
+//
+// The attributes are ordered and sized so that they pack into as few words as possible,
+// which keeps a NODE4 within a 96 byte allocation size class.
+type innerNode struct {
+	ArtNode
+	size      uint16
+	prefixLen int32
+	inline    [MAX_PREFIX_LEN]byte
+	count     uint32
+	maxScore  uint64
 	prefix    []byte
-	prefixLen int
-	size      uint8
+}
 
-	// Leaf Node Attributes
-	key      []byte
-	keySize  uint64
-	value    interface{}
-	nodeType uint8
+// Defines the attributes of an inner node of type NODE4.
+type node4 struct {
+	innerNode
+	keys     [NODE4MAX]byte
+	children [NODE4MAX]*ArtNode
+}
+
+// Defines the attributes of an inner node of type NODE16.
+type node16 struct {
+	innerNode
+	keys     [NODE16MAX]byte
+	children [NODE16MAX]*ArtNode
+}
+
+// Defines the attributes of an inner node of type NODE48.
+type node48 struct {
+	innerNode
+	keys     [256]byte
+	children [NODE48MAX]*ArtNode
+}
+
+// Defines the attributes of an inner node of type NODE256.
+type node256 struct {
+	innerNode
+	children [NODE256MAX]*ArtNode
+}
+
+// Defines the optional attributes of an inner node that are computed from the leaves below it,
+// which only some trees make use of.
+type nodeAugment struct {
+	// The aggregate of the values below the node, as defined by the Aggregator of the tree.
+	aggregate interface{}
+
+	// The hash of the keys and values below the node, as computed by childHash.
+	hash [sha256.Size]byte
+}
+
+// Define inner nodes that carry optional attributes.
+// Since the header flags record whether an inner node has them,
+// inner nodes of trees that do not make use of them do not pay for them.
+type augmentedNode4 struct {
+	node4
+	augment nodeAugment
+}
+
+type augmentedNode16 struct {
+	node16
+	augment nodeAugment
+}
+
+type augmentedNode48 struct {
+	node48
+	augment nodeAugment
+}
+
+type augmentedNode256 struct {
+	node256
+	augment nodeAugment
 }
 
 func NewLeafNode(key []byte, value interface{}) *ArtNode {
 	newKey := make([]byte, len(key))
 	copy(newKey, key)
-	l := &ArtNode{
-		key:      newKey,
-		value:    value,
-		nodeType: LEAF,
+	l := &artLeaf{
+		ArtNode: ArtNode{nodeType: LEAF},
+		key:     newKey,
+		value:   value,
 	}
 
-	return l
+	return &l.ArtNode
 }
 
 // From the specification: The smallest node type can store up to 4 child
@@ -68,7 +191,9 @@ func NewLeafNode(key []byte, value interface{}) *ArtNode {
 // array of the same length for pointers. The keys and pointers
 // are stored at corresponding positions and the keys are sorted.
 func NewNode4() *ArtNode {
-	return &ArtNode{keys: make([]byte, NODE4MAX), children: make([]*ArtNode, NODE4MAX), nodeType: NODE4, prefix: make([]byte, MAX_PREFIX_LEN)}
+	n := &node4{}
+	n.nodeType = NODE4
+	return &n.ArtNode
 }
 
 // From the specification: This node type is used for storing between 5 and
@@ -78,7 +203,9 @@ func NewNode4() *ArtNode {
 // efﬁciently with binary search or, on modern hardware, with
 // parallel comparisons using SIMD instructions.
 func NewNode16() *ArtNode {
-	return &ArtNode{keys: make([]byte, NODE16MAX), children: make([]*ArtNode, NODE16MAX), nodeType: NODE16, prefix: make([]byte, MAX_PREFIX_LEN)}
+	n := &node16{}
+	n.nodeType = NODE16
+	return &n.ArtNode
 }
 
 // From the specification: As the number of entries in a node increases,
@@ -89,7 +216,9 @@ func NewNode16() *ArtNode {
 // pointers, this array stores indexes into a second array which
 // contains up to 48 pointers.
 func NewNode48() *ArtNode {
-	return &ArtNode{keys: make([]byte, 256), children: make([]*ArtNode, NODE48MAX), nodeType: NODE48, prefix: make([]byte, MAX_PREFIX_LEN)}
+	n := &node48{}
+	n.nodeType = NODE48
+	return &n.ArtNode
 }
 
 // From the specification: The largest node type is simply an array of 256
@@ -100,11 +229,193 @@ func NewNode48() *ArtNode {
 // null, this representation is also very space efﬁcient because
 // only pointers need to be stored.
 func NewNode256() *ArtNode {
-	return &ArtNode{children: make([]*ArtNode, NODE256MAX), nodeType: NODE256, prefix: make([]byte, MAX_PREFIX_LEN)}
+	n := &node256{}
+	n.nodeType = NODE256
+	return &n.ArtNode
+}
+
+// Returns the leaf structure that backs the current node.
+// The current node must be of type LEAF.
+func (n *ArtNode) leaf() *artLeaf {
+	return (*artLeaf)(unsafe.Pointer(n))
+}
+
+// Returns the optional attributes of the current leaf, or nil if it does not have any.
+func (n *ArtNode) ext() *leafExt {
+	if n.flags&leafExtFlag == 0 {
+		return nil
+	}
+
+	return &(*extLeaf)(unsafe.Pointer(n)).ext
+}
+
+// Carries over the attributes that are unset in the current attributes from the passed in attributes,
+// which belong to the leaf that the current attributes are replacing.
+func (ext *leafExt) inherit(from *leafExt) {
+	if ext.originalKey == nil {
+		ext.originalKey = from.originalKey
+	}
+
+	if ext.score == 0 {
+		ext.score = from.score
+	}
+
+	if ext.expires == 0 {
+		ext.expires = from.expires
+	}
+
+	if ext.usage == nil {
+		ext.usage = from.usage
+	}
+}
+
+// Returns the optional attributes of the current inner node, or nil if it does not have any.
+func (n *ArtNode) augment() *nodeAugment {
+	if n.nodeType == LEAF || n.flags&innerAugmentFlag == 0 {
+		return nil
+	}
+
+	switch n.nodeType {
+	case NODE4:
+		return &(*augmentedNode4)(unsafe.Pointer(n)).augment
+	case NODE16:
+		return &(*augmentedNode16)(unsafe.Pointer(n)).augment
+	case NODE48:
+		return &(*augmentedNode48)(unsafe.Pointer(n)).augment
+	case NODE256:
+		return &(*augmentedNode256)(unsafe.Pointer(n)).augment
+	default:
+	}
+
+	return nil
+}
+
+// Returns the attributes shared by all inner nodes for the current node.
+// The current node must not be of type LEAF.
+func (n *ArtNode) inner() *innerNode {
+	return (*innerNode)(unsafe.Pointer(n))
+}
+
+// Returns the structure that backs the current node, which must be of type NODE4.
+func (n *ArtNode) node4() *node4 {
+	return (*node4)(unsafe.Pointer(n))
+}
+
+// Returns the structure that backs the current node, which must be of type NODE16.
+func (n *ArtNode) node16() *node16 {
+	return (*node16)(unsafe.Pointer(n))
+}
+
+// Returns the structure that backs the current node, which must be of type NODE48.
+func (n *ArtNode) node48() *node48 {
+	return (*node48)(unsafe.Pointer(n))
+}
+
+// Returns the structure that backs the current node, which must be of type NODE256.
+func (n *ArtNode) node256() *node256 {
+	return (*node256)(unsafe.Pointer(n))
+}
+
+// Returns the keys of the current inner node as a slice of its fixed-size key array.
+// ArtNodes of type NODE256 and LEAF do not store any keys.
+func (n *ArtNode) keys() []byte {
+	switch n.nodeType {
+	case NODE4:
+		return n.node4().keys[:]
+	case NODE16:
+		return n.node16().keys[:]
+	case NODE48:
+		return n.node48().keys[:]
+	default:
+	}
+
+	return nil
+}
+
+// Returns the children of the current inner node as a slice of its fixed-size child array.
+// ArtNodes of type LEAF do not have any children.
+func (n *ArtNode) children() []*ArtNode {
+	switch n.nodeType {
+	case NODE4:
+		return n.node4().children[:]
+	case NODE16:
+		return n.node16().children[:]
+	case NODE48:
+		return n.node48().children[:]
+	case NODE256:
+		return n.node256().children[:]
+	default:
+	}
+
+	return nil
+}
+
+// Calls the passed in callback for every child of the current inner node, in key order,
+// along with the key byte that the child is stored under.
+func (n *ArtNode) eachChild(callback func(key byte, child *ArtNode)) {
+	switch n.nodeType {
+	case NODE4, NODE16:
+		keys, children := n.keys(), n.children()
+		for i := 0; i < int(n.inner().size); i++ {
+			callback(keys[i], children[i])
+		}
+	case NODE48:
+		n48 := n.node48()
+		for i, index := range n48.keys {
+			if index > 0 {
+				callback(byte(i), n48.children[index-1])
+			}
+		}
+	case NODE256:
+		for i, child := range n.node256().children {
+			if child != nil {
+				callback(byte(i), child)
+			}
+		}
+	default:
+	}
+}
+
+// Returns the first child of the current inner node that is stored under a key byte of at least
+// the passed in value, along with its key byte, or nil if there is none.
+func (n *ArtNode) nextChild(from int) (byte, *ArtNode) {
+	switch n.nodeType {
+	case NODE4, NODE16:
+		keys, children := n.keys(), n.children()
+		for i := 0; i < int(n.inner().size); i++ {
+			if int(keys[i]) >= from {
+				return keys[i], children[i]
+			}
+		}
+	case NODE48:
+		n48 := n.node48()
+		for i := from; i < len(n48.keys); i++ {
+			if index := n48.keys[i]; index > 0 {
+				return byte(i), n48.children[index-1]
+			}
+		}
+	case NODE256:
+		n256 := n.node256()
+		for i := from; i < len(n256.children); i++ {
+			if child := n256.children[i]; child != nil {
+				return byte(i), child
+			}
+		}
+	default:
+	}
+
+	return 0, nil
 }
 
 // Returns whether or not this particular art node is full.
-func (n *ArtNode) IsFull() bool { return uint16(n.size) == uint16(n.MaxSize()) }
+// Leaves can not hold any children, so they are always considered full.
+func (n *ArtNode) IsFull() bool {
+	if n.IsLeaf() {
+		return true
+	}
+
+	return int(n.inner().size) == n.MaxSize()
+}
 
 // Returns whether or not this particular art node is a leaf node.
 func (n *ArtNode) IsLeaf() bool { return n.nodeType == LEAF }
@@ -117,37 +428,38 @@ func (n *ArtNode) IsMatch(key []byte) bool {
 		return false
 	}
 
-	return bytes.Compare(n.key, key) == 0
+	return bytes.Compare(n.leaf().key, key) == 0
 
 }
 
 // Returns the number of bytes that differ between the passed in key
 // and the compressed path of the current node at the specified depth.
 func (n *ArtNode) PrefixMismatch(key []byte, depth int) int {
+	// Leaves do not have a compressed path.
+	if n.IsLeaf() {
+		return 0
+	}
+
+	inner := n.inner()
+	limit := min(int(inner.prefixLen), len(key)-depth)
 	index := 0
 
-	if n.prefixLen > MAX_PREFIX_LEN {
-		for ; index < MAX_PREFIX_LEN; index++ {
-			if key[depth+index] != n.prefix[index] {
-				return index
-			}
+	for ; index < limit && index < len(inner.prefix); index++ {
+		if key[depth+index] != inner.prefix[index] {
+			return index
 		}
+	}
 
-		minKey := n.Minimum().key
+	// The remainder of the compressed path is not stored in the node,
+	// so compare against the minimum leaf below it instead.
+	if index < limit {
+		minKey := n.Minimum().leaf().key
 
-		for ; index < n.prefixLen; index++ {
+		for ; index < limit; index++ {
 			if key[depth+index] != minKey[depth+index] {
 				return index
 			}
 		}
-
-	} else {
-
-		for ; index < n.prefixLen; index++ {
-			if key[depth+index] != n.prefix[index] {
-				return index
-			}
-		}
 	}
 
 	return index
@@ -158,9 +470,10 @@ func (n *ArtNode) Index(key byte) int {
 	case NODE4:
 		// ArtNodes of type NODE4 have a relatively simple lookup algorithm since
 		// they are of very small size:  Simply iterate over all keys and check to see if they match.
-		for i := uint8(0); i < n.size; i++ {
-			if n.keys[i] == key {
-				return int(i)
+		n4 := n.node4()
+		for i := 0; i < int(n4.size); i++ {
+			if n4.keys[i] == key {
+				return i
 			}
 		}
 		return -1
@@ -174,21 +487,17 @@ func (n *ArtNode) Index(key byte) int {
 		// instruction. Alternatively, binary search can be used
 		// if SIMD instructions are not available.
 		//
-		// TODO It is currently unclear if golang has intentions of supporting SIMD instructions
-		//      So until then, go-art will opt for Binary Search
-		index := sort.Search(int(n.size), func(i int) bool { return n.keys[uint8(i)] >= key })
-		if index < len(n.keys) && n.keys[index] == key {
-			return index
-		}
-
-		return -1
+		// go-art uses SSE2 instructions on amd64, and compares eight keys at a time
+		// within a 64 bit register on every other architecture.
+		n16 := n.node16()
+		return node16Index(&n16.keys, key, int(n16.size))
 	case NODE48:
 		// ArtNodes of type NODE48 store the indicies in which to access their children
 		// in the keys array which are byte-accessible by the desired key.
 		// However, when this key array initialized, it contains many 0 value indicies.
 		// In order to distinguish if a child actually exists, we increment this value
 		// during insertion and decrease it during retrieval.
-		index := int(n.keys[key])
+		index := int(n.node48().keys[key])
 		if index > 0 {
 			return int(index) - 1
 		}
@@ -199,324 +508,356 @@ func (n *ArtNode) Index(key byte) int {
 		// Since all of their keys are byte-addressable, we can simply index to the specific child with the key.
 		return int(key)
 	default:
-		return -1
 	}
 
 	return -1
 }
 
 // Returns a pointer to the child that matches the passed in key,
-// or nil if not present.
+// or a pointer to nil if not present.
 func (n *ArtNode) FindChild(key byte) **ArtNode {
+	if child := n.findChild(key); child != nil {
+		return child
+	}
+
 	var nullNode *ArtNode = nil
+	return &nullNode
+}
 
+// Returns a pointer to the child that matches the passed in key,
+// or nil if not present.  Unlike FindChild, this does not allocate
+// when the child is missing.
+func (n *ArtNode) findChild(key byte) **ArtNode {
 	if n == nil {
-		return &nullNode
+		return nil
 	}
 
 	switch n.nodeType {
 	case NODE4, NODE16, NODE48:
 		index := n.Index(key)
 		if index >= 0 {
-			return &n.children[index]
+			return &n.children()[index]
 		}
 
-		return &nullNode
-
 	case NODE256:
 		// NODE256 Types directly address their children with bytes
-		child := n.children[key]
-		if child != nil {
-			return &n.children[key]
+		n256 := n.node256()
+		if n256.children[key] != nil {
+			return &n256.children[key]
 		}
 
-		return &nullNode
-
 	default:
 	}
 
-	return &nullNode
+	return nil
 }
 
 // Adds the passed in node to the current ArtNode's children at the specified key.
 // The current node will grow if necessary in order for the insertion to take place.
-func (n *ArtNode) AddChild(key byte, node *ArtNode) {
+// Returns the node that now holds the children, which is a new node if the current node grew.
+// Callers must replace any references to the current node with the returned node.
+func (n *ArtNode) AddChild(key byte, node *ArtNode) *ArtNode {
+	return n.addChild(nil, key, node)
+}
+
+// Adds the passed in node to the current ArtNode's children at the specified key,
+// allocating from and releasing to the passed in tree's arena if the current node needs to grow.
+func (n *ArtNode) addChild(t *ArtTree, key byte, node *ArtNode) *ArtNode {
 	switch n.nodeType {
-	case NODE4:
+	case NODE4, NODE16:
 		if !n.IsFull() {
-			index := uint8(0)
-			for ; index < n.size; index++ {
-				if key < n.keys[index] {
-					break
-				}
-			}
+			inner := n.inner()
+			keys, children := n.keys(), n.children()
 
-			for i := n.size; i > index; i-- {
-				if n.keys[i-1] > key {
-					n.keys[i] = n.keys[i-1]
-					n.children[i] = n.children[i-1]
-				}
-			}
+			index := sort.Search(int(inner.size), func(i int) bool { return keys[i] > key })
 
-			n.keys[index] = key
-			n.children[index] = node
-			n.size += 1
-		} else {
-			n.grow()
-			n.AddChild(key, node)
-		}
+			copy(keys[index+1:inner.size+1], keys[index:inner.size])
+			copy(children[index+1:inner.size+1], children[index:inner.size])
 
-	case NODE16:
-		if !n.IsFull() {
-			index := uint8(sort.Search(int(n.size), func(i int) bool { return n.keys[byte(i)] >= key }))
-
-			for i := n.size; i > index; i-- {
-				if n.keys[i-1] > key {
-					n.keys[i] = n.keys[i-1]
-					n.children[i] = n.children[i-1]
-				}
-			}
-
-			n.keys[index] = key
-			n.children[index] = node
-			n.size += 1
+			keys[index] = key
+			children[index] = node
+			inner.size += 1
+			inner.count += node.subtreeCount()
 		} else {
-			n.grow()
-			n.AddChild(key, node)
+			return n.grow(t).addChild(t, key, node)
 		}
 
 	case NODE48:
 		if !n.IsFull() {
+			n48 := n.node48()
 			index := 0
 
-			for i := 0; i < len(n.children); i++ {
-				if n.children[index] != nil {
+			for i := 0; i < len(n48.children); i++ {
+				if n48.children[index] != nil {
 					index++
 				}
 			}
 
-			n.children[index] = node
-			n.keys[key] = byte(index + 1)
-			n.size += 1
+			n48.children[index] = node
+			n48.keys[key] = byte(index + 1)
+			n48.size += 1
+			n48.count += node.subtreeCount()
 		} else {
-			n.grow()
-			n.AddChild(key, node)
+			return n.grow(t).addChild(t, key, node)
 		}
 
 	case NODE256:
 		if !n.IsFull() {
-			n.children[key] = node
+			n256 := n.node256()
+			n256.children[key] = node
 
-			n.size += 1
+			n256.size += 1
+			n256.count += node.subtreeCount()
 		}
 	default:
 	}
+
+	return n
 }
 
 // The child indexed by the passed in key is removed if found
 // and the current ArtNode is shrunk if it falls below its minimum size.
-func (n *ArtNode) RemoveChild(key byte) {
+// Returns the node that now holds the remaining children, which differs from the current node if it shrunk.
+// Callers must replace any references to the current node with the returned node.
+func (n *ArtNode) RemoveChild(key byte) *ArtNode {
+	return n.removeChild(nil, key)
+}
+
+// The child indexed by the passed in key is removed if found,
+// allocating from and releasing to the passed in tree's arena if the current node needs to shrink.
+// The removed child itself is not released, since it is still owned by the caller.
+func (n *ArtNode) removeChild(t *ArtTree, key byte) *ArtNode {
+	// Leaves do not have any children to remove.
+	if n.IsLeaf() {
+		return n
+	}
+
+	inner := n.inner()
+
 	switch n.nodeType {
 	case NODE4, NODE16:
 		idx := n.Index(key)
 
-		n.keys[idx] = 0
-		n.children[idx] = nil
-
 		if idx >= 0 {
-			for i := uint8(idx); i < n.size-1; i++ {
-				n.keys[i] = n.keys[i+1]
-				n.children[i] = n.children[i+1]
-			}
+			keys, children := n.keys(), n.children()
+			inner.count -= children[idx].subtreeCount()
 
-		}
+			copy(keys[idx:inner.size-1], keys[idx+1:inner.size])
+			copy(children[idx:inner.size-1], children[idx+1:inner.size])
 
-		n.keys[n.size-1] = 0
-		n.children[n.size-1] = nil
+			keys[inner.size-1] = 0
+			children[inner.size-1] = nil
 
-		n.size -= 1
+			inner.size -= 1
+		}
 
 	case NODE48:
 		idx := n.Index(key)
 
 		if idx >= 0 {
-			child := n.children[idx]
+			n48 := n.node48()
+			child := n48.children[idx]
 			if child != nil {
-				n.children[idx] = nil
-				n.keys[key] = 0
-				n.size -= 1
+				n48.children[idx] = nil
+				n48.keys[key] = 0
+				n48.size -= 1
+				n48.count -= child.subtreeCount()
 			}
 		}
 
 	case NODE256:
-		idx := n.Index(key)
+		n256 := n.node256()
 
-		child := n.children[idx]
+		child := n256.children[key]
 		if child != nil {
-			n.children[idx] = nil
-			n.size -= 1
+			n256.children[key] = nil
+			n256.size -= 1
+			n256.count -= child.subtreeCount()
 		}
 
 	default:
 	}
 
-	if int(n.size) < n.MinSize() {
-		n.shrink()
+	if int(inner.size) < n.MinSize() {
+		return n.shrink(t)
 	}
+
+	return n
 }
 
-// Grows the current ArtNode to the next biggest size.
+// Grows the current ArtNode to the next biggest size and returns the grown node.
 // ArtNodes of type NODE4 will grow to NODE16
 // ArtNodes of type NODE16 will grow to NODE48.
 // ArtNodes of type NODE48 will grow to NODE256.
 // ArtNodes of type NODE256 will not grow, as they are the biggest type of ArtNodes
-func (n *ArtNode) grow() {
+// The grown node is allocated by the passed in tree, and the current node is released to its arena.
+// A nil tree allocates the grown node from the heap.
+func (n *ArtNode) grow(t *ArtTree) *ArtNode {
+	a := t.allocator()
+
 	switch n.nodeType {
 	case NODE4:
-		other := NewNode16()
-		other.copyMeta(n)
-		for i := 0; i < int(n.size); i++ {
-			other.keys[i] = n.keys[i]
-			other.children[i] = n.children[i]
-		}
+		n4 := n.node4()
+		other := t.newInnerNode(NODE16)
+		n16 := other.node16()
+		n16.copyMeta(&n4.innerNode)
 
-		n.replaceWith(other)
+		copy(n16.keys[:], n4.keys[:n4.size])
+		copy(n16.children[:], n4.children[:n4.size])
 
-	case NODE16:
-		other := NewNode48()
-		other.copyMeta(n)
-		for i := 0; i < int(n.size); i++ {
-			child := n.children[i]
-			if child != nil {
-				index := 0
+		a.free(n)
+		return other
 
-				for j := 0; j < len(other.children); j++ {
-					if other.children[index] != nil {
-						index++
-					}
-				}
-
-				other.children[index] = child
-				other.keys[n.keys[i]] = byte(index + 1)
-			}
+	case NODE16:
+		n16 := n.node16()
+		other := t.newInnerNode(NODE48)
+		n48 := other.node48()
+		n48.copyMeta(&n16.innerNode)
+
+		for i := 0; i < int(n16.size); i++ {
+			n48.children[i] = n16.children[i]
+			n48.keys[n16.keys[i]] = byte(i + 1)
 		}
 
-		n.replaceWith(other)
+		a.free(n)
+		return other
 
 	case NODE48:
-		other := NewNode256()
-		other.copyMeta(n)
-		for i := 0; i < len(n.keys); i++ {
-			child := *(n.FindChild(byte(i)))
-			if child != nil {
-				other.children[byte(i)] = child
+		n48 := n.node48()
+		other := t.newInnerNode(NODE256)
+		n256 := other.node256()
+		n256.copyMeta(&n48.innerNode)
+
+		for i := 0; i < len(n48.keys); i++ {
+			index := n48.keys[i]
+			if index > 0 {
+				n256.children[i] = n48.children[index-1]
 			}
 		}
 
-		n.replaceWith(other)
+		a.free(n)
+		return other
 
 	case NODE256:
 		// Can't get no bigger (⊙ ロ  ⊙;)
 	default:
 	}
+
+	return n
 }
 
-// Shrinks the current ArtNode to the next smallest size.
+// Shrinks the current ArtNode to the next smallest size and returns the shrunk node.
 // ArtNodes of type NODE256 will grow to NODE48
 // ArtNodes of type NODE48 will grow to NODE16.
 // ArtNodes of type NODE16 will grow to NODE4.
 // ArtNodes of type NODE4 will collapse into its first child.
 // If that child is not a leaf, it will concatenate its current prefix with that of its childs
 // before replacing itself.
-func (n *ArtNode) shrink() {
+// The shrunk node is allocated by the passed in tree, and the current node is released to its arena.
+// A nil tree allocates the shrunk node from the heap.
+func (n *ArtNode) shrink(t *ArtTree) *ArtNode {
+	a := t.allocator()
+
 	switch n.nodeType {
 	case NODE4:
 		// From the specification: If that node now has only one child, it is replaced by its child
 		// and the compressed path is adjusted.
-		other := n.children[0]
+		n4 := n.node4()
+		other := n4.children[0]
 
 		if !other.IsLeaf() {
-			currentPrefixLen := n.prefixLen
+			other = other.unshare(t)
 
-			if currentPrefixLen < MAX_PREFIX_LEN {
-				n.prefix[currentPrefixLen] = n.keys[0]
-				currentPrefixLen++
-			}
+			// The stored bytes of the child's new compressed path are the stored bytes of our own path,
+			// followed by the key of the child, followed by the stored bytes of the child's path.
+			// Since each stored prefix is only truncated once it reaches the prefix capacity,
+			// their concatenation is correct up to the prefix capacity as well.
+			otherInner := other.inner()
 
-			if currentPrefixLen < MAX_PREFIX_LEN {
-				childPrefixLen := min(other.prefixLen, MAX_PREFIX_LEN-currentPrefixLen)
-				memcpy(n.prefix[currentPrefixLen:], other.prefix, childPrefixLen)
-				currentPrefixLen += childPrefixLen
-			}
+			var buf [MAX_PREFIX_LEN]byte
+			path := append(buf[:0], n4.prefix...)
+			path = append(path, n4.keys[0])
+			path = append(path, otherInner.prefix...)
 
-			memcpy(other.prefix, n.prefix, min(currentPrefixLen, MAX_PREFIX_LEN))
-			other.prefixLen += n.prefixLen + 1
-		} else {
-			other.copyMeta(n)
+			otherInner.setPrefix(path, int(n4.prefixLen)+1+int(otherInner.prefixLen), t.prefixCapacity())
 		}
 
-		n.replaceWith(other)
+		a.free(n)
+		return other
 
 	case NODE16:
-		other := NewNode4()
-		other.copyMeta(n)
-		other.size = 0
-
-		for i := 0; i < len(other.keys); i++ {
-			other.keys[i] = n.keys[i]
-			other.children[i] = n.children[i]
-			other.size++
+		n16 := n.node16()
+		other := t.newInnerNode(NODE4)
+		n4 := other.node4()
+		n4.copyMeta(&n16.innerNode)
+		n4.size = 0
+
+		for i := 0; i < len(n4.keys); i++ {
+			n4.keys[i] = n16.keys[i]
+			n4.children[i] = n16.children[i]
+			n4.size++
 		}
 
-		n.replaceWith(other)
+		a.free(n)
+		return other
 
 	case NODE48:
-		other := NewNode16()
-		other.copyMeta(n)
-		other.size = 0
-
-		for i := 0; i < len(n.keys); i++ {
-			idx := n.keys[byte(i)]
+		n48 := n.node48()
+		other := t.newInnerNode(NODE16)
+		n16 := other.node16()
+		n16.copyMeta(&n48.innerNode)
+		n16.size = 0
+
+		for i := 0; i < len(n48.keys); i++ {
+			idx := n48.keys[i]
 			if idx > 0 {
-				child := n.children[idx-1]
+				child := n48.children[idx-1]
 				if child != nil {
-					other.children[other.size] = child
-					other.keys[other.size] = byte(i)
-					other.size++
+					n16.children[n16.size] = child
+					n16.keys[n16.size] = byte(i)
+					n16.size++
 				}
 			}
 		}
 
-		n.replaceWith(other)
+		a.free(n)
+		return other
 
 	case NODE256:
-		other := NewNode48()
-		other.copyMeta(n)
-		other.size = 0
-
-		for i := 0; i < len(n.children); i++ {
-			child := n.children[byte(i)]
+		n256 := n.node256()
+		other := t.newInnerNode(NODE48)
+		n48 := other.node48()
+		n48.copyMeta(&n256.innerNode)
+		n48.size = 0
+
+		for i := 0; i < len(n256.children); i++ {
+			child := n256.children[i]
 			if child != nil {
-				other.children[other.size] = child
-				other.keys[byte(i)] = byte(other.size + 1)
-				other.size++
+				n48.children[n48.size] = child
+				n48.keys[i] = byte(n48.size + 1)
+				n48.size++
 			}
 		}
 
-		n.replaceWith(other)
+		a.free(n)
+		return other
 
 	default:
 	}
+
+	return n
 }
 
 // Returns the longest number of bytes that match between the current node's prefix
 // and the passed in node at the specified depth.
+// Both the current node and the passed in node must be leaves.
 func (n *ArtNode) LongestCommonPrefix(other *ArtNode, depth int) int {
-	limit := min(len(n.key), len(other.key)) - depth
+	key, otherKey := n.leaf().key, other.leaf().key
+	limit := min(len(key), len(otherKey)) - depth
 
 	i := 0
 	for ; i < limit; i++ {
-		if n.key[depth+i] != other.key[depth+i] {
+		if key[depth+i] != otherKey[depth+i] {
 			return i
 		}
 	}
@@ -569,25 +910,27 @@ func (n *ArtNode) Minimum() *ArtNode {
 		return n
 
 	case NODE4, NODE16:
-		return n.children[0].Minimum()
+		return n.children()[0].Minimum()
 
 	case NODE48:
+		n48 := n.node48()
 		i := 0
 
-		for n.keys[i] == 0 {
+		for n48.keys[i] == 0 {
 			i++
 		}
 
-		child := n.children[n.keys[i]-1]
+		child := n48.children[n48.keys[i]-1]
 
 		return child.Minimum()
 
 	case NODE256:
+		n256 := n.node256()
 		i := 0
-		for n.children[i] == nil {
+		for n256.children[i] == nil {
 			i++
 		}
-		return n.children[i].Minimum()
+		return n256.children[i].Minimum()
 
 	default:
 	}
@@ -609,24 +952,26 @@ func (n *ArtNode) Maximum() *ArtNode {
 		return n
 
 	case NODE4, NODE16:
-		return n.children[n.size-1].Maximum()
+		return n.children()[n.inner().size-1].Maximum()
 
 	case NODE48:
-		i := len(n.keys) - 1
-		for n.keys[i] == 0 {
+		n48 := n.node48()
+		i := len(n48.keys) - 1
+		for n48.keys[i] == 0 {
 			i--
 		}
 
-		child := n.children[n.keys[i]-1]
+		child := n48.children[n48.keys[i]-1]
 		return child.Maximum()
 
 	case NODE256:
-		i := len(n.children) - 1
-		for i > 0 && n.children[byte(i)] == nil {
+		n256 := n.node256()
+		i := len(n256.children) - 1
+		for i > 0 && n256.children[i] == nil {
 			i--
 		}
 
-		return n.children[i].Maximum()
+		return n256.children[i].Maximum()
 
 	default:
 	}
@@ -634,17 +979,225 @@ func (n *ArtNode) Maximum() *ArtNode {
 	return n
 }
 
-// Replaces the current node with the passed in ArtNode.
-func (n *ArtNode) replaceWith(other *ArtNode) {
-	*n = *other
+// Returns the current node if it is not shared, or otherwise a copy of it that is allocated by the passed in tree,
+// which the caller must store in place of the current node before changing it.  Since the children of a copy
+// are then referenced by both the copy and the current node, they are marked as shared in turn,
+// so that a change to a shared subtree only copies the nodes on the path to the change.
+func (n *ArtNode) unshare(t *ArtTree) *ArtNode {
+	if n.flags&sharedFlag == 0 {
+		return n
+	}
+
+	if n.IsLeaf() {
+		return t.newLeaf(n.leaf().key, n.leaf().value, n.ext())
+	}
+
+	copied := t.newInnerNode(n.nodeType)
+	copied.inner().copyMeta(n.inner())
+
+	switch n.nodeType {
+	case NODE4:
+		copy(copied.node4().keys[:], n.node4().keys[:])
+		copy(copied.node4().children[:], n.node4().children[:])
+	case NODE16:
+		copy(copied.node16().keys[:], n.node16().keys[:])
+		copy(copied.node16().children[:], n.node16().children[:])
+	case NODE48:
+		copy(copied.node48().keys[:], n.node48().keys[:])
+		copy(copied.node48().children[:], n.node48().children[:])
+	case NODE256:
+		copy(copied.node256().children[:], n.node256().children[:])
+	default:
+	}
+
+	if augment, other := copied.augment(), n.augment(); augment != nil && other != nil {
+		*augment = *other
+	} else if augment != nil {
+		t.refreshAugment(&copied)
+	}
+
+	copied.eachChild(func(key byte, child *ArtNode) {
+		child.flags |= sharedFlag
+	})
+
+	return copied
 }
 
-// Copies the prefix and size metadata from the passed in ArtNode
+// Copies the prefix and size metadata from the passed in inner node
 // to the current node.
-func (n *ArtNode) copyMeta(other *ArtNode) {
+func (n *innerNode) copyMeta(other *innerNode) {
 	n.size = other.size
-	n.prefix = other.prefix
-	n.prefixLen = other.prefixLen
+	n.count = other.count
+	n.maxScore = other.maxScore
+	n.setPrefix(other.prefix, int(other.prefixLen), len(other.prefix))
+}
+
+// Returns the entire compressed path of the current inner node, which starts at the passed in depth of its keys.
+// Bytes of the path that are not stored in the node are loaded from the minimum leaf below it.
+func (n *ArtNode) compressedPath(depth int) []byte {
+	inner := n.inner()
+	if len(inner.prefix) >= int(inner.prefixLen) {
+		return inner.prefix
+	}
+
+	return n.Minimum().leaf().key[depth : depth+int(inner.prefixLen)]
+}
+
+// Sets the length of the compressed path of the current node to prefixLen,
+// and stores as many of the leading bytes of the passed in path as the passed in capacity allows.
+// The passed in path must contain at least that many bytes, and may overlap the current prefix.
+func (n *innerNode) setPrefix(path []byte, prefixLen int, capacity int) {
+	stored := min(prefixLen, capacity)
+
+	var prefix []byte
+	if stored <= len(n.inline) {
+		prefix = n.inline[:stored]
+	} else if cap(n.prefix) >= stored {
+		prefix = n.prefix[:stored]
+	} else {
+		prefix = make([]byte, stored)
+	}
+
+	copy(prefix, path[:stored])
+	n.prefix = prefix
+	n.prefixLen = int32(prefixLen)
+}
+
+// Returns the key of the given node, or nil if it is not a leaf.
+// Keys without a null byte are returned with the null byte the tree appended to them.
+func (n *ArtNode) Key() []byte {
+	if n.nodeType != LEAF {
+		return nil
+	}
+
+	stored := n.leaf().key
+	if bytes.IndexByte(stored, keyEscape) < 0 {
+		return stored
+	}
+
+	key := unescapeKey(stored)
+	if bytes.IndexByte(key, 0) < 0 {
+		key = append(key, 0)
+	}
+
+	return key
+}
+
+// Returns the key that was passed to Insert for the given node, before the KeyTransform of the tree
+// was applied, or nil if it is not a leaf.  For trees without a KeyTransform, this is the same as Key().
+func (n *ArtNode) OriginalKey() []byte {
+	if n.nodeType != LEAF {
+		return nil
+	}
+
+	if ext := n.ext(); ext != nil && ext.originalKey != nil {
+		return ext.originalKey
+	}
+
+	return n.Key()
+}
+
+// Returns the score of the given node, or zero if it is not a leaf or was not inserted with a score.
+func (n *ArtNode) Score() float64 {
+	if n.nodeType != LEAF {
+		return 0
+	}
+
+	if ext := n.ext(); ext != nil && ext.score != 0 {
+		return scoreFromKey(ext.score)
+	}
+
+	return 0
+}
+
+// Returns the number of leaves below the current node, or one if it is a leaf.
+func (n *ArtNode) subtreeCount() uint32 {
+	if n.nodeType == LEAF {
+		return 1
+	}
+
+	return n.inner().count
+}
+
+// Returns the number of leaves below the children of the current inner node
+// that are stored under key bytes less than the passed in key byte.
+func (n *ArtNode) countBelow(key byte) int64 {
+	var count int64
+
+	switch n.nodeType {
+	case NODE4, NODE16:
+		keys, children := n.keys(), n.children()
+		for i := 0; i < int(n.inner().size) && keys[i] < key; i++ {
+			count += int64(children[i].subtreeCount())
+		}
+	case NODE48:
+		n48 := n.node48()
+		for i := 0; i < int(key); i++ {
+			if index := n48.keys[i]; index > 0 {
+				count += int64(n48.children[index-1].subtreeCount())
+			}
+		}
+	case NODE256:
+		n256 := n.node256()
+		for i := 0; i < int(key); i++ {
+			if child := n256.children[i]; child != nil {
+				count += int64(child.subtreeCount())
+			}
+		}
+	default:
+	}
+
+	return count
+}
+
+// Returns the child of the current inner node that contains the leaf at the passed in position
+// among the leaves below the node, along with the position of that leaf among the leaves below the child.
+func (n *ArtNode) selectChild(position int64) (*ArtNode, int64) {
+	var selected *ArtNode
+
+	n.eachChild(func(key byte, child *ArtNode) {
+		if selected != nil {
+			return
+		}
+
+		if count := int64(child.subtreeCount()); position < count {
+			selected = child
+		} else {
+			position -= count
+		}
+	})
+
+	return selected, position
+}
+
+// Returns the highest score of the current node or any leaf below it, as encoded by scoreKey,
+// or zero if none of them have a score.
+func (n *ArtNode) subtreeScore() uint64 {
+	if n.nodeType != LEAF {
+		return n.inner().maxScore
+	}
+
+	if ext := n.ext(); ext != nil {
+		return ext.score
+	}
+
+	return 0
+}
+
+// Recomputes the highest score below the current inner node from its children.
+func (n *ArtNode) refreshMaxScore() {
+	if n.IsLeaf() {
+		return
+	}
+
+	var maxScore uint64
+	n.eachChild(func(key byte, child *ArtNode) {
+		if score := child.subtreeScore(); score > maxScore {
+			maxScore = score
+		}
+	})
+
+	n.inner().maxScore = maxScore
 }
 
 // Returns the value of the given node, or nil if it is not a leaf.
@@ -653,7 +1206,7 @@ func (n *ArtNode) Value() interface{} {
 		return nil
 	}
 
-	return n.value
+	return n.leaf().value
 }
 
 // Returns the smallest of the two passed in integers.
@@ -0,0 +1,56 @@
+package art
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"sort"
+)
+
+const (
+	// Every byte of a 64 bit word set to 0x01 and 0x7f respectively,
+	// used to compare eight keys of a Node16 at once.
+	swarOnes  = 0x0101010101010101
+	swarLow7s = 0x7f7f7f7f7f7f7f7f
+)
+
+// Returns the index of the passed in key within the first size keys of a Node16,
+// or -1 if the key is not present.
+//
+// This is a SWAR (SIMD within a register) variation of the parallel comparison described
+// by the specification.  The searched key is replicated into every byte of a 64 bit word,
+// and XORed against eight stored keys at a time so that matching keys become zero bytes.
+// Those zero bytes are then turned into a bit field, from which the index is taken
+// by counting trailing zeros.
+func node16IndexSWAR(keys *[NODE16MAX]byte, key byte, size int) int {
+	pattern := uint64(key) * swarOnes
+
+	for offset := 0; offset < NODE16MAX; offset += 8 {
+		word := binary.LittleEndian.Uint64(keys[offset:]) ^ pattern
+
+		// Sets the high bit of every byte that is zero, without any false positives
+		// caused by carries between bytes.
+		matches := ^(((word & swarLow7s) + swarLow7s) | word | swarLow7s)
+
+		if matches != 0 {
+			index := offset + bits.TrailingZeros64(matches)/8
+			if index < size {
+				return index
+			}
+
+			return -1
+		}
+	}
+
+	return -1
+}
+
+// Returns the index of the passed in key within the first size keys of a Node16,
+// or -1 if the key is not present, by binary searching the sorted keys.
+func node16IndexBinarySearch(keys *[NODE16MAX]byte, key byte, size int) int {
+	index := sort.Search(size, func(i int) bool { return keys[i] >= key })
+	if index < size && keys[index] == key {
+		return index
+	}
+
+	return -1
+}
@@ -0,0 +1,10 @@
+//go:build amd64 && !purego
+
+package art
+
+// Returns the index of the passed in key within the first size keys of a Node16,
+// or -1 if the key is not present.
+// Implemented in assembly with SSE2 instructions, as described by the specification.
+//
+//go:noescape
+func node16Index(keys *[NODE16MAX]byte, key byte, size int) int
@@ -0,0 +1,36 @@
+//go:build amd64 && !purego
+
+#include "textflag.h"
+
+// func node16Index(keys *[NODE16MAX]byte, key byte, size int) int
+TEXT ·node16Index(SB), NOSPLIT, $0-32
+	MOVQ    keys+0(FP), SI
+	MOVBQZX key+8(FP), AX
+	MOVQ    size+16(FP), CX
+
+	// Replicate the searched key into all 16 bytes of X0.
+	MOVQ      AX, X0
+	PUNPCKLBW X0, X0
+	PUNPCKLWL X0, X0
+	PSHUFD    $0, X0, X0
+
+	// Compare it to all 16 stored keys at once, and convert the result to a bit field.
+	MOVOU    (SI), X1
+	PCMPEQB  X0, X1
+	PMOVMSKB X1, DX
+
+	// Mask off the keys beyond the size of the node.
+	MOVL $1, BX
+	SHLL CX, BX
+	DECL BX
+	ANDL BX, DX
+	JZ   notfound
+
+	// Convert the bit field to an index by counting trailing zeros.
+	BSFL DX, DX
+	MOVQ DX, ret+24(FP)
+	RET
+
+notfound:
+	MOVQ $-1, ret+24(FP)
+	RET
@@ -0,0 +1,77 @@
+package art
+
+import (
+	"testing"
+)
+
+// Returns a Node16 key array filled with the passed in number of evenly spaced keys,
+// starting at the passed in key.
+func node16Keys(first byte, size int) *[NODE16MAX]byte {
+	keys := &[NODE16MAX]byte{}
+	for i := 0; i < size; i++ {
+		keys[i] = first + byte(i*3)
+	}
+
+	return keys
+}
+
+// The assembly, SWAR and binary search lookups should agree for every key and every node size.
+func TestNode16IndexImplementationsAgree(t *testing.T) {
+	for _, first := range []byte{0, 1, 7, 200} {
+		for size := 0; size <= NODE16MAX; size++ {
+			keys := node16Keys(first, size)
+
+			for key := 0; key < 256; key++ {
+				expected := node16IndexBinarySearch(keys, byte(key), size)
+
+				if actual := node16Index(keys, byte(key), size); actual != expected {
+					t.Errorf("node16Index(%d) with size %d: expected %d, got %d", key, size, expected, actual)
+				}
+
+				if actual := node16IndexSWAR(keys, byte(key), size); actual != expected {
+					t.Errorf("node16IndexSWAR(%d) with size %d: expected %d, got %d", key, size, expected, actual)
+				}
+			}
+		}
+	}
+}
+
+// Keys beyond the size of the node should never be found, even if they match the searched key.
+func TestNode16IndexIgnoresKeysBeyondSize(t *testing.T) {
+	keys := &[NODE16MAX]byte{}
+
+	if node16Index(keys, 0, 0) != -1 || node16IndexSWAR(keys, 0, 0) != -1 {
+		t.Error("Did not expect to find key in empty Node16")
+	}
+
+	if node16Index(keys, 0, 1) != 0 || node16IndexSWAR(keys, 0, 1) != 0 {
+		t.Error("Expected to find key at the first index")
+	}
+}
+
+// Prevents the compiler from optimizing away the benchmarked lookups.
+var node16IndexResult int
+
+// Looks up a mix of present and missing keys in a full Node16 with the passed in implementation.
+func benchmarkNode16Index(b *testing.B, index func(*[NODE16MAX]byte, byte, int) int) {
+	keys := node16Keys(1, NODE16MAX)
+	found := 0
+
+	for i := 0; i < b.N; i++ {
+		found += index(keys, byte(i&63), NODE16MAX)
+	}
+
+	node16IndexResult = found
+}
+
+func BenchmarkNode16Index(b *testing.B) {
+	benchmarkNode16Index(b, node16Index)
+}
+
+func BenchmarkNode16IndexSWAR(b *testing.B) {
+	benchmarkNode16Index(b, node16IndexSWAR)
+}
+
+func BenchmarkNode16IndexBinarySearch(b *testing.B) {
+	benchmarkNode16Index(b, node16IndexBinarySearch)
+}
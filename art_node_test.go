@@ -9,12 +9,12 @@ import (
 
 // A Leaf Node should be able to correctly determine if it is a match or not
 func TestIsMatch(t *testing.T) {
-	leaf := &ArtNode{key: []byte("test"), nodeType: LEAF}
+	leaf := NewLeafNode([]byte("test"), nil)
 	if !leaf.IsMatch([]byte("test")) {
 		t.Error("Unexpected match for leaf node")
 	}
 
-	leaf2 := &ArtNode{key: []byte("test2"), nodeType: LEAF}
+	leaf2 := NewLeafNode([]byte("test2"), nil)
 	if leaf2.IsMatch([]byte("test")) {
 		t.Error("Unexpected match for leaf2 node")
 	}
@@ -22,7 +22,7 @@ func TestIsMatch(t *testing.T) {
 
 // An ArtNode should be able to determine if it is a leaf or not
 func TestIsLeaf(t *testing.T) {
-	leaf := &ArtNode{nodeType: LEAF}
+	leaf := NewLeafNode(nil, nil)
 
 	if !leaf.IsLeaf() {
 		t.Error("Unable to successfully classify leaf")
@@ -39,7 +39,7 @@ func TestIsLeaf(t *testing.T) {
 
 // A Leaf Node should be able to retreive its value
 func TestValue(t *testing.T) {
-	leaf := &ArtNode{nodeType: LEAF, value: "foo"}
+	leaf := NewLeafNode(nil, "foo")
 
 	if leaf.Value() != "foo" {
 		t.Error("Unexpected value for leaf node")
@@ -57,8 +57,8 @@ func TestAddChildAndFindChildForAllNodeTypes(t *testing.T) {
 
 		// Fill it up
 		for i := 0; i < n.MaxSize(); i++ {
-			newChild := &ArtNode{value: byte(i)}
-			n.AddChild(byte(i), newChild)
+			newChild := NewLeafNode(nil, byte(i))
+			n = n.AddChild(byte(i), newChild)
 		}
 
 		// Expect to find all children for that paticular type of node
@@ -69,7 +69,7 @@ func TestAddChildAndFindChildForAllNodeTypes(t *testing.T) {
 				t.Error("Could not find child as expected")
 			}
 
-			if x.value.(byte) != byte(i) {
+			if x.Value().(byte) != byte(i) {
 				t.Error("Child value does not match as expected")
 			}
 		}
@@ -87,8 +87,8 @@ func TestIndexForAllNodeTypes(t *testing.T) {
 
 		// Fill it up
 		for i := 0; i < n.MaxSize(); i++ {
-			newChild := &ArtNode{value: byte(i)}
-			n.AddChild(byte(i), newChild)
+			newChild := NewLeafNode(nil, byte(i))
+			n = n.AddChild(byte(i), newChild)
 		}
 
 		for i := 0; i < n.MaxSize(); i++ {
@@ -103,9 +103,9 @@ func TestIndexForAllNodeTypes(t *testing.T) {
 func TestArtNode4AddChild1AndFindChild(t *testing.T) {
 	n := NewNode4()
 	n2 := NewNode4()
-	n.AddChild('a', n2)
+	n = n.AddChild('a', n2)
 
-	if n.size < 1 {
+	if n.inner().size < 1 {
 		t.Error("Size is incorrect after adding one child to empty Node4")
 	}
 
@@ -121,18 +121,18 @@ func TestArtNode4AddChildTwicePreserveSorted(t *testing.T) {
 	n := NewNode4()
 	n2 := NewNode4()
 	n3 := NewNode4()
-	n.AddChild('b', n2)
-	n.AddChild('a', n3)
+	n = n.AddChild('b', n2)
+	n = n.AddChild('a', n3)
 
-	if n.size < 2 {
+	if n.inner().size < 2 {
 		t.Error("Size is incorrect after adding one child to empty Node4")
 	}
 
-	if n.keys[0] != 'a' {
+	if n.keys()[0] != 'a' {
 		t.Error("Unexpected key value for first key index")
 	}
 
-	if n.keys[1] != 'b' {
+	if n.keys()[1] != 'b' {
 		t.Error("Unexpected key value for second key index")
 	}
 }
@@ -143,15 +143,15 @@ func TestArtNode4AddChild4PreserveSorted(t *testing.T) {
 	n := NewNode4()
 
 	for i := 4; i > 0; i-- {
-		n.AddChild(byte(i), NewNode4())
+		n = n.AddChild(byte(i), NewNode4())
 	}
 
-	if n.size < 4 {
+	if n.inner().size < 4 {
 		t.Error("Size is incorrect after adding one child to empty Node4")
 	}
 
 	expectedKeys := []byte{1, 2, 3, 4}
-	if bytes.Compare(n.keys, expectedKeys) != 0 {
+	if bytes.Compare(n.keys(), expectedKeys) != 0 {
 		t.Error("Unexpected key sequence")
 	}
 }
@@ -160,15 +160,15 @@ func TestArtNode4AddChild4PreserveSorted(t *testing.T) {
 func TestArtNode16AddChild16PreserveSorted(t *testing.T) {
 	n := NewNode16()
 	for i := 16; i > 0; i-- {
-		n.AddChild(byte(i), NewNode4())
+		n = n.AddChild(byte(i), NewNode4())
 	}
 
-	if n.size < 16 {
+	if n.inner().size < 16 {
 		t.Error("Size is incorrect after adding one child to empty Node4")
 	}
 
 	for i := 0; i < 16; i++ {
-		if n.keys[i] != byte(i+1) {
+		if n.keys()[i] != byte(i+1) {
 			t.Error("Unexpected key sequence")
 		}
 	}
@@ -182,7 +182,7 @@ func TestGrow(t *testing.T) {
 	for i := range nodes {
 		node := nodes[i]
 
-		node.grow()
+		node = node.grow(nil)
 		if node.nodeType != expectedTypes[i] {
 			t.Error("Unexpected node type after growing")
 		}
@@ -199,15 +199,15 @@ func TestShrink(t *testing.T) {
 
 		for j := 0; j < node.MinSize(); j++ {
 			if node.nodeType != NODE4 {
-				node.AddChild(byte(i), NewNode4())
+				node = node.AddChild(byte(i), NewNode4())
 			} else {
 				// We want to test that the Node4 reduces itself to
 				// A LEAF if its only child is a leaf
-				node.AddChild(byte(i), &ArtNode{nodeType: LEAF})
+				node = node.AddChild(byte(i), NewLeafNode(nil, nil))
 			}
 		}
 
-		node.shrink()
+		node = node.shrink(nil)
 		if node.nodeType != expectedTypes[i] {
 			t.Error("Unexpected node type after shrinking")
 		}
@@ -217,7 +217,7 @@ func TestShrink(t *testing.T) {
 func TestNewLeafNode(t *testing.T) {
 	key := []byte{'a', 'r', 't' }
 	value := "tree"
-	l := NewLeafNode(key, value)
+	l := NewLeafNode(key, value).leaf()
 
 	if &l.key == &key {
 		t.Errorf("Address of key byte slices should not match.")
@@ -0,0 +1,527 @@
+package art
+
+import (
+	"errors"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// The kinds of states of a compiled Pattern.
+	PATTERN_MATCH = iota
+	PATTERN_BYTE
+	PATTERN_SPLIT
+
+	// The deterministic state of a pattern that can no longer match, whatever bytes follow.
+	PATTERN_DEAD = -1
+)
+
+var (
+	// Returned when compiling a pattern that uses a regular expression feature Match does not support,
+	// such as word boundaries.
+	ErrUnsupportedPattern = errors.New("art: unsupported pattern")
+)
+
+// Defines a pattern compiled into a nondeterministic finite automaton over the bytes of keys,
+// which Match runs in lockstep with its traversal of the tree.
+// Patterns always match entire keys.
+//
+// The automaton is converted into a deterministic one lazily, as bytes are consumed,
+// and the deterministic states are cached in the pattern.  As a result, a Pattern must not
+// be used by multiple goroutines at once.
+type Pattern struct {
+	states []patternState
+	start  int
+
+	dfa          []*dfaState
+	dfaIndex     map[string]int32
+	initialState int32
+}
+
+// Defines a single state of a compiled Pattern.
+// States of kind PATTERN_BYTE consume a byte in their set and move to out,
+// while states of kind PATTERN_SPLIT move to both out and out1 without consuming a byte.
+type patternState struct {
+	kind uint8
+	set  [4]uint64
+	out  int
+	out1 int
+}
+
+// Defines a cached deterministic state of a Pattern, which is the set of pattern states it stands for.
+// The next state for each byte is stored offset by one, so that zero means it has not been computed yet.
+type dfaState struct {
+	states  []int
+	accepts bool
+	next    [256]int32
+}
+
+// Compiles the passed in shell glob into a Pattern.
+// Supports * for any sequence of characters, ? for any single character,
+// character classes such as [a-z], [!a-z] and [[:alpha:]], and backslash escapes.
+// Unlike path globs, * and ? also match the / character.
+func CompileGlob(glob string) (*Pattern, error) {
+	var expr strings.Builder
+	expr.WriteString("(?s)")
+
+	literal := 0
+	flush := func(i int) {
+		expr.WriteString(regexp.QuoteMeta(glob[literal:i]))
+	}
+
+	for i := 0; i < len(glob); i++ {
+		switch glob[i] {
+		case '*', '?':
+			flush(i)
+			if glob[i] == '*' {
+				expr.WriteString(".*")
+			} else {
+				expr.WriteString(".")
+			}
+			literal = i + 1
+		case '\\':
+			flush(i)
+			literal = i + 1
+			i++
+		case '[':
+			end := classEnd(glob, i)
+			if end < 0 {
+				continue
+			}
+
+			flush(i)
+			class := glob[i+1 : end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + strings.Replace(class, `\`, `\\`, -1) + "]")
+			literal = end + 1
+			i = end
+		default:
+		}
+	}
+
+	flush(len(glob))
+
+	return CompileRegexp(expr.String())
+}
+
+// Returns the index of the ] that closes the character class of the passed in glob that opens at the passed in index,
+// or -1 if it is never closed.  A ] immediately after the opening bracket, or its negation, is a literal,
+// and so is one that closes a named class such as [:alpha:] within it.
+func classEnd(glob string, open int) int {
+	i := open + 1
+	if i < len(glob) && glob[i] == '!' {
+		i++
+	}
+
+	if i < len(glob) && glob[i] == ']' {
+		i++
+	}
+
+	for ; i < len(glob); i++ {
+		switch {
+		case glob[i] == ']':
+			return i
+		case strings.HasPrefix(glob[i:], "[:"):
+			if end := strings.Index(glob[i+2:], ":]"); end >= 0 {
+				i += end + 3
+			}
+		default:
+		}
+	}
+
+	return -1
+}
+
+// Compiles the passed in regular expression into a Pattern.
+// Supports the syntax of the regexp package, including literals, character classes, alternation,
+// grouping and repetition, but not word boundaries.  Since patterns always match entire keys,
+// the ^ and $ anchors are accepted at the start and end of the expression, where they have no effect,
+// and anywhere else return ErrUnsupportedPattern.
+//
+// Keys are matched as UTF-8 text.  Bytes that can never be part of valid UTF-8, which are continuation bytes
+// that do not follow a lead byte, and the bytes 0xC0, 0xC1 and 0xF5 to 0xFF, are matched one at a time
+// by . and by negated character classes.  Unlike the regexp package, lead bytes of truncated, overlong
+// or surrogate encodings are not matched by any pattern.
+func CompileRegexp(expr string) (*Pattern, error) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Pattern{states: []patternState{{kind: PATTERN_MATCH}}}
+
+	p.start, err = p.compile(re.Simplify(), 0, true, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Adds the passed in state to the pattern, and returns its index.
+func (p *Pattern) addState(state patternState) int {
+	p.states = append(p.states, state)
+	return len(p.states) - 1
+}
+
+// Returns a new state that consumes a byte between lo and hi, inclusive, and moves to next.
+func (p *Pattern) addByteRange(lo byte, hi byte, next int) int {
+	state := patternState{kind: PATTERN_BYTE, out: next}
+	for b := int(lo); b <= int(hi); b++ {
+		state.set[b/64] |= 1 << uint(b%64)
+	}
+
+	return p.addState(state)
+}
+
+// Returns a new state that moves to both of the passed in states.
+func (p *Pattern) addSplit(out int, out1 int) int {
+	return p.addState(patternState{kind: PATTERN_SPLIT, out: out, out1: out1})
+}
+
+// Returns whether or not the passed in regular expression matches without consuming any bytes,
+// as the empty expression and anchors do.
+func zeroWidth(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText:
+		return true
+	default:
+	}
+
+	return false
+}
+
+// Compiles the passed in regular expression so that it moves to the passed in state once it has matched,
+// and returns the state it starts at.  States are compiled back to front, so that every state
+// can refer to the state that follows it.  The passed in flags tell whether or not the expression
+// can only match at the start and at the end of a key, where anchors are the only places they can be.
+func (p *Pattern) compile(re *syntax.Regexp, next int, atStart bool, atEnd bool) (int, error) {
+	var err error
+
+	switch re.Op {
+	case syntax.OpNoMatch:
+		return p.addState(patternState{kind: PATTERN_BYTE, out: next}), nil
+
+	case syntax.OpEmptyMatch:
+		return next, nil
+
+	case syntax.OpBeginLine, syntax.OpBeginText:
+		if !atStart {
+			return 0, ErrUnsupportedPattern
+		}
+
+		return next, nil
+
+	case syntax.OpEndLine, syntax.OpEndText:
+		if !atEnd {
+			return 0, ErrUnsupportedPattern
+		}
+
+		return next, nil
+
+	case syntax.OpLiteral:
+		for i := len(re.Rune) - 1; i >= 0; i-- {
+			r := re.Rune[i]
+			if re.Flags&syntax.FoldCase == 0 {
+				next = p.compileRuneRanges([]rune{r, r}, next)
+				continue
+			}
+
+			ranges := []rune{r, r}
+			for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+				ranges = append(ranges, f, f)
+			}
+			next = p.compileRuneRanges(ranges, next)
+		}
+
+		return next, nil
+
+	case syntax.OpCharClass:
+		return p.compileRuneRanges(re.Rune, next), nil
+
+	case syntax.OpAnyChar:
+		return p.compileRuneRanges([]rune{0, unicode.MaxRune}, next), nil
+
+	case syntax.OpAnyCharNotNL:
+		return p.compileRuneRanges([]rune{0, '\n' - 1, '\n' + 1, unicode.MaxRune}, next), nil
+
+	case syntax.OpCapture:
+		return p.compile(re.Sub[0], next, atStart, atEnd)
+
+	case syntax.OpStar, syntax.OpPlus:
+		split := p.addSplit(0, next)
+
+		// A repeated body also matches after and before its other repetitions.
+		body, err := p.compile(re.Sub[0], split, false, false)
+		if err != nil {
+			return 0, err
+		}
+
+		p.states[split].out = body
+		if re.Op == syntax.OpPlus {
+			return body, nil
+		}
+
+		return split, nil
+
+	case syntax.OpQuest:
+		body, err := p.compile(re.Sub[0], next, atStart, atEnd)
+		if err != nil {
+			return 0, err
+		}
+
+		return p.addSplit(body, next), nil
+
+	case syntax.OpConcat:
+		// Each expression is at the start or end of the concatenation if only zero-width ones come before or after it.
+		starts := make([]bool, len(re.Sub))
+		for i := range re.Sub {
+			starts[i] = atStart
+			atStart = atStart && zeroWidth(re.Sub[i])
+		}
+
+		for i := len(re.Sub) - 1; i >= 0 && err == nil; i-- {
+			next, err = p.compile(re.Sub[i], next, starts[i], atEnd)
+			atEnd = atEnd && zeroWidth(re.Sub[i])
+		}
+
+		return next, err
+
+	case syntax.OpAlternate:
+		start, err := p.compile(re.Sub[len(re.Sub)-1], next, atStart, atEnd)
+		for i := len(re.Sub) - 2; i >= 0 && err == nil; i-- {
+			var alternative int
+			alternative, err = p.compile(re.Sub[i], next, atStart, atEnd)
+			start = p.addSplit(alternative, start)
+		}
+
+		return start, err
+
+	default:
+	}
+
+	return 0, ErrUnsupportedPattern
+}
+
+// Compiles the passed in pairs of inclusive rune ranges into states that match
+// the UTF-8 encoding of any rune within them, and returns the state they start at.
+// Ranges that include utf8.RuneError also match any single byte that can never be part of valid UTF-8,
+// so that a multibyte encoding is never matched one byte at a time.
+func (p *Pattern) compileRuneRanges(ranges []rune, next int) int {
+	sequences := [][][2]byte{}
+	invalid := false
+
+	for i := 0; i+1 < len(ranges); i += 2 {
+		sequences = utf8Sequences(ranges[i], ranges[i+1], sequences)
+		if ranges[i] <= utf8.RuneError && utf8.RuneError <= ranges[i+1] {
+			invalid = true
+		}
+	}
+
+	if invalid {
+		sequences = append(sequences, [][2]byte{{0x80, 0xC1}}, [][2]byte{{0xF5, 0xFF}})
+	}
+
+	start := -1
+	for _, sequence := range sequences {
+		state := next
+		for i := len(sequence) - 1; i >= 0; i-- {
+			state = p.addByteRange(sequence[i][0], sequence[i][1], state)
+		}
+
+		if start < 0 {
+			start = state
+		} else {
+			start = p.addSplit(state, start)
+		}
+	}
+
+	if start < 0 {
+		return p.addState(patternState{kind: PATTERN_BYTE, out: next})
+	}
+
+	return start
+}
+
+// Appends sequences of byte ranges to the passed in sequences that together match
+// exactly the UTF-8 encodings of the runes between lo and hi, inclusive, and returns the extended sequences.
+func utf8Sequences(lo rune, hi rune, sequences [][][2]byte) [][][2]byte {
+	// Surrogates have no UTF-8 encoding.
+	if lo < 0xD800 && hi > 0xDFFF {
+		sequences = utf8Sequences(lo, 0xD7FF, sequences)
+		return utf8Sequences(0xE000, hi, sequences)
+	}
+
+	if lo >= 0xD800 && lo <= 0xDFFF {
+		lo = 0xE000
+	}
+
+	if hi >= 0xD800 && hi <= 0xDFFF {
+		hi = 0xD7FF
+	}
+
+	if hi > unicode.MaxRune {
+		hi = unicode.MaxRune
+	}
+
+	if lo > hi {
+		return sequences
+	}
+
+	// Split ranges whose runes have encodings of different lengths.
+	for _, max := range []rune{0x7F, 0x7FF, 0xFFFF} {
+		if lo <= max && hi > max {
+			sequences = utf8Sequences(lo, max, sequences)
+			return utf8Sequences(max+1, hi, sequences)
+		}
+	}
+
+	if hi <= 0x7F {
+		return append(sequences, [][2]byte{{byte(lo), byte(hi)}})
+	}
+
+	// Split ranges until every continuation byte can vary independently of the bytes before it.
+	for i := uint(1); i < 4; i++ {
+		mask := rune(1)<<(6*i) - 1
+		if lo&^mask != hi&^mask {
+			if lo&mask != 0 {
+				sequences = utf8Sequences(lo, lo|mask, sequences)
+				return utf8Sequences((lo|mask)+1, hi, sequences)
+			}
+
+			if hi&mask != mask {
+				sequences = utf8Sequences(lo, (hi&^mask)-1, sequences)
+				return utf8Sequences(hi&^mask, hi, sequences)
+			}
+		}
+	}
+
+	var loBytes, hiBytes [utf8.UTFMax]byte
+	n := utf8.EncodeRune(loBytes[:], lo)
+	utf8.EncodeRune(hiBytes[:], hi)
+
+	sequence := make([][2]byte, n)
+	for i := 0; i < n; i++ {
+		sequence[i] = [2]byte{loBytes[i], hiBytes[i]}
+	}
+
+	return append(sequences, sequence)
+}
+
+// Returns the states that are reachable from the passed in states without consuming a byte,
+// excluding split states, in ascending order.
+func (p *Pattern) closure(states []int) []int {
+	seen := make([]bool, len(p.states))
+
+	var visit func(s int)
+	visit = func(s int) {
+		if seen[s] {
+			return
+		}
+		seen[s] = true
+
+		if p.states[s].kind == PATTERN_SPLIT {
+			visit(p.states[s].out)
+			visit(p.states[s].out1)
+		}
+	}
+
+	for _, s := range states {
+		visit(s)
+	}
+
+	closed := []int{}
+	for s, ok := range seen {
+		if ok && p.states[s].kind != PATTERN_SPLIT {
+			closed = append(closed, s)
+		}
+	}
+
+	return closed
+}
+
+// Returns the deterministic state for the passed in set of pattern states, adding it to the cache if needed.
+func (p *Pattern) dfaStateFor(states []int) int32 {
+	if len(states) == 0 {
+		return PATTERN_DEAD
+	}
+
+	key := make([]byte, 0, len(states)*4)
+	for _, s := range states {
+		key = append(key, byte(s>>24), byte(s>>16), byte(s>>8), byte(s))
+	}
+
+	if id, ok := p.dfaIndex[string(key)]; ok {
+		return id
+	}
+
+	state := &dfaState{states: states}
+	for _, s := range states {
+		if p.states[s].kind == PATTERN_MATCH {
+			state.accepts = true
+		}
+	}
+
+	id := int32(len(p.dfa))
+	p.dfa = append(p.dfa, state)
+	p.dfaIndex[string(key)] = id
+
+	return id
+}
+
+// Returns the deterministic state the pattern is in before it has consumed any bytes.
+func (p *Pattern) initial() int32 {
+	if p.dfaIndex == nil {
+		p.dfaIndex = make(map[string]int32)
+		p.initialState = p.dfaStateFor(p.closure([]int{p.start}))
+	}
+
+	return p.initialState
+}
+
+// Returns the deterministic state the pattern is in after consuming the passed in byte from the passed in state,
+// or PATTERN_DEAD if no key with the bytes consumed so far can match.
+func (p *Pattern) step(id int32, b byte) int32 {
+	state := p.dfa[id]
+	if next := state.next[b]; next != 0 {
+		return next - 1
+	}
+
+	targets := []int{}
+	for _, s := range state.states {
+		nfa := &p.states[s]
+		if nfa.kind == PATTERN_BYTE && nfa.set[b/64]&(1<<(b%64)) != 0 {
+			targets = append(targets, nfa.out)
+		}
+	}
+
+	next := int32(PATTERN_DEAD)
+	if len(targets) > 0 {
+		next = p.dfaStateFor(p.closure(targets))
+	}
+
+	state.next[b] = next + 1
+	return next
+}
+
+// Returns whether or not the passed in deterministic state matches the bytes consumed so far.
+func (p *Pattern) accepts(id int32) bool {
+	return p.dfa[id].accepts
+}
+
+// Returns whether or not the pattern matches the entire passed in key.
+func (p *Pattern) MatchKey(key []byte) bool {
+	state := p.initial()
+	for _, b := range key {
+		if state = p.step(state, b); state == PATTERN_DEAD {
+			return false
+		}
+	}
+
+	return p.accepts(state)
+}
@@ -0,0 +1,238 @@
+package art
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+)
+
+// Compiled regular expressions should agree with the regexp package on entire keys.
+func TestCompileRegexpAgreesWithRegexp(t *testing.T) {
+	exprs := []string{
+		`abc`, `a.c`, `a*b+c?`, `(ab|cd)*e`, `[a-c]+x`, `[^a-c]x`, `\d{2,3}`, `(?i)HeLLo`, `^foo$`,
+		`caf.`, `[à-ÿ]+`, `.*`, `x|`, `(?i)straße`, `\w+@\w+\.com`,
+	}
+	inputs := []string{
+		"", "abc", "adc", "aabbc", "bc", "ababcde", "abcde", "cde", "aax", "bbbcx", "dx", "ax", "12", "123", "1234",
+		"hello", "HELLO", "foo", "café", "cafe", "caf\xff", "éèà", "x", "STRASSE", "STRAßE", "bob@example.com", "a\nb",
+	}
+
+	for _, expr := range exprs {
+		pattern, err := CompileRegexp(expr)
+		if err != nil {
+			t.Fatalf("Unexpected error compiling %q: %v", expr, err)
+		}
+
+		re := regexp.MustCompile(`^(?:` + expr + `)$`)
+		for _, input := range inputs {
+			if pattern.MatchKey([]byte(input)) != re.MatchString(input) {
+				t.Errorf("Expected %q matching %q to be %v", expr, input, re.MatchString(input))
+			}
+		}
+	}
+
+	if _, err := CompileRegexp(`\bword`); err != ErrUnsupportedPattern {
+		t.Errorf("Expected ErrUnsupportedPattern, got %v", err)
+	}
+
+	// Anchors are only supported where they cannot rule out any key.
+	for _, expr := range []string{`a^b`, `a$b`, `(?m)a^b`, `(?m)a$b`, `a(^b|c)`, `(a$)*`, `(^a)+`, `x^`, `$x`} {
+		if _, err := CompileRegexp(expr); err != ErrUnsupportedPattern {
+			t.Errorf("Expected ErrUnsupportedPattern compiling %q, got %v", expr, err)
+		}
+	}
+
+	for _, expr := range []string{`^a$`, `^^a$$`, `(?m)^a$`, `(^a|^b)c`, `a(b$|c$)`, `^(a$)?`, `^`, `$`, `^$`} {
+		pattern, err := CompileRegexp(expr)
+		if err != nil {
+			t.Fatalf("Unexpected error compiling %q: %v", expr, err)
+		}
+
+		re := regexp.MustCompile(`^(?:` + expr + `)$`)
+		for _, input := range append(inputs, "a", "b", "ac", "bc", "ab") {
+			if pattern.MatchKey([]byte(input)) != re.MatchString(input) {
+				t.Errorf("Expected %q matching %q to be %v", expr, input, re.MatchString(input))
+			}
+		}
+	}
+
+	if _, err := CompileRegexp(`(`); err == nil {
+		t.Error("Expected an error for an invalid expression")
+	}
+}
+
+// Compiled globs should match wildcards, classes and escapes.
+func TestCompileGlob(t *testing.T) {
+	cases := []struct {
+		glob  string
+		input string
+		match bool
+	}{
+		{"*.txt", "notes.txt", true},
+		{"*.txt", "dir/notes.txt", true},
+		{"*.txt", "notes.md", false},
+		{"a?c", "abc", true},
+		{"a?c", "ac", false},
+		{"a?c", "aéc", true},
+		{"[a-c]x", "bx", true},
+		{"[!a-c]x", "bx", false},
+		{"[!a-c]x", "dx", true},
+		{"[]]", "]", true},
+		{"[!]]", "a", true},
+		{`\*`, "*", true},
+		{`\*`, "a", false},
+		{"a.b", "a.b", true},
+		{"a.b", "axb", false},
+		{"[abc", "[abc", true},
+		{"(a)+", "(a)+", true},
+		{"[[:alpha:]]", "a", true},
+		{"[[:alpha:]]", "1", false},
+		{"[[:alpha:]]", "[", false},
+		{"[![:digit:]]x", "ax", true},
+		{"[![:digit:]]x", "1x", false},
+		{"[[:digit:]_]*", "_x", true},
+		{"[[:digit:]_]*", "a1", false},
+		{"[]a]", "]", true},
+		{"[[]", "[", true},
+		{"[[:x]", "[", true},
+		{"[[:x]", ":", true},
+	}
+
+	for _, c := range cases {
+		pattern, err := CompileGlob(c.glob)
+		if err != nil {
+			t.Fatalf("Unexpected error compiling %q: %v", c.glob, err)
+		}
+
+		if pattern.MatchKey([]byte(c.input)) != c.match {
+			t.Errorf("Expected %q matching %q to be %v", c.glob, c.input, c.match)
+		}
+	}
+}
+
+// Patterns should agree with the regexp package on keys with multibyte runes and bytes that are not valid UTF-8,
+// never matching part of a multibyte rune as a rune of its own.
+func TestPatternsAgreeWithRegexpOnMultibyteKeys(t *testing.T) {
+	cases := []struct {
+		glob string
+		expr string
+	}{
+		{"?", `.`},
+		{"??", `..`},
+		{"caf?", `caf.`},
+		{"caf??", `caf..`},
+		{"caf*", `caf.*`},
+		{"*é", `.*é`},
+		{"[!a]", `[^a]`},
+		{"[!a][!a]", `[^a][^a]`},
+		{"[é]?", `[é].`},
+		{"", `\x{FFFD}`},
+		{"", `.?.?.`},
+	}
+	inputs := []string{
+		"", "é", "éé", "café", "cafe", "cafée", "caf\xff", "caf\x80", "caf\x80\x80", "caf\xc0\xaf", "\xf5\x80",
+		"日本", "日本語", "😀", "a😀", "\uFFFD", "\xff", "\xff\xfe", "é\xbf",
+	}
+
+	for _, c := range cases {
+		re := regexp.MustCompile(`^(?s:` + c.expr + `)$`)
+
+		patterns := []*Pattern{}
+		if pattern, err := CompileRegexp(c.expr); err != nil {
+			t.Fatalf("Unexpected error compiling %q: %v", c.expr, err)
+		} else {
+			patterns = append(patterns, pattern)
+		}
+
+		if c.glob != "" {
+			pattern, err := CompileGlob(c.glob)
+			if err != nil {
+				t.Fatalf("Unexpected error compiling %q: %v", c.glob, err)
+			}
+			patterns = append(patterns, pattern)
+		}
+
+		for _, pattern := range patterns {
+			for _, input := range inputs {
+				if pattern.MatchKey([]byte(input)) != re.MatchString(input) {
+					t.Errorf("Expected %q (%q) matching %q to be %v", c.expr, c.glob, input, re.MatchString(input))
+				}
+			}
+		}
+	}
+}
+
+// Match should visit exactly the keys the pattern matches, in key order, in every prefix mode.
+func TestMatchAgreesWithMatchKey(t *testing.T) {
+	modes := []PrefixMode{PREFIX_HYBRID, PREFIX_OPTIMISTIC, PREFIX_PESSIMISTIC}
+	words := loadAsset(t, "test/assets/words.txt")
+	for i := range words {
+		words[i] = bytes.TrimSuffix(words[i], []byte("\n"))
+	}
+
+	globs := []string{"*ing", "un*able", "?", "[xyz]*[aeiou]", "photo*", "*q[!u]*", "zzzzzz*"}
+
+	for _, mode := range modes {
+		tree := NewArtTreeWithOptions(Options{PrefixMode: mode})
+		for _, word := range words {
+			tree.Insert(word, word)
+		}
+
+		for _, glob := range globs {
+			pattern, _ := CompileGlob(glob)
+
+			expected := 0
+			for _, word := range words {
+				if pattern.MatchKey(word) {
+					expected++
+				}
+			}
+
+			count := 0
+			var previous []byte
+			tree.Match(pattern, func(n *ArtNode) {
+				word := n.Value().([]byte)
+				if !pattern.MatchKey(word) {
+					t.Errorf("Unexpected match %q for %q", word, glob)
+				}
+
+				if previous != nil && bytes.Compare(previous, word) >= 0 {
+					t.Errorf("Expected matches in key order, got %q after %q", word, previous)
+				}
+
+				previous = word
+				count++
+			})
+
+			if count != expected {
+				t.Errorf("Expected %d matches for %q in mode %d, got %d", expected, glob, mode, count)
+			}
+		}
+	}
+}
+
+func BenchmarkMatchWordsGlob(b *testing.B) {
+	tree := NewArtTree()
+	for _, word := range loadAsset(b, "test/assets/words.txt") {
+		tree.Insert(bytes.TrimSuffix(word, []byte("\n")), word)
+	}
+
+	pattern, _ := CompileGlob("un*able")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.Match(pattern, func(n *ArtNode) {})
+	}
+}
+
+func BenchmarkMatchWordsRegexpScan(b *testing.B) {
+	words := loadAsset(b, "test/assets/words.txt")
+	re := regexp.MustCompile(`^un.*able\n$`)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, word := range words {
+			re.Match(word)
+		}
+	}
+}
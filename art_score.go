@@ -0,0 +1,73 @@
+package art
+
+import (
+	"bytes"
+	"math"
+)
+
+// Returns the passed in score as an unsigned integer with the same order, which is never zero,
+// so that zero can stand for the absence of a score.
+func scoreKey(score float64) uint64 {
+	bits := math.Float64bits(score)
+	if bits&(1<<63) != 0 {
+		bits = ^bits
+	} else {
+		bits |= 1 << 63
+	}
+
+	// Only the negative NaN with every bit set encodes to zero.
+	if bits == 0 {
+		bits = 1
+	}
+
+	return bits
+}
+
+// Returns the score encoded by scoreKey.
+func scoreFromKey(key uint64) float64 {
+	if key&(1<<63) != 0 {
+		key &^= 1 << 63
+	} else {
+		key = ^key
+	}
+
+	return math.Float64frombits(key)
+}
+
+// Defines a node that is waiting to be visited by a best-first search, ordered by the highest score below it.
+// Since the nodes waiting to be visited never overlap, nodes with equal scores are ordered by their minimum keys,
+// which returns leaves with equal scores in key order.
+type scoreHeapItem struct {
+	node   *ArtNode
+	score  uint64
+	minKey []byte
+}
+
+// Returns a new item for the passed in node.
+func newScoreHeapItem(node *ArtNode) scoreHeapItem {
+	return scoreHeapItem{node: node, score: node.subtreeScore(), minKey: node.Minimum().leaf().key}
+}
+
+// Defines a max-heap of nodes ordered by the highest score below them, for use with container/heap.
+type scoreHeap []scoreHeapItem
+
+func (h scoreHeap) Len() int { return len(h) }
+
+func (h scoreHeap) Less(i, j int) bool {
+	if h[i].score != h[j].score {
+		return h[i].score > h[j].score
+	}
+
+	return bytes.Compare(h[i].minKey, h[j].minKey) < 0
+}
+
+func (h scoreHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *scoreHeap) Push(x interface{}) { *h = append(*h, x.(scoreHeapItem)) }
+
+func (h *scoreHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
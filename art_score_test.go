@@ -0,0 +1,38 @@
+package art
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// Encoded scores should keep their order, never be zero, and decode to their original value.
+func TestScoreKeyOrderAndRoundTrip(t *testing.T) {
+	scores := []float64{math.Inf(-1), -math.MaxFloat64, -1, -math.SmallestNonzeroFloat64, 0, math.SmallestNonzeroFloat64, 1, math.MaxFloat64, math.Inf(1)}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		scores = append(scores, r.NormFloat64()*1e6)
+	}
+
+	sort.Float64s(scores)
+
+	for i, score := range scores {
+		key := scoreKey(score)
+		if key == 0 {
+			t.Errorf("Unexpected zero encoding for %v", score)
+		}
+
+		if scoreFromKey(key) != score {
+			t.Errorf("Unexpected decoded score %v for %v", scoreFromKey(key), score)
+		}
+
+		if i > 0 && scores[i-1] < score && scoreKey(scores[i-1]) >= key {
+			t.Errorf("Expected %v to encode below %v", scores[i-1], score)
+		}
+	}
+
+	if scoreKey(math.Float64frombits(math.MaxUint64)) == 0 {
+		t.Error("Unexpected zero encoding for NaN")
+	}
+}
@@ -0,0 +1,379 @@
+package art
+
+// Defines a set operation between two trees, which walks both of them together, node by node.
+// Subtrees that only one of the trees has a key under are adopted by the result as a whole,
+// or skipped, without comparing any of their keys, while leaves that both trees share are resolved.
+// Leaves that have expired are treated as if they had already been removed from their trees.
+type setOperation struct {
+	first  *ArtTree
+	second *ArtTree
+	result *ArtTree
+
+	// The indexed keys of the leaves of each tree that have expired, in key order.
+	expiredFirst  [][]byte
+	expiredSecond [][]byte
+
+	// Whether or not keys that are only in the first tree, only in the second tree,
+	// or in both of them are kept in the result.
+	keepFirst  bool
+	keepSecond bool
+	keepBoth   bool
+
+	// Returns the value kept for a key that is in both trees, or nil to keep the value of the first tree.
+	resolve func(n, other *ArtNode) interface{}
+}
+
+// Defines a child of a node during the walk of a set operation.
+// Nodes whose remaining path does not end at the depth of the walk are visited as their own single child,
+// stored under the next byte of their path, so that they line up with the children of the other tree.
+type setChild struct {
+	node *ArtNode
+
+	// The remaining path of a node that is visited as its own child, which is nil for actual children.
+	path    []byte
+	virtual bool
+}
+
+// Returns a new tree holding every key of the current tree or the passed in tree.
+// The value of a key that is in both trees is decided by the passed in resolver, which is called
+// with the leaf of the current tree and the leaf of the passed in tree, and keeps the value of
+// the current tree if it is nil.
+//
+// Both trees must index their keys the same way, and the new tree is configured like the current tree.
+// Keys that have expired are treated as absent from their tree, by this and every other set operation.
+// Subtrees that are only in one of the trees are adopted by the new tree as they are, so merging a few keys
+// into a large tree only allocates the nodes on their paths.  Adopted nodes are shared between the trees
+// until one of them changes them, which copies the nodes on the path to the change first, so any of the trees
+// can still be changed without affecting the others.  Since a change marks the nodes below the copies as shared,
+// trees that share nodes must not be changed while any of them is used concurrently.
+//
+// Subtrees of the passed in tree are only adopted if both trees store compressed paths the same way and
+// neither keeps aggregates or hashes, and no subtree is adopted by a tree that evicts keys, or that holds keys
+// below it that have expired.  Such subtrees are copied instead.
+func (t *ArtTree) Union(other *ArtTree, resolve func(n, other *ArtNode) interface{}) *ArtTree {
+	return t.setOperation(other, &setOperation{keepFirst: true, keepSecond: true, keepBoth: true, resolve: resolve})
+}
+
+// Returns a new tree holding every key that is in both the current tree and the passed in tree,
+// with values decided by the passed in resolver as for Union.
+// Subtrees that are only in one of the trees are skipped as a whole.
+func (t *ArtTree) Intersect(other *ArtTree, resolve func(n, other *ArtNode) interface{}) *ArtTree {
+	return t.setOperation(other, &setOperation{keepBoth: true, resolve: resolve})
+}
+
+// Returns a new tree holding every key of the current tree that is not in the passed in tree.
+// Subtrees that are only in the current tree are adopted as for Union,
+// and subtrees that are only in the passed in tree are skipped as a whole.
+func (t *ArtTree) Difference(other *ArtTree) *ArtTree {
+	return t.setOperation(other, &setOperation{keepFirst: true})
+}
+
+// Performs the passed in set operation between the current tree and the passed in tree.
+func (t *ArtTree) setOperation(other *ArtTree, op *setOperation) *ArtTree {
+	op.first, op.second = t, other
+	op.expiredFirst, op.expiredSecond = t.expiredKeys(), other.expiredKeys()
+	op.result = NewArtTreeWithOptions(t.options)
+	op.result.scored = t.scored || other.scored
+
+	op.result.root = op.merge(t.root, other.root, 0)
+	if op.result.root != nil {
+		op.result.size = int64(op.result.root.subtreeCount())
+	}
+
+	if t.expiring() || other.expiring() {
+		op.result.indexExpiries()
+	}
+
+	if op.result.bounded() {
+		op.result.indexUsage()
+		op.result.evict(nil)
+	}
+
+	return op.result
+}
+
+// Returns the bytes of the current node from the passed in depth up to its children:
+// the rest of its key for a leaf, or its compressed path for an inner node.
+func (n *ArtNode) remainingPath(depth int) []byte {
+	if n.IsLeaf() {
+		return n.leaf().key[depth:]
+	}
+
+	return n.compressedPath(depth)
+}
+
+// Returns the result of the set operation for the passed in nodes, which both start at the passed in depth.
+func (op *setOperation) merge(a *ArtNode, b *ArtNode, depth int) *ArtNode {
+	var pathA, pathB []byte
+	if a != nil {
+		pathA = a.remainingPath(depth)
+	}
+
+	if b != nil {
+		pathB = b.remainingPath(depth)
+	}
+
+	return op.mergeAt(a, pathA, b, pathB, depth)
+}
+
+// Returns the result of the set operation for the passed in nodes, whose remaining paths
+// from the passed in depth are also passed in.
+func (op *setOperation) mergeAt(a *ArtNode, pathA []byte, b *ArtNode, pathB []byte, depth int) *ArtNode {
+	if a != nil && a.IsLeaf() && op.first.expired(a) {
+		a = nil
+	}
+
+	if b != nil && b.IsLeaf() && op.second.expired(b) {
+		b = nil
+	}
+
+	if a == nil {
+		if !op.keepSecond || b == nil {
+			return nil
+		}
+
+		return op.clone(op.second, b, pathB, depth)
+	}
+
+	if b == nil {
+		if !op.keepFirst {
+			return nil
+		}
+
+		return op.clone(op.first, a, pathA, depth)
+	}
+
+	mismatch := 0
+	for mismatch < len(pathA) && mismatch < len(pathB) && pathA[mismatch] == pathB[mismatch] {
+		mismatch++
+	}
+
+	// Leaves whose keys match are in both trees.
+	if a.IsLeaf() && b.IsLeaf() && mismatch == len(pathA) && mismatch == len(pathB) {
+		if !op.keepBoth {
+			return nil
+		}
+
+		value := a.leaf().value
+		if op.resolve != nil {
+			value = op.resolve(a, b)
+		}
+
+		return op.newLeaf(a.leaf().key, value, a.ext())
+	}
+
+	// Otherwise, the children of both nodes below their common path are merged by their key bytes.
+	depth += mismatch
+	keysA, childrenA := setChildren(a, pathA, mismatch)
+	keysB, childrenB := setChildren(b, pathB, mismatch)
+
+	keys := []byte{}
+	children := []*ArtNode{}
+	add := func(key byte, child *ArtNode) {
+		if child != nil {
+			keys = append(keys, key)
+			children = append(children, child)
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(keysA) || j < len(keysB) {
+		switch {
+		case j == len(keysB) || (i < len(keysA) && keysA[i] < keysB[j]):
+			if op.keepFirst {
+				add(keysA[i], op.clone(op.first, childrenA[i].node, childrenA[i].pathAt(depth+1), depth+1))
+			}
+			i++
+
+		case i == len(keysA) || keysB[j] < keysA[i]:
+			if op.keepSecond {
+				add(keysB[j], op.clone(op.second, childrenB[j].node, childrenB[j].pathAt(depth+1), depth+1))
+			}
+			j++
+
+		default:
+			add(keysA[i], op.mergeAt(childrenA[i].node, childrenA[i].pathAt(depth+1), childrenB[j].node, childrenB[j].pathAt(depth+1), depth+1))
+			i++
+			j++
+		}
+	}
+
+	return op.result.newInnerNodeWithChildren(pathA[:mismatch], keys, children, depth-mismatch)
+}
+
+// Returns the children of the passed in node once the passed in number of bytes of its remaining path
+// have been consumed, along with the key bytes they are stored under.
+func setChildren(n *ArtNode, path []byte, consumed int) ([]byte, []setChild) {
+	if consumed < len(path) {
+		return path[consumed : consumed+1], []setChild{{node: n, path: path[consumed+1:], virtual: true}}
+	}
+
+	keys := []byte{}
+	children := []setChild{}
+	n.eachChild(func(key byte, child *ArtNode) {
+		keys = append(keys, key)
+		children = append(children, setChild{node: child})
+	})
+
+	return keys, children
+}
+
+// Returns the remaining path of the child, which starts at the passed in depth.
+func (c setChild) pathAt(depth int) []byte {
+	if c.virtual {
+		return c.path
+	}
+
+	return c.node.remainingPath(depth)
+}
+
+// Returns a new leaf of the result tree with the passed in key, value and optional attributes.
+// Usages are not shared between trees, so leaves of a result that evicts keys are given a usage of their own.
+func (op *setOperation) newLeaf(key []byte, value interface{}, ext *leafExt) *ArtNode {
+	if ext == nil && !op.result.bounded() {
+		return op.result.newLeaf(key, value, nil)
+	}
+
+	copied := leafExt{}
+	if ext != nil {
+		copied = *ext
+	}
+
+	copied.usage = nil
+	if op.result.bounded() {
+		copied.usage = &leafUsage{}
+	}
+
+	return op.result.newLeaf(key, value, &copied)
+}
+
+// Returns the passed in node of the passed in tree and every node below it as they are kept by the result tree,
+// with the passed in remaining path from the passed in depth.  The node is adopted as it is whenever it can be,
+// in which case it is marked as shared, and is otherwise copied.  Leaves that have expired are left out of the copy,
+// so nil is returned if every leaf below the node has expired.
+func (op *setOperation) clone(tree *ArtTree, n *ArtNode, path []byte, depth int) *ArtNode {
+	if op.adoptable(tree, n, path, depth) {
+		n.flags |= sharedFlag
+		return n
+	}
+
+	if n.IsLeaf() {
+		if tree.expired(n) {
+			return nil
+		}
+
+		return op.newLeaf(n.leaf().key, n.leaf().value, n.ext())
+	}
+
+	// The children are collected on the stack, since there are at most 256 of them.
+	var keys [256]byte
+	var children [256]*ArtNode
+	size := 0
+
+	n.eachChild(func(key byte, child *ArtNode) {
+		if copied := op.clone(tree, child, child.remainingPath(depth+len(path)+1), depth+len(path)+1); copied != nil {
+			keys[size] = key
+			children[size] = copied
+			size++
+		}
+	})
+
+	return op.result.newInnerNodeWithChildren(path, keys[:size], children[:size], depth)
+}
+
+// Returns whether or not the result tree can adopt the passed in node of the passed in tree as it is, with the passed in
+// remaining path from the passed in depth.  The walk must not have consumed any of the compressed path of the node,
+// the result must not give its leaves usages of their own, and must store the paths and attributes of inner nodes
+// like the tree does, and no leaf below the node may have expired.
+func (op *setOperation) adoptable(tree *ArtTree, n *ArtNode, path []byte, depth int) bool {
+	if op.result.bounded() {
+		return false
+	}
+
+	if tree != op.first && (tree.prefixCapacity() != op.result.prefixCapacity() || tree.augmented() || op.result.augmented()) {
+		return false
+	}
+
+	if n.IsLeaf() {
+		return !tree.expired(n)
+	}
+
+	if len(path) != int(n.inner().prefixLen) {
+		return false
+	}
+
+	expired := op.expiredFirst
+	if tree != op.first {
+		expired = op.expiredSecond
+	}
+
+	return len(expired) == 0 || len(keysWithPrefix(expired, n.Minimum().leaf().key[:depth+len(path)])) == 0
+}
+
+// Returns a new inner node with the passed in compressed path, starting at the passed in depth,
+// that holds the passed in children under the passed in key bytes, which must be in ascending order.
+// The smallest type of node that can hold the children is used, and the number of leaves,
+// highest score and other attributes below the node are computed from them.
+//
+// No node is created for fewer than two children: nil is returned if there are none,
+// and the only child is returned if there is one, with the path of the node prepended to its own.
+func (t *ArtTree) newInnerNodeWithChildren(path []byte, keys []byte, children []*ArtNode, depth int) *ArtNode {
+	switch len(children) {
+	case 0:
+		return nil
+	case 1:
+		child := children[0]
+		if !child.IsLeaf() {
+			child = child.unshare(t)
+			childPath := child.compressedPath(depth + len(path) + 1)
+			fullPath := append(append(append([]byte{}, path...), keys[0]), childPath...)
+			child.inner().setPrefix(fullPath, len(fullPath), t.prefixCapacity())
+		}
+
+		return child
+	default:
+	}
+
+	var n *ArtNode
+	switch {
+	case len(children) <= NODE4MAX:
+		n = t.newInnerNode(NODE4)
+	case len(children) <= NODE16MAX:
+		n = t.newInnerNode(NODE16)
+	case len(children) <= NODE48MAX:
+		n = t.newInnerNode(NODE48)
+	default:
+		n = t.newInnerNode(NODE256)
+	}
+
+	inner := n.inner()
+	inner.setPrefix(path, len(path), t.prefixCapacity())
+	inner.size = uint16(len(children))
+
+	switch n.nodeType {
+	case NODE4, NODE16:
+		copy(n.keys(), keys)
+		copy(n.children(), children)
+	case NODE48:
+		n48 := n.node48()
+		copy(n48.children[:], children)
+		for i, key := range keys {
+			n48.keys[key] = byte(i + 1)
+		}
+	case NODE256:
+		n256 := n.node256()
+		for i, key := range keys {
+			n256.children[key] = children[i]
+		}
+	default:
+	}
+
+	for _, child := range children {
+		inner.count += child.subtreeCount()
+	}
+
+	n.refreshMaxScore()
+	t.refreshAugment(&n)
+
+	return n
+}
@@ -0,0 +1,308 @@
+package art
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// Returns the keys and values of every leaf of the passed in tree, in key order.
+func treeEntries(tree *ArtTree) ([]string, []interface{}) {
+	keys := []string{}
+	values := []interface{}{}
+	tree.Each(func(n *ArtNode) {
+		if n.IsLeaf() {
+			keys = append(keys, string(n.Key()))
+			values = append(values, n.Value())
+		}
+	})
+
+	return keys, values
+}
+
+// Checks that the passed in tree holds exactly the passed in entries, in key order,
+// and that it can still be searched and counted.
+func checkSetResult(t *testing.T, name string, tree *ArtTree, sorted [][]byte, expected map[string]interface{}) {
+	keys, values := treeEntries(tree)
+
+	if int64(len(expected)) != tree.size || len(keys) != len(expected) {
+		t.Errorf("%s: expected %d keys, got %d with a size of %d", name, len(expected), len(keys), tree.size)
+		return
+	}
+
+	i := 0
+	for _, key := range sorted {
+		value, ok := expected[string(key)]
+		if !ok {
+			continue
+		}
+
+		if keys[i] != string(key)+"\x00" || values[i] != value {
+			t.Errorf("%s: expected %q = %v at %d, got %q = %v", name, key, value, i, keys[i], values[i])
+			return
+		}
+
+		if tree.Search(key) != value {
+			t.Errorf("%s: expected to find %q", name, key)
+		}
+
+		i++
+	}
+
+	checkSubtreeCounts(t, tree.root)
+
+	if err := tree.Validate(); err != nil {
+		t.Errorf("%s: %v", name, err)
+	}
+}
+
+func TestSetOperationsMatchBruteForce(t *testing.T) {
+	modes := []PrefixMode{PREFIX_HYBRID, PREFIX_OPTIMISTIC, PREFIX_PESSIMISTIC}
+	words := loadAsset(t, "test/assets/words.txt")
+	r := rand.New(rand.NewSource(42))
+
+	// Shares the leading part of every key, so that compressed paths reach past the stored prefixes.
+	sorted := [][]byte{}
+	for _, word := range words[:20000] {
+		sorted = append(sorted, append([]byte("shared/prefix/of/every/key/"), word...))
+	}
+
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i], sorted[j]) < 0 })
+
+	for _, mode := range modes {
+		a := NewArtTreeWithOptions(Options{PrefixMode: mode})
+		b := NewArtTreeWithOptions(Options{PrefixMode: mode, Arena: true})
+		inA := map[string]interface{}{}
+		inB := map[string]interface{}{}
+
+		for i, key := range sorted {
+			switch r.Intn(8) {
+			case 0, 1, 2:
+				a.Insert(key, i)
+				inA[string(key)] = i
+			case 3, 4:
+				b.Insert(key, -i)
+				inB[string(key)] = -i
+			case 5:
+				a.Insert(key, i)
+				b.Insert(key, -i)
+				inA[string(key)] = i
+				inB[string(key)] = -i
+			default:
+			}
+		}
+
+		union := map[string]interface{}{}
+		intersection := map[string]interface{}{}
+		difference := map[string]interface{}{}
+		for key, value := range inB {
+			union[key] = value
+		}
+
+		for key, value := range inA {
+			if other, ok := inB[key]; ok {
+				union[key] = value.(int) + other.(int)
+				intersection[key] = value.(int) + other.(int)
+			} else {
+				union[key] = value
+				difference[key] = value
+			}
+		}
+
+		sum := func(n, other *ArtNode) interface{} { return n.Value().(int) + other.Value().(int) }
+
+		checkSetResult(t, "Union", a.Union(b, sum), sorted, union)
+		checkSetResult(t, "Intersect", a.Intersect(b, sum), sorted, intersection)
+		checkSetResult(t, "Difference", a.Difference(b), sorted, difference)
+
+		// Operations with an empty tree, or with the tree itself, copy or skip the whole tree.
+		empty := NewArtTree()
+		checkSetResult(t, "Union with an empty tree", empty.Union(a, nil), sorted, inA)
+		checkSetResult(t, "Intersect with itself", a.Intersect(a, nil), sorted, inA)
+		checkSetResult(t, "Difference with itself", a.Difference(a), sorted, map[string]interface{}{})
+		checkSetResult(t, "Difference from an empty tree", a.Difference(empty), sorted, inA)
+	}
+}
+
+// Results of set operations should adopt the subtrees that only one of the trees has keys under,
+// while changes to the result or to either tree leave the others unchanged.
+func TestSetOperationsShareNodes(t *testing.T) {
+	a := NewArtTree()
+	b := NewArtTree()
+	for _, key := range []string{"apple", "apricot", "banana", "blueberry", "cherry", "cranberry"} {
+		a.Insert([]byte(key), key)
+	}
+
+	for _, key := range []string{"apple", "avocado", "blackberry"} {
+		b.Insert([]byte(key), key)
+	}
+
+	union := a.Union(b, nil)
+	if *union.root.findChild('c') != *a.root.findChild('c') {
+		t.Error("Expected the union to adopt the subtree that only the first tree has keys under")
+	}
+
+	union.Insert([]byte("apples"), "apples")
+	union.Insert([]byte("cherry"), "changed")
+	union.Remove([]byte("banana"))
+	union.Remove([]byte("blackberry"))
+	a.Insert([]byte("cranberry"), "changed")
+	a.Remove([]byte("cherry"))
+	b.Remove([]byte("blackberry"))
+	b.Insert([]byte("blackcurrant"), "blackcurrant")
+
+	expected := map[*ArtTree][]string{
+		a:     {"apple", "apricot", "banana", "blueberry", "cranberry"},
+		b:     {"apple", "avocado", "blackcurrant"},
+		union: {"apple", "apples", "apricot", "avocado", "blueberry", "cherry", "cranberry"},
+	}
+
+	for tree, keys := range expected {
+		actual, _ := treeEntries(tree)
+		for i := range keys {
+			keys[i] += "\x00"
+		}
+
+		if fmt.Sprint(actual) != fmt.Sprint(keys) {
+			t.Errorf("Expected %q, got %q", keys, actual)
+		}
+
+		if err := tree.Validate(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if a.Search([]byte("cranberry")) != "changed" || union.Search([]byte("cranberry")) != "cranberry" {
+		t.Error("Expected a value replaced in the first tree to be kept from the union")
+	}
+
+	if union.Search([]byte("cherry")) != "changed" {
+		t.Error("Expected a value replaced in the union to be kept from the first tree")
+	}
+}
+
+// Trees that share nodes should each keep their own keys across random changes to all of them,
+// whether or not they allocate their nodes from an arena.
+func TestSetOperationsShareNodesAcrossChanges(t *testing.T) {
+	words := loadAsset(t, "test/assets/words.txt")[:20000]
+	r := rand.New(rand.NewSource(49))
+
+	for _, arena := range []bool{false, true} {
+		a := NewArtTreeWithOptions(Options{Arena: arena})
+		b := NewArtTreeWithOptions(Options{Arena: arena})
+		inA := map[string]interface{}{}
+		inB := map[string]interface{}{}
+
+		for i, word := range words {
+			if r.Intn(4) == 0 {
+				b.Insert(word, i)
+				inB[string(word)] = i
+			} else {
+				a.Insert(word, i)
+				inA[string(word)] = i
+			}
+		}
+
+		union := a.Union(b, nil)
+		inUnion := map[string]interface{}{}
+		for key, value := range inB {
+			inUnion[key] = value
+		}
+
+		for key, value := range inA {
+			inUnion[key] = value
+		}
+
+		trees := []*ArtTree{a, b, union}
+		contents := []map[string]interface{}{inA, inB, inUnion}
+
+		for i := 0; i < 20000; i++ {
+			j := r.Intn(len(trees))
+			word := words[r.Intn(len(words))]
+
+			if r.Intn(2) == 0 {
+				trees[j].Insert(word, -i)
+				contents[j][string(word)] = -i
+			} else {
+				trees[j].Remove(word)
+				delete(contents[j], string(word))
+			}
+		}
+
+		for j, tree := range trees {
+			if tree.size != int64(len(contents[j])) {
+				t.Errorf("Expected tree %d to hold %d keys, got %d", j, len(contents[j]), tree.size)
+			}
+
+			for _, word := range words {
+				if value := tree.Search(word); value != contents[j][string(word)] {
+					t.Fatalf("Expected %v for %q in tree %d, got %v", contents[j][string(word)], word, j, value)
+				}
+			}
+
+			if err := tree.Validate(); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+}
+
+// Scores and aggregates should be carried over to the result.
+func TestSetOperationsKeepScoresAndAggregates(t *testing.T) {
+	a := NewArtTreeWithOptions(Options{Aggregator: SumAggregator{}})
+	b := NewArtTree()
+
+	a.InsertWithScore([]byte("apple"), 1, 10)
+	a.Insert([]byte("apricot"), 2)
+	b.InsertWithScore([]byte("avocado"), 4, 20)
+	b.Insert([]byte("banana"), 8)
+
+	union := a.Union(b, nil)
+	if actual := union.Aggregate([]byte("a"), []byte("b")); actual != float64(7) {
+		t.Errorf("Expected an aggregate of 7, got %v", actual)
+	}
+
+	top := union.TopK([]byte("a"), 2)
+	if len(top) != 2 || !bytes.Equal(top[0].Key(), []byte("avocado\x00")) || !bytes.Equal(top[1].Key(), []byte("apple\x00")) {
+		t.Errorf("Unexpected top scoring keys: %v", top)
+	}
+}
+
+func BenchmarkUnionWords(b *testing.B) {
+	words := loadAsset(b, "test/assets/words.txt")
+	first := NewArtTree()
+	second := NewArtTree()
+	for i, word := range words {
+		if i%2 == 0 {
+			first.Insert(word, word)
+		} else {
+			second.Insert(word, word)
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		first.Union(second, nil)
+	}
+}
+
+// Merging a few updates into a large tree adopts the subtrees of the large tree that the updates do not touch.
+func BenchmarkUnionWordsSmallUpdate(b *testing.B) {
+	words := loadAsset(b, "test/assets/words.txt")
+	current := NewArtTree()
+	updates := NewArtTree()
+	for i, word := range words {
+		current.Insert(word, word)
+		if i%1000 == 0 {
+			updates.Insert(word, i)
+		}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		current.Union(updates, nil)
+	}
+}
@@ -0,0 +1,137 @@
+package art
+
+import (
+	"unsafe"
+)
+
+// Describes the shape and estimated memory usage of a tree, as returned by Stats.
+type TreeStats struct {
+	// The number of nodes of each type.
+	Leaves   int64
+	Node4s   int64
+	Node16s  int64
+	Node48s  int64
+	Node256s int64
+
+	// The number of leaves at each depth, counted in inner nodes above the leaf.
+	DepthHistogram []int64
+
+	// The average number of children of the inner nodes, or zero if there are none.
+	AverageFanOut float64
+
+	// The number of inner nodes by the length of their entire compressed paths.
+	PrefixLengths []int64
+
+	// The number of inner nodes whose compressed paths are longer than the bytes stored in them,
+	// which searches that pass through them complete by loading the Minimum leaf below them.
+	// This counts nodes, not searches, so it measures how much of the tree can fall back rather than how often it does.
+	// Trees in the optimistic mode skip compressed paths instead of completing them, so none of their nodes are counted.
+	TruncatedPrefixes int64
+
+	// The estimated number of heap bytes held by the nodes and keys of the tree, along with its expiry and usage indexes.
+	// Values are counted by the ValueSize of the tree, if any.  Nodes allocated from an arena are counted by their own size,
+	// without the unused space of their slabs.
+	HeapBytes int64
+}
+
+// Walks the entire tree, and returns the number of nodes of each type, the depths of its leaves,
+// the fan-out and compressed paths of its inner nodes, and an estimate of the heap bytes it holds.
+func (t *ArtTree) Stats() *TreeStats {
+	stats := &TreeStats{}
+	children := int64(0)
+
+	var walk func(n *ArtNode, depth int)
+	walk = func(n *ArtNode, depth int) {
+		if n.IsLeaf() {
+			stats.Leaves++
+			for len(stats.DepthHistogram) <= depth {
+				stats.DepthHistogram = append(stats.DepthHistogram, 0)
+			}
+
+			stats.DepthHistogram[depth]++
+			stats.HeapBytes += t.entryBytes(n.leaf().key, n.leaf().value, n.ext())
+			return
+		}
+
+		switch n.nodeType {
+		case NODE4:
+			stats.Node4s++
+		case NODE16:
+			stats.Node16s++
+		case NODE48:
+			stats.Node48s++
+		default:
+			stats.Node256s++
+		}
+
+		inner := n.inner()
+		children += int64(inner.size)
+
+		for len(stats.PrefixLengths) <= int(inner.prefixLen) {
+			stats.PrefixLengths = append(stats.PrefixLengths, 0)
+		}
+
+		stats.PrefixLengths[inner.prefixLen]++
+		if t.options.PrefixMode != PREFIX_OPTIMISTIC && len(inner.prefix) < int(inner.prefixLen) {
+			stats.TruncatedPrefixes++
+		}
+
+		stats.HeapBytes += int64(n.innerNodeSize())
+		if cap(inner.prefix) > len(inner.inline) {
+			stats.HeapBytes += int64(cap(inner.prefix))
+		}
+
+		n.eachChild(func(key byte, child *ArtNode) {
+			walk(child, depth+1)
+		})
+	}
+
+	if t.root != nil {
+		walk(t.root, 0)
+	}
+
+	if inners := stats.Node4s + stats.Node16s + stats.Node48s + stats.Node256s; inners > 0 {
+		stats.AverageFanOut = float64(children) / float64(inners)
+	}
+
+	for _, index := range []*ArtTree{t.expiries, t.usage} {
+		if index != nil {
+			stats.HeapBytes += index.Stats().HeapBytes
+		}
+	}
+
+	return stats
+}
+
+// Returns the size of the structure that backs the current inner node.
+func (n *ArtNode) innerNodeSize() uintptr {
+	augmented := n.augment() != nil
+
+	switch n.nodeType {
+	case NODE4:
+		if augmented {
+			return unsafe.Sizeof(augmentedNode4{})
+		}
+
+		return unsafe.Sizeof(node4{})
+	case NODE16:
+		if augmented {
+			return unsafe.Sizeof(augmentedNode16{})
+		}
+
+		return unsafe.Sizeof(node16{})
+	case NODE48:
+		if augmented {
+			return unsafe.Sizeof(augmentedNode48{})
+		}
+
+		return unsafe.Sizeof(node48{})
+	default:
+	}
+
+	if augmented {
+		return unsafe.Sizeof(augmentedNode256{})
+	}
+
+	return unsafe.Sizeof(node256{})
+}
@@ -0,0 +1,103 @@
+package art
+
+import (
+	"runtime"
+	"testing"
+)
+
+// Stats should count the same nodes as a traversal that counts them by hand.
+func TestStatsCountsAllTypes(t *testing.T) {
+	tree := NewArtTree()
+	for _, word := range loadAsset(t, "test/assets/words.txt") {
+		tree.Insert(word, word)
+	}
+
+	stats := tree.Stats()
+
+	if stats.Leaves != 235886 || stats.Node4s != 111616 || stats.Node16s != 12181 || stats.Node48s != 458 || stats.Node256s != 1 {
+		t.Errorf("Unexpected node counts: %+v", stats)
+	}
+
+	var leaves int64
+	for _, count := range stats.DepthHistogram {
+		leaves += count
+	}
+
+	if leaves != stats.Leaves {
+		t.Errorf("Expected the depth histogram to count %d leaves, got %d", stats.Leaves, leaves)
+	}
+
+	// Every node but the root is the child of an inner node.
+	inners := stats.Node4s + stats.Node16s + stats.Node48s + stats.Node256s
+	if expected := float64(inners+stats.Leaves-1) / float64(inners); stats.AverageFanOut != expected {
+		t.Errorf("Expected an average fan-out of %f, got %f", expected, stats.AverageFanOut)
+	}
+
+	var prefixed int64
+	for _, count := range stats.PrefixLengths {
+		prefixed += count
+	}
+
+	if prefixed != inners {
+		t.Errorf("Expected the prefix lengths of %d inner nodes, got %d", inners, prefixed)
+	}
+
+	if empty := NewArtTree().Stats(); empty.Leaves != 0 || empty.AverageFanOut != 0 || empty.HeapBytes != 0 {
+		t.Errorf("Expected an empty tree to have no stats, got %+v", empty)
+	}
+}
+
+// Inner nodes whose compressed paths are longer than the prefix capacity of the tree store a truncated prefix.
+func TestStatsCountsTruncatedPrefixes(t *testing.T) {
+	words := loadAsset(t, "test/assets/words.txt")[:20000]
+
+	for _, mode := range []PrefixMode{PREFIX_HYBRID, PREFIX_OPTIMISTIC, PREFIX_PESSIMISTIC} {
+		tree := NewArtTreeWithOptions(Options{PrefixMode: mode, MaxPrefixLen: 2})
+		for _, word := range words {
+			tree.Insert(append([]byte("shared/prefix/"), word...), word)
+		}
+
+		stats := tree.Stats()
+
+		var expected int64
+		for length, count := range stats.PrefixLengths {
+			if length > tree.prefixCapacity() && mode != PREFIX_OPTIMISTIC {
+				expected += count
+			}
+		}
+
+		if stats.TruncatedPrefixes != expected {
+			t.Errorf("Expected %d truncated prefixes in mode %d, got %d", expected, mode, stats.TruncatedPrefixes)
+		}
+
+		if mode == PREFIX_HYBRID && expected == 0 || mode != PREFIX_HYBRID && expected != 0 {
+			t.Errorf("Unexpected number of truncated prefixes in mode %d: %d", mode, expected)
+		}
+	}
+}
+
+// The estimated heap bytes should be close to the heap bytes actually retained by the tree.
+func TestStatsEstimatesHeapBytes(t *testing.T) {
+	keys := loadAsset(t, "test/assets/uuid.txt")
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	tree := NewArtTree()
+	for _, key := range keys {
+		tree.Insert(key, nil)
+	}
+
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+
+	retained := int64(after.HeapAlloc) - int64(before.HeapAlloc)
+	estimated := tree.Stats().HeapBytes
+	runtime.KeepAlive(tree)
+	runtime.KeepAlive(keys)
+
+	if estimated < retained*3/4 || estimated > retained*5/4 {
+		t.Errorf("Expected an estimate within 25%% of %d heap bytes, got %d", retained, estimated)
+	}
+}
@@ -0,0 +1,80 @@
+package art
+
+import (
+	"bytes"
+)
+
+// Defines how a tree reaches a replica of itself that it is reconciled with, such as over a network connection.
+// A transport is typically implemented by sending the prefix to the replica and returning the result of
+// calling Summary on its tree with that prefix.
+type SyncTransport interface {
+	// Returns a summary of the subtree of the replica that holds every key that starts with the passed in
+	// indexed prefix, or nil if there are none.
+	Summary(prefix []byte) (*SubtreeSummary, error)
+}
+
+// Compares the tree against a replica that is reached through the passed in transport,
+// and calls the passed in callback with the indexed prefix of every range of keys in which they differ,
+// in key order.  Every key that is in only one of the trees, or that has different values in them,
+// starts with one of the reported prefixes.  A reported prefix can also cover keys that are the same in both trees,
+// when one of the trees only holds a single key under it, or the keys under it share different paths in each tree.
+//
+// Both trees must be configured with the same HashValue.  Subtrees with the same hash in both trees are skipped
+// without being summarized, so the number of summaries requested from the transport is proportional to the number
+// of differences times the length of the keys.  An error returned by the transport is returned as is.
+func (t *ArtTree) Reconcile(remote SyncTransport, callback func(prefix []byte)) error {
+	return t.reconcileHelper(remote, []byte{}, callback)
+}
+
+// Recursive helper for Reconcile, which compares the subtrees of both trees that hold every key
+// starting with the passed in prefix.
+func (t *ArtTree) reconcileHelper(remote SyncTransport, prefix []byte, callback func(prefix []byte)) error {
+	local := t.Summary(prefix)
+
+	other, err := remote.Summary(prefix)
+	if err != nil {
+		return err
+	}
+
+	if local == nil && other == nil {
+		return nil
+	}
+
+	if local != nil && other != nil && local.Hash == other.Hash {
+		return nil
+	}
+
+	// The whole range differs if it is missing from either tree, if either tree holds a single key in it,
+	// or if the keys below it share different paths in each tree.
+	if local == nil || other == nil || len(local.Keys) == 0 || len(other.Keys) == 0 || !bytes.Equal(local.Path, other.Path) {
+		callback(prefix)
+		return nil
+	}
+
+	// Otherwise, compare the children of both subtrees by their key bytes,
+	// and only descend into the children whose hashes differ.
+	i, j := 0, 0
+	for i < len(local.Keys) || j < len(other.Keys) {
+		switch {
+		case j == len(other.Keys) || (i < len(local.Keys) && local.Keys[i] < other.Keys[j]):
+			callback(append(append([]byte{}, local.Path...), local.Keys[i]))
+			i++
+
+		case i == len(local.Keys) || other.Keys[j] < local.Keys[i]:
+			callback(append(append([]byte{}, other.Path...), other.Keys[j]))
+			j++
+
+		default:
+			if local.Hashes[i] != other.Hashes[j] {
+				child := append(append([]byte{}, local.Path...), local.Keys[i])
+				if err := t.reconcileHelper(remote, child, callback); err != nil {
+					return err
+				}
+			}
+			i++
+			j++
+		}
+	}
+
+	return nil
+}
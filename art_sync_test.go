@@ -0,0 +1,131 @@
+package art
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// Defines a transport that reaches a replica in memory, counting the summaries it is asked for.
+type memoryTransport struct {
+	tree     *ArtTree
+	requests int
+	err      error
+}
+
+func (m *memoryTransport) Summary(prefix []byte) (*SubtreeSummary, error) {
+	m.requests++
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return m.tree.Summary(prefix), nil
+}
+
+// Reconciling two replicas should report ranges that cover every difference, after visiting few subtrees,
+// and copying those ranges from the replica should make both trees equal.
+func TestReconcileConvergesReplicas(t *testing.T) {
+	words := loadAsset(t, "test/assets/words.txt")[:50000]
+	r := rand.New(rand.NewSource(45))
+	hashValue := func(value interface{}) []byte { return []byte(fmt.Sprint(value)) }
+
+	local := NewArtTreeWithOptions(Options{HashValue: hashValue})
+	replica := NewArtTreeWithOptions(Options{HashValue: hashValue, PrefixMode: PREFIX_OPTIMISTIC})
+
+	differences := 0
+	for i, word := range words {
+		switch r.Intn(2000) {
+		case 0:
+			local.Insert(word, i)
+		case 1:
+			replica.Insert(word, i)
+		case 2:
+			local.Insert(word, i)
+			replica.Insert(word, -i)
+		default:
+			local.Insert(word, i)
+			replica.Insert(word, i)
+			continue
+		}
+
+		differences++
+	}
+
+	if local.RootHash() == replica.RootHash() {
+		t.Fatal("Expected replicas with different contents to have different root hashes")
+	}
+
+	transport := &memoryTransport{tree: replica}
+	prefixes := [][]byte{}
+	if err := local.Reconcile(transport, func(prefix []byte) {
+		prefixes = append(prefixes, prefix)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(prefixes) < differences/2 || transport.requests > differences*20 {
+		t.Errorf("Expected around %d differing ranges from a few summaries, got %d from %d", differences, len(prefixes), transport.requests)
+	}
+
+	for i := 1; i < len(prefixes); i++ {
+		if string(prefixes[i-1]) >= string(prefixes[i]) {
+			t.Errorf("Expected ranges in key order, got %q before %q", prefixes[i-1], prefixes[i])
+		}
+	}
+
+	// Replace every differing range of the local tree with the range of the replica.
+	for _, prefix := range prefixes {
+		stale := [][]byte{}
+		local.ScanPrefix(prefix, func(n *ArtNode) { stale = append(stale, n.Key()) })
+		for _, key := range stale {
+			local.Remove(key)
+		}
+
+		replica.ScanPrefix(prefix, func(n *ArtNode) { local.Insert(n.Key(), n.Value()) })
+	}
+
+	if local.RootHash() != replica.RootHash() || local.size != replica.size {
+		t.Error("Expected the replicas to be equal after copying the differing ranges")
+	}
+
+	transport.requests = 0
+	if err := local.Reconcile(transport, func(prefix []byte) {
+		t.Errorf("Unexpected differing range %q between equal replicas", prefix)
+	}); err != nil || transport.requests != 1 {
+		t.Errorf("Expected equal replicas to be reconciled from a single summary, used %d", transport.requests)
+	}
+}
+
+func TestReconcileReturnsTransportErrors(t *testing.T) {
+	hashValue := func(value interface{}) []byte { return []byte(fmt.Sprint(value)) }
+	tree := NewArtTreeWithOptions(Options{HashValue: hashValue})
+	tree.Insert([]byte("hello"), "world")
+
+	err := errors.New("connection reset")
+	if actual := tree.Reconcile(&memoryTransport{err: err}, func(prefix []byte) {}); actual != err {
+		t.Errorf("Expected the error of the transport, got %v", actual)
+	}
+}
+
+func TestRootHashOfEmptyTrees(t *testing.T) {
+	hashValue := func(value interface{}) []byte { return []byte(fmt.Sprint(value)) }
+	tree := NewArtTreeWithOptions(Options{HashValue: hashValue})
+
+	if tree.RootHash() != [32]byte{} || NewArtTree().RootHash() != [32]byte{} {
+		t.Error("Expected the zero hash for empty trees and trees without a HashValue")
+	}
+
+	tree.Insert([]byte("hello"), "world")
+	if tree.RootHash() == [32]byte{} {
+		t.Error("Expected a hash for a tree with a key")
+	}
+
+	if summary := tree.Summary([]byte("hello")); summary == nil || string(summary.Path) != "hello\x00" || summary.Hash != tree.RootHash() {
+		t.Errorf("Unexpected summary of a single key: %v", summary)
+	}
+
+	if tree.Summary([]byte("world")) != nil {
+		t.Error("Expected no summary of a prefix without any keys")
+	}
+}
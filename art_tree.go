@@ -3,36 +3,329 @@ package art
 
 import (
 	"bytes"
-	_ "math"
+	"container/heap"
+	"math"
 	_ "os"
+	"sort"
+	"time"
 )
 
 type ArtTree struct {
-	root *ArtNode
-	size int64
+	root    *ArtNode
+	size    int64
+	options Options
+	arena   *nodeArena
+
+	// Whether or not any leaf has been inserted with a score,
+	// after which the highest scores below inner nodes are maintained by every insertion and removal.
+	scored bool
+
+	// The subscriptions of Watch, indexed by their prefixes, or nil if there have never been any.
+	watchers *ArtTree
+
+	// The keys inserted by InsertWithTTL, indexed by the times they expire at followed by the keys themselves,
+	// or nil if there have never been any.
+	expiries *ArtTree
+
+	// The keys of a tree that evicts keys, indexed by their usage followed by the keys themselves,
+	// along with the counter that orders their uses and the estimated number of bytes they hold.
+	usage *ArtTree
+	ticks uint64
+	bytes int64
 }
 
+// Defines the options that can be used to configure a new ArtTree.
+// The zero value describes the default configuration used by NewArtTree.
+type Options struct {
+	// Allocate the nodes of the tree from a per-tree arena instead of the heap.
+	// This is intended for trees that are built, queried and thrown away in batches:
+	// nodes released by removals are reused by later insertions, the bytes of removed keys
+	// are only reclaimed when the whole tree is dropped, and nodes handed out by
+	// Each, Minimum or Maximum must not be retained after they are removed from the tree.
+	Arena bool
+
+	// Determines how the compressed paths of inner nodes are stored and compared.
+	// Defaults to PREFIX_HYBRID.
+	PrefixMode PrefixMode
+
+	// The maximum number of bytes of each compressed path that are stored in its inner node
+	// when using PREFIX_HYBRID.  Longer paths are compared against the minimum leaf below the node.
+	// Keys that share long prefixes benefit from a larger value, at the cost of memory:
+	// prefixes longer than MAX_PREFIX_LEN bytes require a separate allocation.
+	// Defaults to MAX_PREFIX_LEN.
+	MaxPrefixLen int
+
+	// Transforms every key passed to Insert, Search, Remove and ScanPrefix into the key
+	// the tree is actually indexed and ordered by, such as a case folded or Unicode normalized form.
+	// The key originally passed to Insert is kept in its leaf, and is available through OriginalKey.
+	// The transform must not modify the passed in key, and the transform of a prefix of a key
+	// must be a prefix of the transform of that key for ScanPrefix to find it.
+	// Defaults to nil, which indexes keys as they are.
+	KeyTransform func(key []byte) []byte
+
+	// Keeps the aggregate of the values below every inner node, as defined by the passed in monoid,
+	// so that Aggregate can answer range queries without visiting every leaf in the range.
+	// The aggregates are recomputed along the path of every insertion and removal,
+	// and inner nodes that carry them are allocated from the heap even if Arena is set.
+	// Defaults to nil, which does not keep any aggregates.
+	Aggregator Aggregator
+
+	// Encodes the values of the tree for hashing.  Every inner node then keeps a SHA-256 hash
+	// of the keys and encoded values below it, so that Diff can skip the subtrees two trees have in common.
+	// The hashes are recomputed along the path of every insertion and removal,
+	// and inner nodes that carry them are allocated from the heap even if Arena is set.
+	// Defaults to nil, which does not keep any hashes.
+	HashValue func(value interface{}) []byte
+
+	// Returns the current time, which decides when the keys inserted by InsertWithTTL expire.
+	// Defaults to nil, which uses time.Now.
+	Clock func() time.Time
+
+	// The maximum number of keys the tree holds.  Once an insertion exceeds it, keys that have expired are removed,
+	// followed by keys chosen by the Eviction policy of the tree.  The key that was just inserted is never evicted.
+	// Defaults to 0, which does not limit the number of keys.
+	MaxEntries int
+
+	// The maximum estimated number of bytes held by the leaves of the tree, which is enforced like MaxEntries.
+	// Each leaf is estimated by the size of its structure and keys, and the size of its value as returned by ValueSize.
+	// Defaults to 0, which does not limit the number of bytes.
+	MaxBytes int64
+
+	// Returns the estimated number of bytes held by a value, for MaxBytes.
+	// Defaults to nil, which does not count the bytes of values.
+	ValueSize func(value interface{}) int
+
+	// Decides which keys are evicted once the tree exceeds MaxEntries or MaxBytes.
+	// Uses are counted by insertions and Search hits, and the order of uses is kept in a radix tree of its own,
+	// so finding the key to evict does not visit any other key.  Defaults to EVICT_LRU.
+	Eviction EvictionPolicy
+
+	// Called with every evicted key, as returned by OriginalKey, and its value, once it has been removed.
+	// The callback must not modify the tree.  Defaults to nil.
+	OnEvict func(key []byte, value interface{})
+}
+
+// Defines how the compressed paths of inner nodes are stored and compared.
+type PrefixMode uint8
+
+const (
+	// From the specification: Store up to Options.MaxPrefixLen bytes of each compressed path
+	// in its inner node, and switch to the optimistic strategy for any bytes beyond that
+	// by loading them from the minimum leaf below the node.
+	PREFIX_HYBRID PrefixMode = iota
+
+	// From the specification: Store only the length of each compressed path.
+	// Searches skip over the path without comparing it, so the full key is compared
+	// once a leaf has been reached.
+	PREFIX_OPTIMISTIC
+
+	// From the specification: Store every byte of each compressed path in its inner node,
+	// so that searches never have to load the minimum leaf.
+	PREFIX_PESSIMISTIC
+)
+
 // Creates and returns a new Art Tree with a nil root and a size of 0.
 func NewArtTree() *ArtTree {
-	return &ArtTree{root: nil, size: 0}
+	return NewArtTreeWithOptions(Options{})
+}
+
+// Creates and returns a new Art Tree with a nil root and a size of 0,
+// configured by the passed in options.
+func NewArtTreeWithOptions(options Options) *ArtTree {
+	if options.MaxPrefixLen <= 0 {
+		options.MaxPrefixLen = MAX_PREFIX_LEN
+	}
+
+	t := &ArtTree{root: nil, size: 0, options: options}
+
+	if options.Arena {
+		t.arena = newNodeArena()
+	}
+
+	return t
+}
+
+// Returns the arena that the nodes of the tree are allocated from,
+// or nil if they are allocated from the heap.
+func (t *ArtTree) allocator() *nodeArena {
+	if t == nil {
+		return nil
+	}
+
+	return t.arena
+}
+
+// Returns the maximum number of bytes of each compressed path that are stored in the inner nodes of the tree.
+// A nil tree uses the capacity of the default hybrid mode.
+func (t *ArtTree) prefixCapacity() int {
+	if t == nil {
+		return MAX_PREFIX_LEN
+	}
+
+	switch t.options.PrefixMode {
+	case PREFIX_OPTIMISTIC:
+		return 0
+	case PREFIX_PESSIMISTIC:
+		return math.MaxInt32
+	default:
+	}
+
+	return t.options.MaxPrefixLen
+}
+
+// Returns the passed in key as it is indexed by the tree:
+// transformed by the KeyTransform of the tree, if any, then escaped and null terminated.
+func (t *ArtTree) indexKey(key []byte) []byte {
+	if t.options.KeyTransform != nil {
+		key = t.options.KeyTransform(key)
+	}
+
+	return escapeKey(key)
+}
+
+// Returns the passed in prefix as it is indexed by the tree:
+// transformed by the KeyTransform of the tree, if any, and escaped.
+func (t *ArtTree) indexPrefix(prefix []byte) []byte {
+	if t.options.KeyTransform != nil {
+		prefix = t.options.KeyTransform(prefix)
+	}
+
+	return escapePrefix(prefix)
+}
+
+// Returns a new leaf node for the passed in key and value,
+// that carries the passed in optional attributes if they are not nil.
+func (t *ArtTree) newLeaf(key []byte, value interface{}, ext *leafExt) *ArtNode {
+	if ext == nil {
+		return t.arena.newLeaf(key, value)
+	}
+
+	l := &extLeaf{ext: *ext}
+	l.nodeType = LEAF
+	l.flags = leafExtFlag
+	l.key = t.arena.copyKey(key)
+	l.value = value
+
+	return &l.ArtNode
+}
+
+// Returns a new, empty inner node of the passed in type.
+// Trees that keep attributes computed from the leaves below their inner nodes allocate inner nodes
+// that carry them from the heap, while other trees allocate them from their arena.
+// A nil tree allocates them from the heap.
+func (t *ArtTree) newInnerNode(nodeType uint8) *ArtNode {
+	if t == nil || !t.augmented() {
+		a := t.allocator()
+
+		switch nodeType {
+		case NODE4:
+			return a.newNode4()
+		case NODE16:
+			return a.newNode16()
+		case NODE48:
+			return a.newNode48()
+		default:
+		}
+
+		return a.newNode256()
+	}
+
+	var n *ArtNode
+	switch nodeType {
+	case NODE4:
+		n = &(&augmentedNode4{}).ArtNode
+	case NODE16:
+		n = &(&augmentedNode16{}).ArtNode
+	case NODE48:
+		n = &(&augmentedNode48{}).ArtNode
+	default:
+		n = &(&augmentedNode256{}).ArtNode
+	}
+
+	n.nodeType = nodeType
+	n.flags = innerAugmentFlag
+	return n
+}
+
+// Returns whether or not the inner nodes of the tree carry attributes computed from the leaves below them,
+// which are recomputed along the path of every insertion and removal.
+func (t *ArtTree) augmented() bool {
+	return t.options.Aggregator != nil || t.options.HashValue != nil
+}
+
+// Recomputes the attributes of the node at the passed in position from its children,
+// if it is an inner node that carries them.
+func (t *ArtTree) refreshAugment(ref **ArtNode) {
+	if *ref == nil {
+		return
+	}
+
+	augment := (*ref).augment()
+	if augment == nil {
+		return
+	}
+
+	if t.options.Aggregator != nil {
+		augment.aggregate = (*ref).childAggregate(t.options.Aggregator)
+	}
+
+	if t.options.HashValue != nil {
+		augment.hash = (*ref).childHash(t.options.HashValue)
+	}
+}
+
+// Recomputes the highest score below the node at the passed in position, if it is an inner node.
+func (t *ArtTree) refreshMaxScore(ref **ArtNode) {
+	if *ref != nil {
+		(*ref).refreshMaxScore()
+	}
+}
+
+// Returns whether or not the passed in key matches the compressed path of the current inner node
+// at the specified depth, and has a byte left over to select the next child with.
+// In the optimistic mode the compressed path is skipped rather than compared,
+// so callers must compare the full key once they reach a leaf.
+func (t *ArtTree) prefixMatches(current *ArtNode, key []byte, depth int) bool {
+	inner := current.inner()
+
+	if depth+int(inner.prefixLen) >= len(key) {
+		return false
+	}
+
+	if t.options.PrefixMode == PREFIX_OPTIMISTIC {
+		return true
+	}
+
+	return current.PrefixMismatch(key, depth) == int(inner.prefixLen)
 }
 
-// Returns the node that contains the passed in key, or nil if not found.
+// Returns the value of the passed in key, or nil if not found or if it has expired.
+// In a tree that evicts keys, finding a key counts as a use of it.
 func (t *ArtTree) Search(key []byte) interface{} {
-	key = ensureNullTerminatedKey(key)
-	return t.searchHelper(t.root, key, 0)
+	key = t.indexKey(key)
+
+	n := t.searchHelper(t.root, key, 0)
+	if n == nil || t.expired(n) {
+		return nil
+	}
+
+	if t.bounded() {
+		t.touch(n)
+	}
+
+	return n.leaf().value
 }
 
 // Recursive search helper function that traverses the tree.
-// Returns the node that contains the passed in key, or nil if not found.
-func (t *ArtTree) searchHelper(current *ArtNode, key []byte, depth int) interface{} {
+// Returns the leaf that contains the passed in key, or nil if not found.
+func (t *ArtTree) searchHelper(current *ArtNode, key []byte, depth int) *ArtNode {
 	// While we have nodes to search
 	for current != nil {
 
 		// Check if the current is a match
 		if current.IsLeaf() {
 			if current.IsMatch(key) {
-				return current.value
+				return current
 			}
 
 			// Bail if no match
@@ -40,26 +333,399 @@ func (t *ArtTree) searchHelper(current *ArtNode, key []byte, depth int) interfac
 		}
 
 		// Check if our key mismatches the current compressed path
-		if current.PrefixMismatch(key, depth) != current.prefixLen {
+		if !t.prefixMatches(current, key, depth) {
 			// Bail if there's a mismatch during traversal.
 			return nil
 		} else {
 			// Otherwise, increase depth accordingly.
-			depth += current.prefixLen
+			depth += int(current.inner().prefixLen)
 		}
 
 		// Find the next node at the specified index, and update depth.
-		current = *(current.FindChild(key[depth]))
+		next := current.findChild(key[depth])
+		if next == nil {
+			return nil
+		}
+
+		current = *next
 		depth++
 	}
 
 	return nil
 }
 
+// Returns the stored key that is the longest prefix of the passed in key, along with its value,
+// or a nil key if no stored key is a prefix of it.  Every stored key is a prefix of itself.
+// Keys are returned as they were passed to Insert, without the null byte the tree appends to them.
+func (t *ArtTree) LongestPrefixMatch(key []byte) ([]byte, interface{}) {
+	key = t.indexPrefix(key)
+
+	var best *ArtNode
+	t.eachPrefixOf(key, func(n *ArtNode) {
+		if !t.expired(n) {
+			best = n
+		}
+	})
+
+	if best == nil {
+		return nil, nil
+	}
+
+	if ext := best.ext(); ext != nil && ext.originalKey != nil {
+		return ext.originalKey, best.leaf().value
+	}
+
+	return unescapeKey(best.leaf().key), best.leaf().value
+}
+
+// Calls the passed in callback for every leaf whose key, without the null byte the tree appended to it,
+// is a prefix of the passed in indexed key, from the shortest to the longest.
+// Only the nodes on the path to the key are visited.
+func (t *ArtTree) eachPrefixOf(key []byte, callback func(*ArtNode)) {
+	var last *ArtNode
+	visit := func(n *ArtNode) {
+		if n != last && isKeyPrefix(n.leaf().key, key) {
+			last = n
+			callback(n)
+		}
+	}
+
+	current := t.root
+	depth := 0
+
+	for current != nil {
+		if current.IsLeaf() {
+			visit(current)
+			break
+		}
+
+		// Bail if the compressed path runs past the end of the key, or diverges from it.
+		// The optimistic mode skips the path, since every candidate leaf is compared in full.
+		inner := current.inner()
+		if depth+int(inner.prefixLen) > len(key) {
+			break
+		}
+
+		if t.options.PrefixMode != PREFIX_OPTIMISTIC && current.PrefixMismatch(key, depth) != int(inner.prefixLen) {
+			break
+		}
+
+		depth += int(inner.prefixLen)
+
+		// A stored key that ends at the current depth is a child under its null terminator.
+		if child := current.findChild(0); child != nil && (*child).IsLeaf() {
+			visit(*child)
+		}
+
+		if depth >= len(key) {
+			break
+		}
+
+		next := current.findChild(key[depth])
+		if next == nil {
+			break
+		}
+
+		current = *next
+		depth++
+	}
+}
+
+// Calls the passed in callback for every leaf whose key, without the null byte the tree appended to it,
+// is within the passed in number of edits of the passed in query, along with that number of edits.
+// An edit is the insertion, deletion or substitution of a single byte.  Leaves are visited in key order.
+//
+// The traversal carries a row of the Levenshtein distance table between the query and the path to
+// the current node, and skips every subtree whose row has no entry within the number of edits.
+func (t *ArtTree) FuzzySearch(query []byte, maxEdits int, callback func(n *ArtNode, distance int)) {
+	if t.options.KeyTransform != nil {
+		query = t.options.KeyTransform(query)
+	}
+
+	if t.root == nil || maxEdits < 0 {
+		return
+	}
+
+	row := make([]int, len(query)+1)
+	for i := range row {
+		row[i] = i
+	}
+
+	t.fuzzySearchHelper(t.root, query, row, 0, false, maxEdits, func(n *ArtNode, distance int) {
+		if !t.expired(n) {
+			callback(n, distance)
+		}
+	})
+}
+
+// Recursive helper for FuzzySearch.  The passed in row holds the distances between the keys that
+// the first depth stored bytes of every key below the current node stand for and each prefix of the query.
+// Escaped is whether or not the stored byte at depth follows an escape byte.
+func (t *ArtTree) fuzzySearchHelper(current *ArtNode, query []byte, row []int, depth int, escaped bool, maxEdits int, callback func(*ArtNode, int)) {
+	// Advances the row over the passed in stored byte, and returns whether or not it can still lead to a match.
+	advance := func(b byte) bool {
+		b, ok, next := unescapeByte(b, escaped)
+		if escaped = next; ok {
+			row = levenshteinRow(query, row, b)
+		}
+
+		return minRow(row) <= maxEdits
+	}
+
+	if current.IsLeaf() {
+		key := current.leaf().key
+		for ; depth < len(key)-1; depth++ {
+			if !advance(key[depth]) {
+				return
+			}
+		}
+
+		if distance := row[len(query)]; distance <= maxEdits {
+			callback(current, distance)
+		}
+
+		return
+	}
+
+	// Advance the row over the compressed path.
+	inner := current.inner()
+	if inner.prefixLen > 0 {
+		for _, b := range current.compressedPath(depth) {
+			if !advance(b) {
+				return
+			}
+		}
+
+		depth += int(inner.prefixLen)
+	}
+
+	current.eachChild(func(key byte, child *ArtNode) {
+		// Leaves advance the row over the rest of their own key, which lets them skip the null terminator.
+		if child.IsLeaf() {
+			t.fuzzySearchHelper(child, query, row, depth, escaped, maxEdits, callback)
+			return
+		}
+
+		next := row
+		b, ok, nextEscaped := unescapeByte(key, escaped)
+		if ok {
+			next = levenshteinRow(query, row, b)
+		}
+
+		if minRow(next) <= maxEdits {
+			t.fuzzySearchHelper(child, query, next, depth+1, nextEscaped, maxEdits, callback)
+		}
+	})
+}
+
+// Calls the passed in callback for every leaf whose key, without the null byte the tree appended to it,
+// is matched in its entirety by the passed in pattern, in key order.
+//
+// The pattern is advanced over the key bytes and compressed paths along the traversal,
+// so that subtrees whose path can not lead to a match are skipped.
+func (t *ArtTree) Match(pattern *Pattern, callback func(*ArtNode)) {
+	if t.root == nil || pattern == nil {
+		return
+	}
+
+	t.matchHelper(t.root, pattern, pattern.initial(), 0, false, func(n *ArtNode) {
+		if !t.expired(n) {
+			callback(n)
+		}
+	})
+}
+
+// Recursive helper for Match.  The passed in state is the state of the pattern after consuming the key that
+// the first depth stored bytes of every key below the current node stand for.
+// Escaped is whether or not the stored byte at depth follows an escape byte.
+func (t *ArtTree) matchHelper(current *ArtNode, pattern *Pattern, state int32, depth int, escaped bool, callback func(*ArtNode)) {
+	// Advances the pattern over the passed in stored byte, and returns whether or not it can still lead to a match.
+	advance := func(b byte) bool {
+		b, ok, next := unescapeByte(b, escaped)
+		if escaped = next; ok {
+			state = pattern.step(state, b)
+		}
+
+		return state != PATTERN_DEAD
+	}
+
+	if current.IsLeaf() {
+		key := current.leaf().key
+		for ; depth < len(key)-1; depth++ {
+			if !advance(key[depth]) {
+				return
+			}
+		}
+
+		if pattern.accepts(state) {
+			callback(current)
+		}
+
+		return
+	}
+
+	// Advance the pattern over the compressed path.
+	inner := current.inner()
+	if inner.prefixLen > 0 {
+		for _, b := range current.compressedPath(depth) {
+			if !advance(b) {
+				return
+			}
+		}
+
+		depth += int(inner.prefixLen)
+	}
+
+	current.eachChild(func(key byte, child *ArtNode) {
+		// Leaves advance the pattern over the rest of their own key, which lets them skip the null terminator.
+		if child.IsLeaf() {
+			t.matchHelper(child, pattern, state, depth, escaped, callback)
+			return
+		}
+
+		next := state
+		b, ok, nextEscaped := unescapeByte(key, escaped)
+		if ok {
+			next = pattern.step(state, b)
+		}
+
+		if next != PATTERN_DEAD {
+			t.matchHelper(child, pattern, next, depth+1, nextEscaped, callback)
+		}
+	})
+}
+
+// Returns the row of the Levenshtein distance table that follows the passed in row,
+// after appending the passed in byte to the key.
+func levenshteinRow(query []byte, previous []int, b byte) []int {
+	row := make([]int, len(previous))
+	row[0] = previous[0] + 1
+
+	for i := 1; i < len(row); i++ {
+		substitution := previous[i-1]
+		if query[i-1] != b {
+			substitution++
+		}
+
+		row[i] = min(min(previous[i]+1, row[i-1]+1), substitution)
+	}
+
+	return row
+}
+
+// Returns the smallest distance in the passed in row.
+func minRow(row []int) int {
+	smallest := row[0]
+	for _, distance := range row[1:] {
+		smallest = min(smallest, distance)
+	}
+
+	return smallest
+}
+
+// Returns whether or not the passed in stored key, without the null byte the tree appended to it,
+// is a prefix of the passed in indexed key.
+func isKeyPrefix(stored []byte, key []byte) bool {
+	return bytes.HasPrefix(key, stored[:len(stored)-1])
+}
+
+// Returns the passed in key without its last byte, if that is its only null byte.
+// Since a key that ends in its only null byte is indexed identically with or without it,
+// such a null byte is always treated as the terminator the tree appends to keys.
+func trimNullTerminator(key []byte) []byte {
+	if index := bytes.IndexByte(key, 0); index >= 0 && index == len(key)-1 {
+		return key[:index]
+	}
+
+	return key
+}
+
 // Inserts the passed in value that is indexed by the passed in key into the ArtTree.
+// If the key is already present, its value is replaced, and it keeps any score or expiry it had.
 func (t *ArtTree) Insert(key []byte, value interface{}) {
-	key = ensureNullTerminatedKey(key)
-	t.insertHelper(t.root, &t.root, key, value, 0)
+	var ext *leafExt
+	if t.options.KeyTransform != nil {
+		ext = &leafExt{originalKey: append([]byte{}, key...)}
+	}
+
+	t.insert(t.indexKey(key), value, ext)
+}
+
+// Inserts the passed in value that is indexed by the passed in key into the ArtTree, with the passed in score
+// for TopK to rank it by.  If the key is already present, its value and score are replaced,
+// and it keeps any expiry it had.
+// The highest score below every inner node on the path to the key is updated accordingly.
+func (t *ArtTree) InsertWithScore(key []byte, value interface{}, score float64) {
+	ext := &leafExt{score: scoreKey(score)}
+	if t.options.KeyTransform != nil {
+		ext.originalKey = append([]byte{}, key...)
+	}
+
+	t.scored = true
+	t.insert(t.indexKey(key), value, ext)
+}
+
+// Inserts the passed in value under the passed in indexed key.  If the tree is watched, holds keys that expire
+// or evicts keys, the previous leaf of the key is looked up first, so that the change can be reported to
+// the subscriptions of the tree, and the expiry and usage indexes can be kept up to date.
+// A key whose previous leaf has expired is reported as inserted.
+func (t *ArtTree) insert(key []byte, value interface{}, ext *leafExt) {
+	if t.bounded() {
+		if ext == nil {
+			ext = &leafExt{}
+		}
+
+		ext.usage = &leafUsage{}
+	}
+
+	var expires int64
+	if ext != nil {
+		expires = ext.expires
+	}
+
+	if !t.watched() && !t.expiring() && !t.bounded() && expires == 0 {
+		t.insertHelper(t.root, &t.root, key, value, ext, 0)
+		return
+	}
+
+	var oldValue interface{}
+	var oldExpires int64
+	var oldUsage *leafUsage
+	present := false
+	if old := t.searchHelper(t.root, key, 0); old != nil {
+		oldValue, oldExpires, oldUsage, present = old.leaf().value, old.expiry(), old.usage(), !t.expired(old)
+
+		if t.bounded() {
+			t.bytes -= t.entryBytes(old.leaf().key, oldValue, old.ext())
+		}
+	}
+
+	// A key that is present keeps its expiry unless a new one is passed in.
+	if present && expires == 0 {
+		expires = oldExpires
+	}
+
+	t.insertHelper(t.root, &t.root, key, value, ext, 0)
+	t.updateExpiry(key, oldExpires, expires)
+
+	if t.watched() {
+		var originalKey []byte
+		if ext != nil {
+			originalKey = ext.originalKey
+		}
+
+		if present {
+			t.notify(key, originalKey, WatchEvent{Type: WATCH_UPDATE, OldValue: oldValue, NewValue: value})
+		} else {
+			t.notify(key, originalKey, WatchEvent{Type: WATCH_INSERT, NewValue: value})
+		}
+	}
+
+	if t.bounded() {
+		t.updateUsage(key, oldUsage, ext.usage)
+		t.bytes += t.entryBytes(key, value, ext)
+		t.evict(key)
+	}
 }
 
 // Recursive helper function that traverses the tree until an insertion point is found.
@@ -77,12 +743,24 @@ func (t *ArtTree) Insert(key []byte, value interface{}) {
 //
 // If there is no child at the specified key at the current depth of traversal, a new leaf node
 // is created and inserted at this position.
-func (t *ArtTree) insertHelper(current *ArtNode, currentRef **ArtNode, key []byte, value interface{}, depth int) {
+//
+// New leaves carry the passed in optional attributes, if they are not nil.
+func (t *ArtTree) insertHelper(current *ArtNode, currentRef **ArtNode, key []byte, value interface{}, ext *leafExt, depth int) {
+	// Once the insertion below has completed, recompute the highest score below the node at this position.
+	if t.scored {
+		defer t.refreshMaxScore(currentRef)
+	}
+
+	// Likewise for the attributes computed from the leaves below the node at this position.
+	if t.augmented() {
+		defer t.refreshAugment(currentRef)
+	}
+
 	// @spec: Usually, the leaf can
 	//        simply be inserted into an existing inner node, after growing
 	//        it if necessary.
 	if current == nil {
-		*currentRef = NewLeafNode(key, value)
+		*currentRef = t.newLeaf(key, value, ext)
 		t.size += 1
 		return
 	}
@@ -92,94 +770,143 @@ func (t *ArtTree) insertHelper(current *ArtNode, currentRef **ArtNode, key []byt
 	//        inner node storing the existing and the new leaf
 	if current.IsLeaf() {
 
-		// TODO Determine if we should overwrite keys if they are attempted to overwritten.
-		//      Currently, we bail if the key matches.
+		// Replace the value of a matching leaf.  The optional attributes that are passed in replace those of the leaf,
+		// which keeps any attribute that is not passed in, unless it has expired.
 		if current.IsMatch(key) {
+			expired := t.expired(current)
+			if ext == nil && !expired {
+				current = current.unshare(t)
+				current.leaf().value = value
+				*currentRef = current
+				return
+			}
+
+			if old := current.ext(); old != nil && ext != nil && !expired {
+				ext.inherit(old)
+			}
+
+			*currentRef = t.newLeaf(key, value, ext)
+			t.arena.free(current)
 			return
 		}
 
 		// Create a new Inner Node to contain the new Leaf and the current node.
-		newNode4 := NewNode4()
-		newLeafNode := NewLeafNode(key, value)
+		newNode4 := t.newInnerNode(NODE4)
+		newLeafNode := t.newLeaf(key, value, ext)
 
 		// Determine the longest common prefix between our current node and the key
 		limit := current.LongestCommonPrefix(newLeafNode, depth)
 
-		newNode4.prefixLen = limit
+		newNode4.inner().setPrefix(key[depth:], limit, t.prefixCapacity())
 
-		memcpy(newNode4.prefix, key[depth:], min(newNode4.prefixLen, MAX_PREFIX_LEN))
+		// Add both children to the new Inner Node
+		newNode4 = newNode4.addChild(t, current.leaf().key[depth+limit], current)
+		newNode4 = newNode4.addChild(t, key[depth+limit], newLeafNode)
 
 		*currentRef = newNode4
 
-		// Add both children to the new Inner Node
-		newNode4.AddChild(current.key[depth+newNode4.prefixLen], current)
-		newNode4.AddChild(key[depth+newNode4.prefixLen], newLeafNode)
-
 		t.size += 1
 		return
 	}
 
+	// Every inner node on the path is changed below, so a shared one is replaced by a copy first.
+	current = current.unshare(t)
+	*currentRef = current
+
 	// @spec: Another special case occurs if the key of the new leaf
 	//        differs from a compressed path: A new inner node is created
 	//        above the current node and the compressed paths are adjusted accordingly.
-	if current.prefixLen != 0 {
+	inner := current.inner()
+	if inner.prefixLen != 0 {
 		mismatch := current.PrefixMismatch(key, depth)
 
 		// If the key differs from the compressed path
-		if mismatch != current.prefixLen {
+		if mismatch != int(inner.prefixLen) {
 
 			// Create a new Inner Node that will contain the current node
 			// and the desired insertion key
-			newNode4 := NewNode4()
-			*currentRef = newNode4
-			newNode4.prefixLen = mismatch
+			newNode4 := t.newInnerNode(NODE4)
+
+			path := current.compressedPath(depth)
 
 			// Copy the mismatched prefix into the new inner node.
-			memcpy(newNode4.prefix, current.prefix, mismatch)
+			newNode4.inner().setPrefix(path, mismatch, t.prefixCapacity())
 
 			// Adjust prefixes so they fit underneath the new inner node
-			if current.prefixLen < MAX_PREFIX_LEN {
-				newNode4.AddChild(current.prefix[mismatch], current)
-				current.prefixLen -= (mismatch + 1)
-				memmove(current.prefix, current.prefix[mismatch+1:], min(current.prefixLen, MAX_PREFIX_LEN))
-			} else {
-				current.prefixLen -= (mismatch + 1)
-				minKey := current.Minimum().key
-				newNode4.AddChild(minKey[depth+mismatch], current)
-				memmove(current.prefix, minKey[depth+mismatch+1:], min(current.prefixLen, MAX_PREFIX_LEN))
-			}
+			newNode4 = newNode4.addChild(t, path[mismatch], current)
+			inner.setPrefix(path[mismatch+1:], int(inner.prefixLen)-(mismatch+1), t.prefixCapacity())
 
 			// Attach the desired insertion key
-			newLeafNode := NewLeafNode(key, value)
-			newNode4.AddChild(key[depth+mismatch], newLeafNode)
+			newLeafNode := t.newLeaf(key, value, ext)
+			newNode4 = newNode4.addChild(t, key[depth+mismatch], newLeafNode)
+			*currentRef = newNode4
 
 			t.size += 1
 			return
 		}
 
-		depth += current.prefixLen
+		depth += int(inner.prefixLen)
 	}
 
 	// Find the next child
-	next := current.FindChild(key[depth])
+	next := current.findChild(key[depth])
 
 	// If we found a child that matches the key at the current depth
-	if *next != nil {
+	if next != nil {
 
-		// Recurse, and keep looking for an insertion point
-		t.insertHelper(*next, next, key, value, depth+1)
+		// Recurse, and keep looking for an insertion point,
+		// counting the new leaf if the insertion below added one.
+		size := t.size
+		t.insertHelper(*next, next, key, value, ext, depth+1)
+		if t.size != size {
+			current.inner().count += 1
+		}
 
 	} else {
 		// Otherwise, Add the child at the current position.
-		current.AddChild(key[depth], NewLeafNode(key, value))
+		*currentRef = current.addChild(t, key[depth], t.newLeaf(key, value, ext))
 		t.size += 1
 	}
 }
 
 // Removes the child that is accessed by the passed in key.
 func (t *ArtTree) Remove(key []byte) {
-	key = ensureNullTerminatedKey(key)
+	t.remove(t.indexKey(key))
+}
+
+// Removes the passed in indexed key.  If the tree is watched, holds keys that expire or evicts keys,
+// the leaf of the key is looked up first, so that the removal can be reported to the subscriptions of the tree,
+// and the expiry and usage indexes can be kept up to date.
+func (t *ArtTree) remove(key []byte) {
+	if !t.watched() && !t.expiring() && !t.bounded() {
+		t.removeHelper(t.root, &t.root, key, 0)
+		return
+	}
+
+	old := t.searchHelper(t.root, key, 0)
+	if old == nil {
+		return
+	}
+
+	oldValue, oldExpires, oldUsage := old.leaf().value, old.expiry(), old.usage()
+	var oldOriginalKey []byte
+	if ext := old.ext(); ext != nil {
+		oldOriginalKey = ext.originalKey
+	}
+	if t.bounded() {
+		t.bytes -= t.entryBytes(old.leaf().key, oldValue, old.ext())
+	}
+
 	t.removeHelper(t.root, &t.root, key, 0)
+	t.updateExpiry(key, oldExpires, 0)
+
+	if t.bounded() {
+		t.updateUsage(key, oldUsage, nil)
+	}
+
+	if t.watched() {
+		t.notify(key, oldOriginalKey, WatchEvent{Type: WATCH_DELETE, OldValue: oldValue})
+	}
 }
 
 // Recursive helper for Removing child nodes.
@@ -190,49 +917,380 @@ func (t *ArtTree) Remove(key []byte) {
 // If the next child at the specifed key and depth matches,
 // the current node shall remove it accordingly.
 func (t *ArtTree) removeHelper(current *ArtNode, currentRef **ArtNode, key []byte, depth int) {
+	// Once the removal below has completed, recompute the highest score below the node at this position.
+	if t.scored {
+		defer t.refreshMaxScore(currentRef)
+	}
+
+	// Likewise for the attributes computed from the leaves below the node at this position.
+	if t.augmented() {
+		defer t.refreshAugment(currentRef)
+	}
+
 	// Bail early if we are at a nil node.
 	if current == nil {
 		return
 	}
 
 	// If the current node matches, remove it.
+	// Otherwise, bail since leaves have no children to recurse into.
 	if current.IsLeaf() {
 		if current.IsMatch(key) {
 			*currentRef = nil
+			t.arena.free(current)
 			t.size -= 1
-			return
 		}
+		return
 	}
 
-	// If the current node contains a prefix length
-	if current.prefixLen != 0 {
+	// Bail out if we encounter a mismatch
+	if !t.prefixMatches(current, key, depth) {
+		return
+	}
 
-		// Bail out if we encounter a mismatch
-		mismatch := current.PrefixMismatch(key, depth)
-		if mismatch != current.prefixLen {
-			return
-		}
+	// Increase traversal depth
+	depth += int(current.inner().prefixLen)
 
-		// Increase traversal depth
-		depth += current.prefixLen
+	// Find the next child
+	next := current.findChild(key[depth])
+
+	// Bail if there is no child to remove or recurse into
+	if next == nil {
+		return
 	}
 
-	// Find the next child
-	next := current.FindChild(key[depth])
+	// The children of the node are changed below, so a shared node is replaced by a copy first.
+	if current.flags&sharedFlag != 0 {
+		current = current.unshare(t)
+		*currentRef = current
+		next = current.findChild(key[depth])
+	}
 
 	// Let the Inner Node handle the removal logic if the child is a match
-	if *next != nil && (*next).IsLeaf() && (*next).IsMatch(key) {
-		current.RemoveChild(key[depth])
+	if (*next).IsLeaf() && (*next).IsMatch(key) {
+		child := *next
+		*currentRef = current.removeChild(t, key[depth])
+		t.arena.free(child)
 		t.size -= 1
-		// Otherwise, recurse.	t.size -= 1
+		// Otherwise, recurse.
 	} else {
+		size := t.size
 		t.removeHelper(*next, next, key, depth+1)
+		if t.size != size {
+			current.inner().count -= 1
+		}
 	}
 }
 
-// Convenience method for EachPreorder
+// Convenience method for EachPreorder.  Leaves that have expired are skipped.
 func (t *ArtTree) Each(callback func(*ArtNode)) {
-	t.eachHelper(t.root, callback)
+	t.eachHelper(t.root, func(n *ArtNode) {
+		if !n.IsLeaf() || !t.expired(n) {
+			callback(n)
+		}
+	})
+}
+
+// Executes the passed in callback for every leaf whose key starts with the passed in prefix,
+// in key order.  Only the subtree below the prefix is visited, and leaves that have expired are skipped.
+func (t *ArtTree) ScanPrefix(prefix []byte, callback func(*ArtNode)) {
+	t.eachHelper(t.prefixRoot(prefix), func(n *ArtNode) {
+		if n.IsLeaf() && !t.expired(n) {
+			callback(n)
+		}
+	})
+}
+
+// Returns up to k leaves whose keys start with the passed in prefix, in descending order of their scores,
+// and in key order among equal scores.  Leaves that were not inserted with a score are returned last,
+// and leaves that have expired are skipped.
+//
+// From the root of the prefix, the search always visits the node with the highest score below it,
+// so it only visits the subtrees that can contain one of the results.
+func (t *ArtTree) TopK(prefix []byte, k int) []*ArtNode {
+	root := t.prefixRoot(prefix)
+	if root == nil || k <= 0 {
+		return nil
+	}
+
+	results := []*ArtNode{}
+
+	h := &scoreHeap{newScoreHeapItem(root)}
+	for h.Len() > 0 && len(results) < k {
+		current := heap.Pop(h).(scoreHeapItem).node
+		if current.IsLeaf() {
+			if !t.expired(current) {
+				results = append(results, current)
+			}
+			continue
+		}
+
+		current.eachChild(func(key byte, child *ArtNode) {
+			heap.Push(h, newScoreHeapItem(child))
+		})
+	}
+
+	return results
+}
+
+// Returns the number of keys in the tree that are less than the passed in key.
+// Leaves below an inner node are counted at once, using the number of leaves it keeps below itself,
+// and keys that have expired but have not been removed by Sweep yet are then subtracted,
+// so that only the keys that Search finds are counted.
+func (t *ArtTree) Rank(key []byte) int64 {
+	key = t.indexKey(key)
+
+	expired := t.expiredKeys()
+	below := sort.Search(len(expired), func(i int) bool { return bytes.Compare(expired[i], key) >= 0 })
+
+	return t.rank(key) - int64(below)
+}
+
+// Returns the number of leaves in the tree whose keys are less than the passed in indexed key,
+// including the leaves that have expired.
+func (t *ArtTree) rank(key []byte) int64 {
+	var rank int64
+	current := t.root
+	depth := 0
+
+	for current != nil {
+		if current.IsLeaf() {
+			if bytes.Compare(current.leaf().key, key) < 0 {
+				rank += 1
+			}
+
+			return rank
+		}
+
+		// Every leaf below the node is less than the key if its compressed path is less than the key,
+		// and none of them are if its compressed path is greater than the key.
+		inner := current.inner()
+		if inner.prefixLen > 0 {
+			path := current.compressedPath(depth)
+			end := min(depth+len(path), len(key))
+
+			if c := bytes.Compare(path[:end-depth], key[depth:end]); c < 0 {
+				return rank + int64(inner.count)
+			} else if c > 0 || end == len(key) {
+				return rank
+			}
+
+			depth += len(path)
+		}
+
+		// Keys that end here are a prefix of every key below the node.
+		if depth >= len(key) {
+			return rank
+		}
+
+		rank += current.countBelow(key[depth])
+
+		next := current.findChild(key[depth])
+		if next == nil {
+			return rank
+		}
+
+		current = *next
+		depth++
+	}
+
+	return rank
+}
+
+// Returns the leaf holding the key at the passed in position in key order, starting from zero,
+// or nil if the position is out of range.  Like Rank, positions only count the keys that Search finds:
+// the position is moved past every key that has expired before the leaf it reaches.
+func (t *ArtTree) Select(position int64) *ArtNode {
+	expired := t.expiredKeys()
+	if position < 0 || position >= t.size-int64(len(expired)) {
+		return nil
+	}
+
+	// The expired keys are in key order, so each one that is not after the position so far moves it by one.
+	for _, key := range expired {
+		if t.rank(key) > position {
+			break
+		}
+
+		position++
+	}
+
+	current := t.root
+	for current != nil && !current.IsLeaf() {
+		current, position = current.selectChild(position)
+	}
+
+	return current
+}
+
+// Returns the aggregate of the values of every key in the tree that is at least lo and less than hi,
+// combined in key order by the Aggregator of the tree, or nil if the tree does not have one.
+// A nil lo or hi leaves the range unbounded on that side.
+// Subtrees that lie entirely within the range contribute the aggregate kept on their root,
+// so only the nodes along the paths to lo and hi are visited.  Like Rank, keys that have expired
+// do not contribute their values: the range is split around each of them, and the parts are combined.
+func (t *ArtTree) Aggregate(lo, hi []byte) interface{} {
+	if t.options.Aggregator == nil {
+		return nil
+	}
+
+	if lo != nil {
+		lo = t.indexKey(lo)
+	}
+
+	if hi != nil {
+		hi = t.indexKey(hi)
+	}
+
+	result := t.options.Aggregator.Identity()
+	for _, key := range t.expiredKeys() {
+		if lo != nil && bytes.Compare(key, lo) < 0 {
+			continue
+		}
+
+		if hi != nil && bytes.Compare(key, hi) >= 0 {
+			break
+		}
+
+		// No stored key starts with another, so the next stored key is at least the expired key followed by a null byte.
+		result = t.options.Aggregator.Combine(result, t.aggregateHelper(t.root, lo, key, 0))
+		lo = append(append([]byte{}, key...), 0)
+	}
+
+	return t.options.Aggregator.Combine(result, t.aggregateHelper(t.root, lo, hi, 0))
+}
+
+// Recursive helper for Aggregate, which aggregates the values below the current node
+// whose keys lie between the passed in bounds.  A nil bound no longer constrains the keys below the node.
+func (t *ArtTree) aggregateHelper(current *ArtNode, lo, hi []byte, depth int) interface{} {
+	agg := t.options.Aggregator
+
+	if current == nil {
+		return agg.Identity()
+	}
+
+	if lo == nil && hi == nil {
+		return current.subtreeAggregate(agg)
+	}
+
+	if current.IsLeaf() {
+		key := current.leaf().key
+		if (lo != nil && bytes.Compare(key, lo) < 0) || (hi != nil && bytes.Compare(key, hi) >= 0) {
+			return agg.Identity()
+		}
+
+		return agg.Lift(current.leaf().value)
+	}
+
+	// A bound stops constraining the keys below the node once the compressed path differs from it,
+	// or once it is exhausted, since it is then a prefix of every key below the node.
+	inner := current.inner()
+	if inner.prefixLen > 0 {
+		path := current.compressedPath(depth)
+
+		if lo != nil {
+			end := min(depth+len(path), len(lo))
+			if c := bytes.Compare(path[:end-depth], lo[depth:end]); c < 0 {
+				return agg.Identity()
+			} else if c > 0 || end == len(lo) {
+				lo = nil
+			}
+		}
+
+		if hi != nil {
+			end := min(depth+len(path), len(hi))
+			if c := bytes.Compare(path[:end-depth], hi[depth:end]); c > 0 || (c == 0 && end == len(hi)) {
+				return agg.Identity()
+			} else if c < 0 {
+				hi = nil
+			}
+		}
+
+		depth += len(path)
+	}
+
+	if lo != nil && depth >= len(lo) {
+		lo = nil
+	}
+
+	if hi != nil && depth >= len(hi) {
+		return agg.Identity()
+	}
+
+	if lo == nil && hi == nil {
+		return current.subtreeAggregate(agg)
+	}
+
+	result := agg.Identity()
+	current.eachChild(func(key byte, child *ArtNode) {
+		childLo, childHi := lo, hi
+
+		if lo != nil {
+			if key < lo[depth] {
+				return
+			} else if key > lo[depth] {
+				childLo = nil
+			}
+		}
+
+		if hi != nil {
+			if key > hi[depth] {
+				return
+			} else if key < hi[depth] {
+				childHi = nil
+			}
+		}
+
+		result = agg.Combine(result, t.aggregateHelper(child, childLo, childHi, depth+1))
+	})
+
+	return result
+}
+
+// Returns the node whose leaves are exactly the leaves whose keys start with the passed in prefix,
+// or nil if there are none.  The KeyTransform of the tree is applied to the prefix.
+func (t *ArtTree) prefixRoot(prefix []byte) *ArtNode {
+	n, _ := t.indexedPrefixRoot(t.indexPrefix(prefix))
+	return n
+}
+
+// Returns the node whose leaves are exactly the leaves whose indexed keys start with the passed in prefix,
+// along with the depth its keys start at, or nil if there are none.
+func (t *ArtTree) indexedPrefixRoot(prefix []byte) (*ArtNode, int) {
+	current := t.root
+	depth := 0
+
+	for current != nil {
+		if current.IsLeaf() {
+			if bytes.HasPrefix(current.leaf().key, prefix) {
+				return current, depth
+			}
+
+			return nil, 0
+		}
+
+		// Bail if the compressed path diverges from the prefix.
+		inner := current.inner()
+		if current.PrefixMismatch(prefix, depth) < min(int(inner.prefixLen), len(prefix)-depth) {
+			return nil, 0
+		}
+
+		// Every leaf below this node shares the prefix once it is exhausted.
+		if depth+int(inner.prefixLen) >= len(prefix) {
+			return current, depth
+		}
+
+		depth += int(inner.prefixLen)
+		next := current.findChild(prefix[depth])
+		if next == nil {
+			return nil, 0
+		}
+
+		current = *next
+		depth++
+	}
+
+	return nil, 0
 }
 
 // Recursive helper for iterative over the ArtTree.  Iterates over all nodes in the tree,
@@ -245,13 +1303,19 @@ func (t *ArtTree) eachHelper(current *ArtNode, callback func(*ArtNode)) {
 
 	callback(current)
 
+	// Leaves do not have any children to iterate over
+	if current.IsLeaf() {
+		return
+	}
+
 	// Art Nodes of type NODE48 do not necessarily store their children in sorted order.
 	// So we must instead iterate over their keys, acccess the children, and iterate properly.
 	if current.nodeType == NODE48 {
-		for i := 0; i < len(current.keys); i++ {
-			index := current.keys[byte(i)]
+		n48 := current.node48()
+		for i := 0; i < len(n48.keys); i++ {
+			index := n48.keys[i]
 			if index > 0 {
-				next := current.children[index-1]
+				next := n48.children[index-1]
 
 				if next != nil {
 
@@ -265,8 +1329,9 @@ func (t *ArtTree) eachHelper(current *ArtNode, callback func(*ArtNode)) {
 		// So we can access them iteratively.
 	} else {
 
-		for i := 0; i < len(current.children); i++ {
-			next := current.children[i]
+		children := current.children()
+		for i := 0; i < len(children); i++ {
+			next := children[i]
 
 			if next != nil {
 
@@ -277,30 +1342,67 @@ func (t *ArtTree) eachHelper(current *ArtNode, callback func(*ArtNode)) {
 	}
 }
 
-func memcpy(dest []byte, src []byte, numBytes int) {
-	for i := 0; i < numBytes && i < len(src) && i < len(dest); i++ {
-		dest[i] = src[i]
-	}
+// Precedes every null byte and escape byte of a stored key, which are stored as the byte after them.
+const keyEscape = 1
+
+// Returns the passed in key as it is stored in the tree: with every null byte and escape byte escaped,
+// followed by a null byte.  Since the escaped bytes sort in the same order as the bytes they stand for,
+// and the terminator sorts before all of them, stored keys sort in the order of the keys,
+// and no stored key is a prefix of another.  A key that ends in its only null byte is stored without it.
+func escapeKey(key []byte) []byte {
+	return append(escapePrefix(trimNullTerminator(key)), 0)
 }
 
-func memmove(dest []byte, src []byte, numBytes int) {
-	for i := 0; i < numBytes; i++ {
-		dest[i] = src[i]
+// Returns the passed in prefix with every null byte and escape byte escaped, without a terminator,
+// so that it is a prefix of the stored form of exactly the keys that start with it.
+// Prefixes without such bytes are returned as is.
+func escapePrefix(prefix []byte) []byte {
+	if bytes.IndexByte(prefix, 0) < 0 && bytes.IndexByte(prefix, keyEscape) < 0 {
+		return prefix
 	}
-}
 
-// Returns the passed in key as a null terminated byte array
-// if it is not already null terminated.
-func ensureNullTerminatedKey(key []byte) []byte {
-	index := bytes.Index(key, []byte{0})
+	escaped := make([]byte, 0, len(prefix)+8)
+	for _, b := range prefix {
+		if b <= keyEscape {
+			escaped = append(escaped, keyEscape, b+1)
+		} else {
+			escaped = append(escaped, b)
+		}
+	}
 
-	// Is there a null terminated character?
-	if index < 0 {
+	return escaped
+}
 
-		// Append one.
-		key = append(key, byte(0))
+// Returns the key that the passed in stored key stands for, without the null byte the tree appended to it.
+// Stored keys without escaped bytes are returned as a slice of themselves.
+func unescapeKey(stored []byte) []byte {
+	stored = stored[:len(stored)-1]
+	if bytes.IndexByte(stored, keyEscape) < 0 {
+		return stored
+	}
 
+	key := make([]byte, 0, len(stored))
+	for i := 0; i < len(stored); i++ {
+		if stored[i] == keyEscape && i+1 < len(stored) {
+			i++
+			key = append(key, stored[i]-1)
+		} else {
+			key = append(key, stored[i])
+		}
 	}
 
 	return key
 }
+
+// Returns the byte of a key that the passed in stored byte stands for, given whether it follows an escape byte,
+// along with whether it completes that byte and whether the next stored byte follows an escape byte.
+func unescapeByte(b byte, escaped bool) (byte, bool, bool) {
+	switch {
+	case escaped:
+		return b - 1, true, false
+	case b == keyEscape:
+		return 0, false, true
+	default:
+		return b, true, false
+	}
+}
@@ -4,10 +4,13 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/binary"
-	_ "fmt"
+	"fmt"
 	_ "log"
 	"math/rand"
 	"os"
+	"runtime"
+	"sort"
+	"strings"
 	"testing"
 )
 
@@ -194,7 +197,7 @@ func TestInsertManyWordsAndEnsureSearchResultAndMinimumMaximum(t *testing.T) {
 			}
 
 			if bytes.Compare(res.([]byte), []byte(line)) != 0 {
-				t.Error("Incorrect value for node %v.", []byte(line))
+				t.Errorf("Incorrect value for node %v.", []byte(line))
 			}
 		}
 	}
@@ -250,7 +253,7 @@ func TestInsertManyUUIDsAndEnsureSearchAndMinimumMaximum(t *testing.T) {
 			}
 
 			if bytes.Compare(res.([]byte), []byte(line)) != 0 {
-				t.Error("Incorrect value for node %v.", []byte(line))
+				t.Errorf("Incorrect value for node %v.", []byte(line))
 			}
 		}
 	}
@@ -507,11 +510,11 @@ func TestEachPreOrderness(t *testing.T) {
 		t.Error("Unexpected node at begining of traversal")
 	}
 
-	if bytes.Compare(traversal[1].key, append([]byte("1"), 0)) != 0 || traversal[1].nodeType != LEAF {
+	if bytes.Compare(traversal[1].Key(), append([]byte("1"), 0)) != 0 || traversal[1].nodeType != LEAF {
 		t.Error("Unexpected node at second element of traversal")
 	}
 
-	if bytes.Compare(traversal[2].key, append([]byte("2"), 0)) != 0 || traversal[2].nodeType != LEAF {
+	if bytes.Compare(traversal[2].Key(), append([]byte("2"), 0)) != 0 || traversal[2].nodeType != LEAF {
 		t.Error("Unexpected node at third element of traversal")
 	}
 }
@@ -539,7 +542,7 @@ func TestEachNode48(t *testing.T) {
 	}
 
 	for i := 1; i < 48; i++ {
-		if bytes.Compare(traversal[i].key, append([]byte{byte(i)}, 0)) != 0 || traversal[i].nodeType != LEAF {
+		if bytes.Compare(traversal[i].Key(), append([]byte{byte(i)}, 0)) != 0 || traversal[i].nodeType != LEAF {
 			t.Error("Unexpected node at second element of traversal")
 		}
 	}
@@ -718,6 +721,7 @@ func TestInsertWithSameByteSliceAddress(t *testing.T) {
 	for i := 0; i < 135; i++ {
 		binary.BigEndian.PutUint64(key, uint64(rand.Int63()))
 		tree.Insert(key, key)
+		mustValidate(t, tree)
 
 		// Ensure that we can search these records later
 		keys[string(key)] = true
@@ -734,3 +738,913 @@ func TestInsertWithSameByteSliceAddress(t *testing.T) {
 		}
 	}
 }
+
+// Inserting a key that is already in the tree should replace its value, without changing the size of the tree.
+func TestInsertReplacesExistingValue(t *testing.T) {
+	for _, arena := range []bool{false, true} {
+		tree := NewArtTreeWithOptions(Options{Arena: arena})
+		words := loadAsset(t, "test/assets/words.txt")[:5000]
+
+		for _, word := range words {
+			tree.Insert(word, 1)
+		}
+
+		for i, word := range words {
+			tree.Insert(word, 2)
+
+			if i%500 == 0 {
+				mustValidate(t, tree)
+			}
+		}
+
+		if tree.size != int64(len(words)) {
+			t.Errorf("Expected a size of %d after replacing every value, got %d", len(words), tree.size)
+		}
+
+		for _, word := range words {
+			if res := tree.Search(word); res != 2 {
+				t.Errorf("Expected the value of %q to be replaced, got %v", word, res)
+			}
+		}
+	}
+
+	// Leaves of a tree with a KeyTransform are replaced along with the key they were inserted with.
+	tree := NewArtTreeWithOptions(Options{KeyTransform: bytes.ToLower})
+	tree.Insert([]byte("Key"), 1)
+	tree.Insert([]byte("KEY"), 2)
+
+	if tree.size != 1 || tree.Search([]byte("key")) != 2 {
+		t.Error("Expected the value of the transformed key to be replaced")
+	}
+
+	tree.Each(func(n *ArtNode) {
+		if n.IsLeaf() && string(n.OriginalKey()) != "KEY" {
+			t.Errorf("Expected the original key to be replaced, got %q", n.OriginalKey())
+		}
+	})
+}
+
+// Returns every line of the passed in test asset, including the trailing newline
+// so that the keys match the ones inserted by the tests above.
+func loadAsset(tb testing.TB, path string) [][]byte {
+	file, err := os.Open(path)
+	if err != nil {
+		tb.Fatalf("Couldn't open %s", path)
+	}
+
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	lines := [][]byte{}
+
+	for {
+		if line, err := reader.ReadBytes('\n'); err != nil {
+			break
+		} else {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// Inserts every line of the passed in test asset into a fresh tree, and reports
+// the number of heap bytes retained and allocations made per key.
+func benchmarkInsertMemory(b *testing.B, path string, options Options) {
+	keys := loadAsset(b, path)
+
+	var before, after runtime.MemStats
+	var bytesPerKey, allocsPerKey float64
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		b.StartTimer()
+
+		tree := NewArtTreeWithOptions(options)
+		for _, key := range keys {
+			tree.Insert(key, key)
+		}
+
+		b.StopTimer()
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		bytesPerKey = float64(int64(after.HeapAlloc)-int64(before.HeapAlloc)) / float64(len(keys))
+		allocsPerKey = float64(after.Mallocs-before.Mallocs) / float64(len(keys))
+		runtime.KeepAlive(tree)
+		b.StartTimer()
+	}
+
+	b.ReportMetric(bytesPerKey, "bytes/key")
+	b.ReportMetric(allocsPerKey, "allocs/key")
+}
+
+// Reports the memory usage of a tree containing every UUID in uuid.txt.
+func BenchmarkInsertUUIDsBytesPerKey(b *testing.B) {
+	benchmarkInsertMemory(b, "test/assets/uuid.txt", Options{})
+}
+
+// Reports the memory usage of a tree containing every word in words.txt.
+func BenchmarkInsertWordsBytesPerKey(b *testing.B) {
+	benchmarkInsertMemory(b, "test/assets/words.txt", Options{})
+}
+
+// Trees should behave the same regardless of how their compressed paths are stored.
+func TestPrefixModesInsertSearchAndRemoveManyKeys(t *testing.T) {
+	modes := []PrefixMode{PREFIX_HYBRID, PREFIX_OPTIMISTIC, PREFIX_PESSIMISTIC}
+	assets := []string{"test/assets/words.txt", "test/assets/uuid.txt"}
+
+	for _, asset := range assets {
+		keys := loadAsset(t, asset)
+
+		for _, mode := range modes {
+			tree := NewArtTreeWithOptions(Options{PrefixMode: mode})
+
+			for i, key := range keys {
+				tree.Insert(key, key)
+
+				if i%20000 == 0 {
+					mustValidate(t, tree)
+				}
+			}
+
+			for _, key := range keys {
+				res := tree.Search(key)
+				if res == nil || bytes.Compare(res.([]byte), key) != 0 {
+					t.Errorf("Incorrect value for node %v in mode %d.", key, mode)
+				}
+
+				if tree.Search(append([]byte("~"), key...)) != nil {
+					t.Errorf("Unexpected search result for missing key in mode %d.", mode)
+				}
+			}
+
+			for i, key := range keys {
+				tree.Remove(key)
+
+				if i%20000 == 0 {
+					mustValidate(t, tree)
+				}
+			}
+
+			if tree.size != 0 || tree.root != nil {
+				t.Errorf("Tree is expected to be empty after removing many keys in mode %d.", mode)
+			}
+		}
+	}
+}
+
+// Pessimistic trees should store every byte of their compressed paths,
+// while optimistic trees should only store their lengths.
+func TestPrefixModesStoredPrefixLengths(t *testing.T) {
+	words := loadAsset(t, "test/assets/words.txt")
+
+	pessimistic := NewArtTreeWithOptions(Options{PrefixMode: PREFIX_PESSIMISTIC})
+	optimistic := NewArtTreeWithOptions(Options{PrefixMode: PREFIX_OPTIMISTIC})
+
+	for _, word := range words {
+		pessimistic.Insert(word, word)
+		optimistic.Insert(word, word)
+	}
+
+	// Remove every other word so that nodes are collapsed and their paths concatenated.
+	for i := 0; i < len(words); i += 2 {
+		pessimistic.Remove(words[i])
+		optimistic.Remove(words[i])
+	}
+
+	pessimistic.Each(func(node *ArtNode) {
+		if !node.IsLeaf() && len(node.inner().prefix) != int(node.inner().prefixLen) {
+			t.Error("Expected pessimistic node to store its entire prefix")
+		}
+	})
+
+	optimistic.Each(func(node *ArtNode) {
+		if !node.IsLeaf() && len(node.inner().prefix) != 0 {
+			t.Error("Expected optimistic node to store none of its prefix")
+		}
+	})
+
+	for i := 1; i < len(words); i += 2 {
+		if pessimistic.Search(words[i]) == nil || optimistic.Search(words[i]) == nil {
+			t.Errorf("Did not find entry for key: %v", words[i])
+		}
+	}
+}
+
+// Optimistic searches skip compressed paths, so a key that only differs within one
+// must be rejected once its leaf has been reached.
+func TestOptimisticSearchComparesLeafKey(t *testing.T) {
+	tree := NewArtTreeWithOptions(Options{PrefixMode: PREFIX_OPTIMISTIC})
+
+	tree.Insert([]byte("compressed-1"), "1")
+	tree.Insert([]byte("compressed-2"), "2")
+
+	if tree.Search([]byte("compressed-1")) != "1" {
+		t.Error("Unexpected search result.")
+	}
+
+	if tree.Search([]byte("decompress-1")) != nil {
+		t.Error("Expected key that differs within the compressed path to be missing.")
+	}
+
+	if tree.Search([]byte("c")) != nil {
+		t.Error("Expected key that is shorter than the compressed path to be missing.")
+	}
+}
+
+// Searches for every line of the passed in test asset in a tree configured by the passed in options.
+func benchmarkSearch(b *testing.B, path string, options Options) {
+	benchmarkSearchKeys(b, loadAsset(b, path), options)
+}
+
+// Searches for each of the passed in keys in a tree configured by the passed in options.
+func benchmarkSearchKeys(b *testing.B, keys [][]byte, options Options) {
+	tree := NewArtTreeWithOptions(options)
+
+	for _, key := range keys {
+		tree.Insert(key, key)
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		tree.Search(keys[i%len(keys)])
+	}
+}
+
+func BenchmarkSearchWordsHybrid(b *testing.B) {
+	benchmarkSearch(b, "test/assets/words.txt", Options{PrefixMode: PREFIX_HYBRID})
+}
+
+func BenchmarkSearchWordsOptimistic(b *testing.B) {
+	benchmarkSearch(b, "test/assets/words.txt", Options{PrefixMode: PREFIX_OPTIMISTIC})
+}
+
+func BenchmarkSearchWordsPessimistic(b *testing.B) {
+	benchmarkSearch(b, "test/assets/words.txt", Options{PrefixMode: PREFIX_PESSIMISTIC})
+}
+
+func BenchmarkSearchUUIDsHybrid(b *testing.B) {
+	benchmarkSearch(b, "test/assets/uuid.txt", Options{PrefixMode: PREFIX_HYBRID})
+}
+
+func BenchmarkSearchUUIDsOptimistic(b *testing.B) {
+	benchmarkSearch(b, "test/assets/uuid.txt", Options{PrefixMode: PREFIX_OPTIMISTIC})
+}
+
+func BenchmarkSearchUUIDsPessimistic(b *testing.B) {
+	benchmarkSearch(b, "test/assets/uuid.txt", Options{PrefixMode: PREFIX_PESSIMISTIC})
+}
+
+// Returns keys that resemble URLs, which share long prefixes with each other.
+func urlKeys(count int) [][]byte {
+	keys := make([][]byte, count)
+	for i := range keys {
+		keys[i] = []byte(fmt.Sprintf("https://api.example.com/v1/accounts/%d/settings/%d", i%97, i))
+	}
+
+	return keys
+}
+
+// Trees should store as many bytes of each compressed path as their configured prefix capacity,
+// and continue to find every key.
+func TestMaxPrefixLenIsHonoured(t *testing.T) {
+	keys := urlKeys(5000)
+
+	for _, maxPrefixLen := range []int{1, MAX_PREFIX_LEN, 64} {
+		tree := NewArtTreeWithOptions(Options{MaxPrefixLen: maxPrefixLen})
+
+		for _, key := range keys {
+			tree.Insert(key, key)
+		}
+
+		// Remove some keys so that nodes are collapsed and their paths concatenated.
+		for i := 0; i < len(keys); i += 3 {
+			tree.Remove(keys[i])
+		}
+
+		tree.Each(func(node *ArtNode) {
+			if !node.IsLeaf() {
+				inner := node.inner()
+				if len(inner.prefix) != min(int(inner.prefixLen), maxPrefixLen) {
+					t.Errorf("Unexpected number of stored prefix bytes with a capacity of %d", maxPrefixLen)
+				}
+			}
+		})
+
+		for i, key := range keys {
+			res := tree.Search(key)
+			if i%3 == 0 && res != nil {
+				t.Errorf("Did not expect to find removed key: %s", key)
+			}
+
+			if i%3 != 0 && (res == nil || bytes.Compare(res.([]byte), key) != 0) {
+				t.Errorf("Did not find entry for key: %s", key)
+			}
+		}
+	}
+}
+
+// A prefix capacity that is not positive should fall back to the default.
+func TestMaxPrefixLenDefault(t *testing.T) {
+	tree := NewArtTreeWithOptions(Options{MaxPrefixLen: -1})
+
+	if tree.prefixCapacity() != MAX_PREFIX_LEN {
+		t.Error("Expected the default prefix capacity")
+	}
+}
+
+func BenchmarkSearchURLsMaxPrefixLen10(b *testing.B) {
+	benchmarkSearchKeys(b, urlKeys(100000), Options{MaxPrefixLen: 10})
+}
+
+func BenchmarkSearchURLsMaxPrefixLen64(b *testing.B) {
+	benchmarkSearchKeys(b, urlKeys(100000), Options{MaxPrefixLen: 64})
+}
+
+// ScanPrefix should visit exactly the keys that start with the prefix, in key order.
+func TestScanPrefixMatchesBruteForce(t *testing.T) {
+	modes := []PrefixMode{PREFIX_HYBRID, PREFIX_OPTIMISTIC, PREFIX_PESSIMISTIC}
+	prefixes := []string{"", "a", "ab", "inter", "photosynthe", "zzzzzzzz", "Z", "aardvark\n"}
+	keys := loadAsset(t, "test/assets/words.txt")
+
+	sorted := make([][]byte, len(keys))
+	copy(sorted, keys)
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i], sorted[j]) < 0 })
+
+	for _, mode := range modes {
+		tree := NewArtTreeWithOptions(Options{PrefixMode: mode})
+		for _, key := range keys {
+			tree.Insert(key, key)
+		}
+
+		for _, prefix := range prefixes {
+			expected := [][]byte{}
+			for _, key := range sorted {
+				if bytes.HasPrefix(key, []byte(prefix)) {
+					expected = append(expected, key)
+				}
+			}
+
+			actual := [][]byte{}
+			tree.ScanPrefix([]byte(prefix), func(n *ArtNode) {
+				actual = append(actual, n.Value().([]byte))
+			})
+
+			if len(actual) != len(expected) {
+				t.Errorf("Expected %d keys with prefix %q in mode %d, got %d", len(expected), prefix, mode, len(actual))
+				continue
+			}
+
+			for i := range expected {
+				if bytes.Compare(actual[i], expected[i]) != 0 {
+					t.Errorf("Unexpected key %q at position %d for prefix %q in mode %d", actual[i], i, prefix, mode)
+					break
+				}
+			}
+		}
+	}
+}
+
+// A tree with a KeyTransform should index keys by their transform, and keep the original keys in their leaves.
+func TestKeyTransformKeepsOriginalKey(t *testing.T) {
+	for _, arena := range []bool{false, true} {
+		tree := NewArtTreeWithOptions(Options{Arena: arena, KeyTransform: bytes.ToLower})
+		keys := loadAsset(t, "test/assets/uuid.txt")[:5000]
+
+		for _, key := range keys {
+			tree.Insert(bytes.ToUpper(key), key)
+		}
+
+		for _, key := range keys {
+			if res := tree.Search(key); res == nil || bytes.Compare(res.([]byte), key) != 0 {
+				t.Errorf("Expected to find %q regardless of case", key)
+			}
+		}
+
+		tree.Each(func(n *ArtNode) {
+			if n.IsLeaf() && bytes.Compare(n.OriginalKey(), bytes.ToUpper(n.Value().([]byte))) != 0 {
+				t.Errorf("Unexpected original key %q for %q", n.OriginalKey(), n.Key())
+			}
+		})
+
+		for _, key := range keys {
+			tree.Remove(key)
+		}
+
+		if tree.size != 0 || tree.root != nil {
+			t.Errorf("Tree is expected to be empty after removing every key with a different case.")
+		}
+	}
+
+	leaf := NewLeafNode([]byte("key"), nil)
+	if bytes.Compare(leaf.OriginalKey(), leaf.Key()) != 0 {
+		t.Error("Expected the original key of a plain leaf to be its key")
+	}
+}
+
+// LongestPrefixMatch should return the longest stored key that is a prefix of the query.
+func TestLongestPrefixMatchRoutes(t *testing.T) {
+	tree := NewArtTree()
+	routes := []string{"/", "/api", "/api/users", "/api/users/admin", "/static/", "a\x00b"}
+	for _, route := range routes {
+		tree.Insert([]byte(route), route)
+	}
+
+	cases := map[string]string{
+		"/":                      "/",
+		"/index.html":            "/",
+		"/api":                   "/api",
+		"/apis":                  "/api",
+		"/api/users":             "/api/users",
+		"/api/users/42":          "/api/users",
+		"/api/users/admin/roles": "/api/users/admin",
+		"/static":                "/",
+		"/static/app.js":         "/static/",
+		"a\x00bc":                "a\x00b",
+	}
+
+	for query, expected := range cases {
+		key, value := tree.LongestPrefixMatch([]byte(query))
+		if string(key) != expected || value != expected {
+			t.Errorf("Unexpected match %q, %v for %q, expected %q", key, value, query, expected)
+		}
+	}
+
+	for _, query := range []string{"", "api", "a", "a\x00"} {
+		if key, value := tree.LongestPrefixMatch([]byte(query)); key != nil || value != nil {
+			t.Errorf("Unexpected match %q, %v for %q", key, value, query)
+		}
+	}
+
+	tree.Insert([]byte(""), "root")
+	if key, value := tree.LongestPrefixMatch([]byte("api")); key == nil || len(key) != 0 || value != "root" {
+		t.Errorf("Expected the empty key to match, got %q, %v", key, value)
+	}
+}
+
+// Keys may contain null bytes anywhere, including keys that are prefixes of each other.
+func TestKeysWithNullBytes(t *testing.T) {
+	for _, keys := range [][]string{{"a\x00b", "a"}, {"\x00\x00\x00\x01", "\x00\x00\x00\x01\x05"}} {
+		tree := NewArtTree()
+		for _, key := range keys {
+			tree.Insert([]byte(key), key)
+		}
+
+		for _, key := range keys {
+			if value := tree.Search([]byte(key)); value != key {
+				t.Errorf("Expected %q to be found, got %v", key, value)
+			}
+		}
+
+		if err := tree.Validate(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// Searches, iteration and every prefix query should agree with a brute force search over keys
+// built from null bytes, escape bytes and other bytes, in every prefix mode.
+func TestKeysWithNullBytesMatchBruteForce(t *testing.T) {
+	modes := []PrefixMode{PREFIX_HYBRID, PREFIX_OPTIMISTIC, PREFIX_PESSIMISTIC}
+	alphabet := []byte{0, 1, 2, 'a'}
+	random := rand.New(rand.NewSource(1))
+
+	// A key that ends in its only null byte is the same key as without it.
+	stored := map[string]bool{}
+	for i := 0; i < 500; i++ {
+		key := make([]byte, random.Intn(7))
+		for j := range key {
+			key[j] = alphabet[random.Intn(len(alphabet))]
+		}
+
+		stored[string(trimNullTerminator(key))] = true
+	}
+
+	sorted := []string{}
+	for key := range stored {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	pattern, err := CompileRegexp("a.*\\x00")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, mode := range modes {
+		tree := NewArtTreeWithOptions(Options{PrefixMode: mode})
+		for _, key := range sorted {
+			tree.Insert([]byte(key), key)
+		}
+
+		if err := tree.Validate(); err != nil {
+			t.Fatal(err)
+		}
+
+		visited := []string{}
+		tree.Each(func(n *ArtNode) {
+			if n.IsLeaf() {
+				visited = append(visited, n.Value().(string))
+
+				// Keys read back from the tree are found again, as the same key.
+				if tree.Search(n.Key()) != n.Value() {
+					t.Errorf("Expected %q to be found by its key %q in mode %d", n.Value(), n.Key(), mode)
+				}
+			}
+		})
+
+		if fmt.Sprint(visited) != fmt.Sprint(sorted) {
+			t.Errorf("Expected keys in order in mode %d, got %q", mode, visited)
+		}
+
+		for _, query := range []string{"", "\x00", "\x01", "a\x00", "\x00\x01a", "a\x02\x00\x01"} {
+			var longest interface{}
+			prefixed := []string{}
+			for _, key := range sorted {
+				if strings.HasPrefix(query, key) {
+					longest = key
+				}
+
+				if strings.HasPrefix(key, query) {
+					prefixed = append(prefixed, key)
+				}
+			}
+
+			if key, value := tree.LongestPrefixMatch([]byte(query)); value != longest || (value != nil && string(key) != value) {
+				t.Errorf("Unexpected match %q, %v for %q in mode %d, expected %q", key, value, query, mode, longest)
+			}
+
+			scanned := []string{}
+			tree.ScanPrefix([]byte(query), func(n *ArtNode) { scanned = append(scanned, n.Value().(string)) })
+			if fmt.Sprint(scanned) != fmt.Sprint(prefixed) {
+				t.Errorf("Expected %q below %q in mode %d, got %q", prefixed, query, mode, scanned)
+			}
+
+			expected := map[string]int{}
+			for _, key := range sorted {
+				if distance := levenshtein([]byte(query), []byte(key)); distance <= 1 {
+					expected[key] = distance
+				}
+			}
+
+			found := map[string]int{}
+			tree.FuzzySearch([]byte(query), 1, func(n *ArtNode, distance int) { found[n.Value().(string)] = distance })
+			if fmt.Sprint(found) != fmt.Sprint(expected) {
+				t.Errorf("Expected %v within one edit of %q in mode %d, got %v", expected, query, mode, found)
+			}
+		}
+
+		matched, expected := []string{}, []string{}
+		for _, key := range sorted {
+			if strings.HasPrefix(key, "a") && strings.HasSuffix(key, "\x00") {
+				expected = append(expected, key)
+			}
+		}
+
+		tree.Match(pattern, func(n *ArtNode) { matched = append(matched, n.Value().(string)) })
+		if fmt.Sprint(matched) != fmt.Sprint(expected) {
+			t.Errorf("Expected %q to match in mode %d, got %q", expected, mode, matched)
+		}
+	}
+}
+
+// LongestPrefixMatch should agree with a brute force search in every prefix mode.
+func TestLongestPrefixMatchMatchesBruteForce(t *testing.T) {
+	modes := []PrefixMode{PREFIX_HYBRID, PREFIX_OPTIMISTIC, PREFIX_PESSIMISTIC}
+	words := loadAsset(t, "test/assets/words.txt")
+
+	// Store every other word without its trailing newline, so that words are prefixes of each other.
+	stored := map[string]bool{}
+	for i := 0; i < len(words); i += 2 {
+		stored[string(bytes.TrimSuffix(words[i], []byte("\n")))] = true
+	}
+
+	for _, mode := range modes {
+		tree := NewArtTreeWithOptions(Options{PrefixMode: mode})
+		for key := range stored {
+			tree.Insert([]byte(key), key)
+		}
+
+		for i := 1; i < len(words); i += 97 {
+			query := words[i]
+
+			expected := ""
+			found := false
+			for end := len(query); end >= 0 && !found; end-- {
+				if stored[string(query[:end])] {
+					expected, found = string(query[:end]), true
+				}
+			}
+
+			key, value := tree.LongestPrefixMatch(query)
+			if (key != nil) != found || string(key) != expected || (found && value != expected) {
+				t.Errorf("Unexpected match %q for %q in mode %d, expected %q", key, query, mode, expected)
+			}
+		}
+	}
+}
+
+// Returns the Levenshtein distance between the two passed in keys.
+func levenshtein(a []byte, b []byte) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		previous := row[0]
+		row[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			current := row[j]
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			row[j] = min(min(row[j]+1, row[j-1]+1), previous+cost)
+			previous = current
+		}
+	}
+
+	return row[len(b)]
+}
+
+// FuzzySearch should visit exactly the keys within the number of edits, in key order, in every prefix mode.
+func TestFuzzySearchMatchesBruteForce(t *testing.T) {
+	modes := []PrefixMode{PREFIX_HYBRID, PREFIX_OPTIMISTIC, PREFIX_PESSIMISTIC}
+	words := loadAsset(t, "test/assets/words.txt")
+	for i := range words {
+		words[i] = bytes.TrimSuffix(words[i], []byte("\n"))
+	}
+
+	queries := []string{"speling", "recieve", "algorithm", "a", "", "zzzzzzzzzzzz"}
+
+	// Compute the distance to every word within the largest number of edits once per query.
+	distances := map[string]map[string]int{}
+	for _, query := range queries {
+		distances[query] = map[string]int{}
+		for _, word := range words {
+			if distance := levenshtein([]byte(query), word); distance <= 2 {
+				distances[query][string(word)] = distance
+			}
+		}
+	}
+
+	for _, mode := range modes {
+		tree := NewArtTreeWithOptions(Options{PrefixMode: mode})
+		for _, word := range words {
+			tree.Insert(word, word)
+		}
+
+		for _, query := range queries {
+			for maxEdits := 0; maxEdits <= 2; maxEdits++ {
+				expected := map[string]int{}
+				for word, distance := range distances[query] {
+					if distance <= maxEdits {
+						expected[word] = distance
+					}
+				}
+
+				count := 0
+				var previous []byte
+				tree.FuzzySearch([]byte(query), maxEdits, func(n *ArtNode, distance int) {
+					word := n.Value().([]byte)
+					if d, ok := expected[string(word)]; !ok || d != distance {
+						t.Errorf("Unexpected match %q at distance %d for %q within %d edits in mode %d", word, distance, query, maxEdits, mode)
+					}
+
+					if previous != nil && bytes.Compare(previous, word) >= 0 {
+						t.Errorf("Expected matches in key order, got %q after %q", word, previous)
+					}
+
+					previous = word
+					count++
+				})
+
+				if count != len(expected) {
+					t.Errorf("Expected %d matches for %q within %d edits in mode %d, got %d", len(expected), query, maxEdits, mode, count)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkFuzzySearchWords(b *testing.B) {
+	tree := NewArtTree()
+	for _, word := range loadAsset(b, "test/assets/words.txt") {
+		tree.Insert(bytes.TrimSuffix(word, []byte("\n")), word)
+	}
+
+	queries := [][]byte{[]byte("speling"), []byte("recieve"), []byte("definately"), []byte("occurence")}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.FuzzySearch(queries[i%len(queries)], 2, func(n *ArtNode, distance int) {})
+	}
+}
+
+// Returns the keys of the passed in leaves as strings.
+func leafKeys(leaves []*ArtNode) []string {
+	keys := []string{}
+	for _, leaf := range leaves {
+		keys = append(keys, string(leaf.Value().([]byte)))
+	}
+
+	return keys
+}
+
+// TopK should agree with sorting every leaf below the prefix by score,
+// across score updates and removals, in every prefix mode.
+func TestTopKMatchesBruteForce(t *testing.T) {
+	modes := []PrefixMode{PREFIX_HYBRID, PREFIX_OPTIMISTIC, PREFIX_PESSIMISTIC}
+	words := loadAsset(t, "test/assets/words.txt")[:50000]
+	prefixes := []string{"", "a", "ab", "aba", "b", "zzz"}
+
+	for _, mode := range modes {
+		r := rand.New(rand.NewSource(1))
+		tree := NewArtTreeWithOptions(Options{PrefixMode: mode})
+		scores := map[string]float64{}
+
+		for _, word := range words {
+			// Leave some words without a score, and give many of them equal scores.
+			if r.Intn(10) == 0 {
+				tree.Insert(word, word)
+			} else {
+				score := float64(r.Intn(1000))
+				scores[string(word)] = score
+				tree.InsertWithScore(word, word, score)
+			}
+		}
+
+		check := func(stage string) {
+			for _, prefix := range prefixes {
+				expected := []string{}
+				tree.ScanPrefix([]byte(prefix), func(n *ArtNode) {
+					expected = append(expected, string(n.Value().([]byte)))
+				})
+
+				sort.SliceStable(expected, func(i, j int) bool {
+					si, iScored := scores[expected[i]]
+					sj, jScored := scores[expected[j]]
+					if iScored != jScored {
+						return iScored
+					}
+					return si > sj
+				})
+
+				for _, k := range []int{1, 10, 100} {
+					want := expected
+					if len(want) > k {
+						want = want[:k]
+					}
+
+					got := leafKeys(tree.TopK([]byte(prefix), k))
+					if len(got) != len(want) {
+						t.Fatalf("Expected %d results for %q after %s in mode %d, got %d", len(want), prefix, stage, mode, len(got))
+					}
+
+					for i := range want {
+						if got[i] != want[i] {
+							t.Fatalf("Unexpected result %q at %d for %q after %s in mode %d, expected %q", got[i], i, prefix, stage, mode, want[i])
+						}
+					}
+				}
+			}
+		}
+
+		check("inserting")
+
+		for i := 0; i < 5000; i++ {
+			word := words[r.Intn(len(words))]
+			score := float64(r.Intn(2000) - 500)
+			scores[string(word)] = score
+			tree.InsertWithScore(word, word, score)
+		}
+
+		check("updating scores")
+
+		for i := 0; i < 20000; i++ {
+			word := words[r.Intn(len(words))]
+			delete(scores, string(word))
+			tree.Remove(word)
+		}
+
+		check("removing")
+	}
+}
+
+func BenchmarkTopKWords(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	tree := NewArtTree()
+	for _, word := range loadAsset(b, "test/assets/words.txt") {
+		tree.InsertWithScore(word, word, r.Float64())
+	}
+
+	prefixes := [][]byte{[]byte("a"), []byte("con"), []byte("pre"), []byte("un")}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.TopK(prefixes[i%len(prefixes)], 10)
+	}
+}
+
+// Returns the number of leaves below the passed in node,
+// and reports an error for every inner node whose count does not match it.
+func checkSubtreeCounts(t *testing.T, n *ArtNode) uint32 {
+	if n == nil {
+		return 0
+	}
+
+	if n.IsLeaf() {
+		return 1
+	}
+
+	var count uint32
+	n.eachChild(func(key byte, child *ArtNode) {
+		count += checkSubtreeCounts(t, child)
+	})
+
+	if n.inner().count != count {
+		t.Errorf("Inner node counts %d leaves, but has %d", n.inner().count, count)
+	}
+
+	return count
+}
+
+// Rank and Select should agree with the sorted keys, across insertions and removals, in every prefix mode.
+func TestRankAndSelectMatchSortedKeys(t *testing.T) {
+	modes := []PrefixMode{PREFIX_HYBRID, PREFIX_OPTIMISTIC, PREFIX_PESSIMISTIC}
+	words := loadAsset(t, "test/assets/words.txt")
+
+	for _, mode := range modes {
+		r := rand.New(rand.NewSource(1))
+		tree := NewArtTreeWithOptions(Options{PrefixMode: mode})
+		for _, word := range words {
+			tree.Insert(word, word)
+		}
+
+		for i := 0; i < len(words); i += 2 {
+			tree.Remove(words[i])
+		}
+
+		checkSubtreeCounts(t, tree.root)
+
+		sorted := [][]byte{}
+		tree.Each(func(n *ArtNode) {
+			if n.IsLeaf() {
+				sorted = append(sorted, n.Value().([]byte))
+			}
+		})
+
+		if int64(len(sorted)) != tree.size {
+			t.Fatalf("Expected %d keys, got %d", tree.size, len(sorted))
+		}
+
+		for i := 0; i < 2000; i++ {
+			position := r.Intn(len(sorted))
+
+			if n := tree.Select(int64(position)); n == nil || bytes.Compare(n.Value().([]byte), sorted[position]) != 0 {
+				t.Errorf("Unexpected leaf selected at %d in mode %d", position, mode)
+			}
+
+			if rank := tree.Rank(sorted[position]); rank != int64(position) {
+				t.Errorf("Expected a rank of %d for %q in mode %d, got %d", position, sorted[position], mode, rank)
+			}
+
+			// Removed keys and keys between stored keys rank after every smaller stored key.
+			query := words[r.Intn(len(words))]
+			query = append(query[:len(query)-1:len(query)-1], 'm')
+			expected := int64(sort.Search(len(sorted), func(i int) bool {
+				return bytes.Compare(append(sorted[i][:len(sorted[i]):len(sorted[i])], 0), append(query, 0)) >= 0
+			}))
+
+			if rank := tree.Rank(query); rank != expected {
+				t.Errorf("Expected a rank of %d for %q in mode %d, got %d", expected, query, mode, rank)
+			}
+		}
+
+		if tree.Select(-1) != nil || tree.Select(tree.size) != nil {
+			t.Error("Expected no leaf for positions out of range")
+		}
+	}
+}
+
+func BenchmarkRankWords(b *testing.B) {
+	words := loadAsset(b, "test/assets/words.txt")
+	tree := NewArtTree()
+	for _, word := range words {
+		tree.Insert(word, word)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.Rank(words[i%len(words)])
+	}
+}
@@ -0,0 +1,144 @@
+package art
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"time"
+)
+
+// Inserts the passed in value that is indexed by the passed in key into the ArtTree, expiring it once the passed in
+// duration has passed by the Clock of the tree.  If the key is already present, its value and expiry are replaced,
+// and it keeps any score it had.
+//
+// Keys that have expired are no longer returned by Search or any iteration over the tree, but they are only removed
+// from it by Sweep, which the tree only calls on its own before evicting keys.  Until then they still count towards
+// the size of the tree, while Rank, Select and Aggregate leave them out without removing them, so that they can be
+// called under a read lock.  Since the tree is not safe for concurrent use, a background sweeper should call Sweep
+// from a time.Ticker while holding the same lock as every other change to the tree.
+func (t *ArtTree) InsertWithTTL(key []byte, value interface{}, ttl time.Duration) {
+	ext := &leafExt{expires: t.now().Add(ttl).UnixNano()}
+	if t.options.KeyTransform != nil {
+		ext.originalKey = append([]byte{}, key...)
+	}
+
+	t.insert(t.indexKey(key), value, ext)
+}
+
+// Removes every key that has expired by the Clock of the tree, and returns the number of keys removed.
+// The keys are found from the front of the expiry index of the tree, so only the keys that have expired are visited,
+// and they are removed like any other key, which reports their removal to the subscriptions of the tree.
+func (t *ArtTree) Sweep() int {
+	keys := t.expiredKeys()
+	for _, key := range keys {
+		t.remove(key)
+	}
+
+	return len(keys)
+}
+
+// Returns the indexed keys of the leaves that have expired by the Clock of the tree, but have not been removed yet,
+// in key order.  The keys are found from the front of the expiry index of the tree, so only they are visited.
+func (t *ArtTree) expiredKeys() [][]byte {
+	if !t.expiring() {
+		return nil
+	}
+
+	now := t.now().UnixNano()
+
+	keys := [][]byte{}
+	c := t.expiries.Cursor()
+	for c.Next() {
+		entry := c.Node().leaf().key
+		if int64(binary.BigEndian.Uint64(entry)) > now {
+			break
+		}
+
+		keys = append(keys, entry[8:])
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+	return keys
+}
+
+// Returns the keys among the passed in keys, which must be in key order, that start with the passed in prefix.
+func keysWithPrefix(keys [][]byte, prefix []byte) [][]byte {
+	start := sort.Search(len(keys), func(i int) bool { return bytes.Compare(keys[i], prefix) >= 0 })
+
+	end := start
+	for end < len(keys) && bytes.HasPrefix(keys[end], prefix) {
+		end++
+	}
+
+	return keys[start:end]
+}
+
+// Returns the current time of the tree, as returned by its Clock.
+func (t *ArtTree) now() time.Time {
+	if t.options.Clock != nil {
+		return t.options.Clock()
+	}
+
+	return time.Now()
+}
+
+// Returns whether or not the tree holds any keys that expire.
+func (t *ArtTree) expiring() bool {
+	return t.expiries != nil && t.expiries.size > 0
+}
+
+// Returns whether or not the passed in leaf expires, and has expired by the Clock of the tree.
+// Only leaves that carry optional attributes can expire, so the clock is not consulted for any other leaf.
+func (t *ArtTree) expired(n *ArtNode) bool {
+	expires := n.expiry()
+	return expires != 0 && expires <= t.now().UnixNano()
+}
+
+// Returns the time the current leaf expires at, in nanoseconds since the Unix epoch, or zero if it does not expire.
+func (n *ArtNode) expiry() int64 {
+	if ext := n.ext(); ext != nil {
+		return ext.expires
+	}
+
+	return 0
+}
+
+// Moves the passed in indexed key within the expiry index of the tree, from the first passed in expiry to the second.
+// An expiry of zero stands for a key that does not expire, which is not in the index.
+func (t *ArtTree) updateExpiry(key []byte, from int64, to int64) {
+	if from == to {
+		return
+	}
+
+	if from != 0 {
+		t.expiries.removeHelper(t.expiries.root, &t.expiries.root, expiryKey(from, key), 0)
+	}
+
+	if to != 0 {
+		if t.expiries == nil {
+			t.expiries = NewArtTree()
+		}
+
+		t.expiries.insertHelper(t.expiries.root, &t.expiries.root, expiryKey(to, key), nil, nil, 0)
+	}
+}
+
+// Returns the key of the passed in indexed key within the expiry index, which orders keys by the passed in expiry.
+// The expiry is encoded in big endian, so that the byte order of the keys follows the order of the expiries.
+// Since the indexed key is already null terminated, no key of the index is a prefix of another.
+func expiryKey(expires int64, key []byte) []byte {
+	entry := make([]byte, 8, 8+len(key))
+	binary.BigEndian.PutUint64(entry, uint64(expires))
+
+	return append(entry, key...)
+}
+
+// Rebuilds the expiry index of the tree from the leaves that expire, for trees that were built without inserting them.
+func (t *ArtTree) indexExpiries() {
+	t.expiries = nil
+	t.eachHelper(t.root, func(n *ArtNode) {
+		if n.IsLeaf() {
+			t.updateExpiry(n.leaf().key, 0, n.expiry())
+		}
+	})
+}
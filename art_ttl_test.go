@@ -0,0 +1,509 @@
+package art
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+// Defines a clock that only moves when it is advanced, so that expiries can be tested deterministically.
+type fakeClock struct {
+	current time.Time
+}
+
+func (c *fakeClock) now() time.Time { return c.current }
+
+func (c *fakeClock) advance(d time.Duration) { c.current = c.current.Add(d) }
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{current: time.Unix(1700000000, 0)}
+}
+
+// Keys that have expired should not be returned by any read, even before they have been swept.
+func TestExpiredKeysAreHiddenBeforeSweep(t *testing.T) {
+	clock := newFakeClock()
+	tree := NewArtTreeWithOptions(Options{Clock: clock.now})
+
+	tree.Insert([]byte("apple"), "apple")
+	tree.InsertWithTTL([]byte("app"), "app", time.Minute)
+	tree.InsertWithTTL([]byte("apricot"), "apricot", time.Hour)
+	tree.InsertWithTTL([]byte("banana"), "banana", time.Second)
+	tree.InsertWithScore([]byte("blueberry"), "blueberry", 1)
+
+	if tree.Search([]byte("app")) != "app" || tree.Search([]byte("banana")) != "banana" {
+		t.Error("Expected keys to be found before they expire")
+	}
+
+	clock.advance(time.Minute)
+
+	if tree.Search([]byte("app")) != nil || tree.Search([]byte("banana")) != nil {
+		t.Error("Expected expired keys not to be found")
+	}
+
+	if tree.Search([]byte("apricot")) != "apricot" || tree.Search([]byte("apple")) != "apple" {
+		t.Error("Expected keys that have not expired to be found")
+	}
+
+	if key, value := tree.LongestPrefixMatch([]byte("applesauce")); string(key) != "apple" || value != "apple" {
+		t.Errorf("Expected the longest prefix match to be apple, got %q", key)
+	}
+
+	if key, _ := tree.LongestPrefixMatch([]byte("apps")); key != nil {
+		t.Errorf("Expected no prefix match, got %q", key)
+	}
+
+	expected := []string{"apple\x00", "apricot\x00", "blueberry\x00"}
+
+	keys, _ := treeEntries(tree)
+	checkKeys(t, "Each", keys, expected)
+
+	keys = []string{}
+	tree.ScanPrefix([]byte(""), func(n *ArtNode) { keys = append(keys, string(n.Key())) })
+	checkKeys(t, "ScanPrefix", keys, expected)
+
+	keys = []string{}
+	for c := tree.Cursor(); c.Next(); {
+		keys = append(keys, string(c.Node().Key()))
+	}
+	checkKeys(t, "Cursor", keys, expected)
+
+	// Seeking to an expired key moves past it.
+	if c := tree.Cursor(); !c.Seek([]byte("app")) || string(c.Node().Key()) != "apple\x00" {
+		t.Error("Expected Seek to skip the expired key")
+	}
+
+	keys = []string{}
+	for _, n := range tree.TopK([]byte(""), 10) {
+		keys = append(keys, string(n.Key()))
+	}
+	checkKeys(t, "TopK", keys, []string{"blueberry\x00", "apple\x00", "apricot\x00"})
+
+	keys = []string{}
+	tree.FuzzySearch([]byte("bananas"), 1, func(n *ArtNode, distance int) { keys = append(keys, string(n.Key())) })
+	checkKeys(t, "FuzzySearch", keys, []string{})
+
+	// Expired keys are only removed by Sweep.
+	if tree.size != 5 {
+		t.Errorf("Expected a size of 5 before sweeping, got %d", tree.size)
+	}
+
+	if removed := tree.Sweep(); removed != 2 || tree.size != 3 {
+		t.Errorf("Expected Sweep to remove 2 keys leaving 3, removed %d leaving %d", removed, tree.size)
+	}
+
+	if removed := tree.Sweep(); removed != 0 {
+		t.Errorf("Expected a second Sweep to remove nothing, removed %d", removed)
+	}
+
+	clock.advance(time.Hour)
+	if removed := tree.Sweep(); removed != 1 || tree.Search([]byte("apricot")) != nil || tree.expiring() {
+		t.Error("Expected the last expiring key to be swept")
+	}
+
+	checkSubtreeCounts(t, tree.root)
+}
+
+// Checks that the passed in keys are exactly the expected keys, in order.
+func checkKeys(t *testing.T, name string, keys []string, expected []string) {
+	if len(keys) != len(expected) {
+		t.Errorf("%s: expected %q, got %q", name, expected, keys)
+		return
+	}
+
+	for i := range keys {
+		if keys[i] != expected[i] {
+			t.Errorf("%s: expected %q, got %q", name, expected, keys)
+			return
+		}
+	}
+}
+
+// Random insertions with and without expiries, removals and sweeps should agree with a map of expiry times,
+// and the expiry index should only ever hold the keys that expire.
+func TestTTLMatchesBruteForce(t *testing.T) {
+	modes := []PrefixMode{PREFIX_HYBRID, PREFIX_OPTIMISTIC, PREFIX_PESSIMISTIC}
+	words := loadAsset(t, "test/assets/words.txt")[:3000]
+	r := rand.New(rand.NewSource(47))
+
+	for _, mode := range modes {
+		clock := newFakeClock()
+		tree := NewArtTreeWithOptions(Options{Clock: clock.now, PrefixMode: mode, Arena: mode == PREFIX_HYBRID})
+
+		values := map[string]interface{}{}
+		expiries := map[string]time.Time{}
+
+		for i := 0; i < 20000; i++ {
+			word := words[r.Intn(len(words))]
+
+			switch r.Intn(10) {
+			case 0, 1, 2, 3:
+				ttl := time.Duration(r.Intn(100)) * time.Second
+				tree.InsertWithTTL(word, i, ttl)
+				values[string(word)] = i
+				expiries[string(word)] = clock.current.Add(ttl)
+			case 4, 5:
+				// A key that has not expired yet keeps its expiry.
+				tree.Insert(word, i)
+				values[string(word)] = i
+				if expires, ok := expiries[string(word)]; ok && !expires.After(clock.current) {
+					delete(expiries, string(word))
+				}
+			case 6, 7:
+				tree.Remove(word)
+				delete(values, string(word))
+				delete(expiries, string(word))
+			case 8:
+				clock.advance(time.Duration(r.Intn(10)) * time.Second)
+			default:
+				if r.Intn(10) == 0 {
+					expected := 0
+					for key, expires := range expiries {
+						if !expires.After(clock.current) {
+							delete(values, key)
+							delete(expiries, key)
+							expected++
+						}
+					}
+
+					if removed := tree.Sweep(); removed != expected {
+						t.Fatalf("Expected Sweep to remove %d keys, removed %d", expected, removed)
+					}
+
+					if tree.size != int64(len(values)) {
+						t.Fatalf("Expected a size of %d after sweeping, got %d", len(values), tree.size)
+					}
+				}
+			}
+		}
+
+		live := []string{}
+		for key, value := range values {
+			expires, ok := expiries[key]
+			found := tree.Search([]byte(key))
+
+			if ok && !expires.After(clock.current) {
+				if found != nil {
+					t.Errorf("Expected %q to have expired", key)
+				}
+				continue
+			}
+
+			if found != value {
+				t.Errorf("Expected %q = %v, got %v", key, value, found)
+			}
+
+			live = append(live, key+"\x00")
+		}
+
+		sort.Strings(live)
+		keys, _ := treeEntries(tree)
+		checkKeys(t, "Each", keys, live)
+
+		if tree.expiries.size != int64(len(expiries)) {
+			t.Errorf("Expected %d keys in the expiry index, got %d", len(expiries), tree.expiries.size)
+		}
+
+		checkSubtreeCounts(t, tree.root)
+
+		if err := tree.Validate(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// Sweeping should report the removal of expired keys to the subscriptions of the tree,
+// and inserting over an expired key that has not been swept yet should be reported as an insertion.
+func TestSweepReportsDeletions(t *testing.T) {
+	clock := newFakeClock()
+	tree := NewArtTreeWithOptions(Options{Clock: clock.now})
+
+	events := []WatchEvent{}
+	tree.Watch([]byte("a"), func(event WatchEvent) { events = append(events, event) })
+
+	tree.InsertWithTTL([]byte("apple"), 1, time.Second)
+	tree.InsertWithTTL([]byte("avocado"), 2, time.Second)
+	clock.advance(time.Second)
+
+	tree.Insert([]byte("apple"), 3)
+	tree.Sweep()
+
+	expected := []WatchEvent{
+		{Type: WATCH_INSERT, Key: []byte("apple"), NewValue: 1},
+		{Type: WATCH_INSERT, Key: []byte("avocado"), NewValue: 2},
+		{Type: WATCH_INSERT, Key: []byte("apple"), NewValue: 3},
+		{Type: WATCH_DELETE, Key: []byte("avocado"), OldValue: 2},
+	}
+
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %v", len(expected), events)
+	}
+
+	for i := range events {
+		if events[i].Type != expected[i].Type || !bytes.Equal(events[i].Key, expected[i].Key) ||
+			events[i].OldValue != expected[i].OldValue || events[i].NewValue != expected[i].NewValue {
+			t.Errorf("Expected event %d to be %v, got %v", i, expected[i], events[i])
+		}
+	}
+
+	if tree.Search([]byte("apple")) != 3 || tree.expiring() {
+		t.Error("Expected apple to no longer expire once it was replaced")
+	}
+}
+
+// The results of set operations should keep the expiries of their keys, and be able to sweep them.
+func TestSetOperationsKeepExpiries(t *testing.T) {
+	clock := newFakeClock()
+	a := NewArtTreeWithOptions(Options{Clock: clock.now})
+	b := NewArtTreeWithOptions(Options{Clock: clock.now})
+
+	a.InsertWithTTL([]byte("apple"), 1, time.Second)
+	a.Insert([]byte("apricot"), 2)
+	b.InsertWithTTL([]byte("avocado"), 3, time.Minute)
+
+	union := a.Union(b, nil)
+	clock.advance(time.Second)
+
+	if union.Search([]byte("apple")) != nil || union.Search([]byte("avocado")) != 3 {
+		t.Error("Expected the union to keep the expiries of its keys")
+	}
+
+	if removed := union.Sweep(); removed != 1 || union.size != 2 {
+		t.Errorf("Expected the union to sweep 1 key leaving 2, removed %d leaving %d", removed, union.size)
+	}
+
+	if a.size != 2 {
+		t.Error("Expected sweeping the union to leave the first tree unchanged")
+	}
+}
+
+// Set operations should treat keys that have expired as if they had been swept from their trees.
+func TestSetOperationsSkipExpiredKeys(t *testing.T) {
+	clock := newFakeClock()
+	a := NewArtTreeWithOptions(Options{Clock: clock.now})
+	b := NewArtTreeWithOptions(Options{Clock: clock.now})
+
+	a.Insert([]byte("k"), "a")
+	a.InsertWithTTL([]byte("y"), "a", time.Second)
+	a.InsertWithTTL([]byte("zebra"), "a", time.Second)
+	a.InsertWithTTL([]byte("zero"), "a", time.Second)
+	b.InsertWithTTL([]byte("k"), "b", time.Second)
+	b.Insert([]byte("y"), "b")
+	clock.advance(time.Second)
+
+	cases := []struct {
+		name     string
+		result   *ArtTree
+		expected map[string]interface{}
+	}{
+		{"union", a.Union(b, nil), map[string]interface{}{"k": "a", "y": "b"}},
+		{"intersection", a.Intersect(b, nil), map[string]interface{}{}},
+		{"difference", a.Difference(b), map[string]interface{}{"k": "a"}},
+	}
+
+	for _, c := range cases {
+		if c.result.size != int64(len(c.expected)) {
+			t.Errorf("Expected the %s to hold %d keys, got %d", c.name, len(c.expected), c.result.size)
+		}
+
+		for _, key := range []string{"k", "y", "zebra", "zero"} {
+			if value := c.result.Search([]byte(key)); value != c.expected[key] {
+				t.Errorf("Expected %v for %q in the %s, got %v", c.expected[key], key, c.name, value)
+			}
+		}
+
+		if err := c.result.Validate(); err != nil {
+			t.Errorf("%s: %v", c.name, err)
+		}
+	}
+}
+
+// Diff should treat keys that have expired as if they had been swept from their trees,
+// even below subtrees that have the same hash in both trees.
+func TestDiffSkipsExpiredKeys(t *testing.T) {
+	clock := newFakeClock()
+	options := Options{Clock: clock.now, HashValue: func(value interface{}) []byte { return []byte(value.(string)) }}
+	a := NewArtTreeWithOptions(options)
+	b := NewArtTreeWithOptions(options)
+
+	a.Insert([]byte("k"), "v")
+	a.InsertWithTTL([]byte("y"), "v", time.Second)
+	a.Insert([]byte("same"), "v")
+	b.InsertWithTTL([]byte("k"), "v", time.Second)
+	b.Insert([]byte("y"), "v")
+	b.Insert([]byte("same"), "v")
+	clock.advance(time.Second)
+
+	expected := []diffEntry{{key: "k\x00", before: "v"}, {key: "y\x00", after: "v"}}
+	if entries := collectDiff(a, b); fmt.Sprint(entries) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v, got %v", expected, entries)
+	}
+
+	if entries := collectDiff(a, a); len(entries) != 0 {
+		t.Errorf("Expected no differences between a tree and itself, got %v", entries)
+	}
+}
+
+// Root hashes, summaries and reconciliation should leave out keys that have expired,
+// so that they agree with replicas that have already swept them.
+func TestHashesSkipExpiredKeys(t *testing.T) {
+	words := loadAsset(t, "test/assets/words.txt")[:2000]
+	r := rand.New(rand.NewSource(48))
+
+	clock := newFakeClock()
+	options := Options{Clock: clock.now, HashValue: func(value interface{}) []byte { return []byte(fmt.Sprint(value)) }}
+	tree := NewArtTreeWithOptions(options)
+
+	ttls := make([]int, len(words))
+	for i, word := range words {
+		if r.Intn(3) == 0 {
+			ttls[i] = 1 + r.Intn(10)
+			tree.InsertWithTTL(word, i, time.Duration(ttls[i])*time.Second)
+		} else {
+			tree.Insert(word, i)
+		}
+	}
+
+	for step := 1; step <= 10; step++ {
+		clock.advance(time.Second)
+
+		swept := NewArtTreeWithOptions(options)
+		for i, word := range words {
+			if ttls[i] == 0 || ttls[i] > step {
+				swept.Insert(word, i)
+			}
+		}
+
+		if tree.RootHash() != swept.RootHash() {
+			t.Fatalf("Expected the root hash to leave out expired keys after %d seconds", step)
+		}
+
+		for _, prefix := range []string{"", "a", "ab", "abb"} {
+			if local, other := tree.Summary([]byte(prefix)), swept.Summary([]byte(prefix)); fmt.Sprint(local) != fmt.Sprint(other) {
+				t.Errorf("Expected the summary of %q to leave out expired keys, got %v and %v", prefix, local, other)
+			}
+		}
+
+		if err := tree.Reconcile(&memoryTransport{tree: swept}, func(prefix []byte) {
+			t.Errorf("Unexpected differing range %q after %d seconds", prefix, step)
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// Replacing the value of a key should keep the score and expiry it has, unless new ones are passed in
+// or the key has expired.
+func TestInsertKeepsScoresAndExpiries(t *testing.T) {
+	for _, arena := range []bool{false, true} {
+		clock := newFakeClock()
+		tree := NewArtTreeWithOptions(Options{Clock: clock.now, Arena: arena, KeyTransform: bytes.ToLower})
+
+		tree.InsertWithScore([]byte("Apple"), 1, 5)
+		tree.InsertWithTTL([]byte("apple"), 2, time.Minute)
+		tree.Insert([]byte("APPLE"), 3)
+
+		if top := tree.TopK(nil, 1); len(top) != 1 || top[0].Score() != 5 || top[0].Value() != 3 {
+			t.Errorf("Expected the score of apple to be kept, got %v", top)
+		}
+
+		if key := tree.TopK(nil, 1)[0].OriginalKey(); string(key) != "APPLE" {
+			t.Errorf("Expected the key apple was last inserted with, got %q", key)
+		}
+
+		clock.advance(time.Minute)
+		if tree.Search([]byte("apple")) != nil {
+			t.Error("Expected apple to keep its expiry")
+		}
+
+		// An expired key keeps none of its attributes.
+		tree.Insert([]byte("apple"), 4)
+		if tree.Search([]byte("apple")) != 4 || tree.root.Score() != 0 || tree.Sweep() != 0 {
+			t.Error("Expected apple to be inserted again without a score or expiry")
+		}
+
+		if err := tree.Validate(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// Rank, Select and Aggregate should only count the keys that Search finds, so that Select(Rank(key))
+// returns every key that has not expired, whatever the keys around it that have, without removing any of them.
+func TestRankSelectAndAggregateSkipExpiredKeys(t *testing.T) {
+	words := loadAsset(t, "test/assets/words.txt")[:2000]
+	r := rand.New(rand.NewSource(47))
+
+	clock := newFakeClock()
+	tree := NewArtTreeWithOptions(Options{Clock: clock.now, Aggregator: SumAggregator{}})
+
+	events := 0
+	tree.Watch(nil, func(event WatchEvent) {
+		if event.Type == WATCH_DELETE {
+			events++
+		}
+	})
+
+	for i, word := range words {
+		if r.Intn(3) == 0 {
+			tree.InsertWithTTL(word, float64(1), time.Duration(1+r.Intn(10))*time.Second)
+		} else {
+			tree.Insert(word, float64(i%2))
+		}
+	}
+
+	for step := 0; step < 10; step++ {
+		clock.advance(time.Second)
+
+		live := []string{}
+		sum := 0.0
+		tree.Each(func(n *ArtNode) {
+			if n.IsLeaf() {
+				live = append(live, string(n.Key()))
+				sum += n.Value().(float64)
+			}
+		})
+
+		for _, word := range words {
+			if tree.Search(word) == nil {
+				continue
+			}
+
+			rank := tree.Rank(word)
+			if n := tree.Select(rank); n == nil || !bytes.Equal(n.Key(), append(append([]byte{}, word...), 0)) {
+				t.Fatalf("Expected Select(Rank(%q)) to return it, got %v", word, n)
+			}
+		}
+
+		if rank := tree.Rank([]byte{0xff}); rank != int64(len(live)) || tree.Select(rank) != nil {
+			t.Errorf("Expected %d keys to be ranked, got %d", len(live), rank)
+		}
+
+		if aggregate := tree.Aggregate(nil, nil); aggregate != sum {
+			t.Errorf("Expected an aggregate of %v, got %v", sum, aggregate)
+		}
+
+		// Ranges that start and end at keys that have expired leave them out as well.
+		lo, hi := words[r.Intn(len(words))], words[r.Intn(len(words))]
+		ranged := 0.0
+		tree.Each(func(n *ArtNode) {
+			if key := n.Key(); n.IsLeaf() && bytes.Compare(key, lo) >= 0 && bytes.Compare(key[:len(key)-1], hi) < 0 {
+				ranged += n.Value().(float64)
+			}
+		})
+
+		if aggregate := tree.Aggregate(lo, hi); aggregate != ranged {
+			t.Errorf("Expected an aggregate of %v between %q and %q, got %v", ranged, lo, hi, aggregate)
+		}
+
+		if events != 0 || tree.size != int64(len(words)) {
+			t.Errorf("Expected the expired keys to be kept, got %d deletions leaving %d keys", events, tree.size)
+		}
+
+		if err := tree.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
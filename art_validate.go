@@ -0,0 +1,243 @@
+package art
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	// Returned by Validate, wrapped in a description of the first broken invariant it found.
+	ErrInvalidTree = errors.New("art: invalid tree")
+)
+
+// Walks the entire tree and checks the invariants of its structure, returning an error that wraps ErrInvalidTree
+// and describes the first broken invariant, or nil if there is none.  This visits every node of the tree,
+// so it is intended for tests and debugging, to be called after mutations to catch corruption early.
+//
+// Every inner node must have between MinSize and MaxSize children, stored under distinct keys:
+// sorted for NODE4 and NODE16, and pointing at children for NODE48.  The compressed path of every inner node
+// must be stored as far as the prefix capacity of the tree allows, and shared by every leaf below it.
+// Leaves must be in key order, and the number of leaves, highest scores and other attributes kept on inner nodes,
+// as well as the size of the tree and its expiry and usage indexes, must match the leaves below them.
+func (t *ArtTree) Validate() error {
+	v := &validator{tree: t}
+
+	if t.root != nil {
+		if _, err := v.validate(t.root, []byte{}); err != nil {
+			return err
+		}
+	}
+
+	if v.leaves != t.size {
+		return fmt.Errorf("%w: size is %d, but there are %d leaves", ErrInvalidTree, t.size, v.leaves)
+	}
+
+	if t.expiries != nil && t.expiries.size != v.expiring {
+		return fmt.Errorf("%w: expiry index holds %d keys, but %d leaves expire", ErrInvalidTree, t.expiries.size, v.expiring)
+	}
+
+	if t.bounded() {
+		if t.usage != nil && t.usage.size != v.leaves || t.usage == nil && v.leaves > 0 {
+			return fmt.Errorf("%w: usage index does not hold all %d leaves", ErrInvalidTree, v.leaves)
+		}
+
+		if t.bytes != v.bytes {
+			return fmt.Errorf("%w: estimated bytes are %d, but the leaves hold %d", ErrInvalidTree, t.bytes, v.bytes)
+		}
+	}
+
+	return nil
+}
+
+// Defines the state of a walk of Validate.
+type validator struct {
+	tree *ArtTree
+
+	// The key of the last leaf visited, and the number of leaves, leaves that expire and estimated bytes visited so far.
+	last     []byte
+	leaves   int64
+	expiring int64
+	bytes    int64
+}
+
+// Checks the passed in node, whose keys must all start with the passed in path, and every node below it.
+// Returns the number of leaves below the node.
+func (v *validator) validate(n *ArtNode, path []byte) (uint32, error) {
+	if n.IsLeaf() {
+		return 1, v.validateLeaf(n, path)
+	}
+
+	inner := n.inner()
+	if size := int(inner.size); size < n.MinSize() || size > n.MaxSize() {
+		return 0, fmt.Errorf("%w: inner node at %q of type %d has %d children, outside of %d to %d",
+			ErrInvalidTree, path, n.nodeType, size, n.MinSize(), n.MaxSize())
+	}
+
+	if err := v.validateChildren(n, path); err != nil {
+		return 0, err
+	}
+
+	// The stored bytes of the compressed path are compared against the minimum leaf below the node,
+	// and the entire path is then checked against every leaf below it.
+	stored := min(int(inner.prefixLen), v.tree.prefixCapacity())
+	if len(inner.prefix) != stored {
+		return 0, fmt.Errorf("%w: inner node at %q stores %d bytes of its compressed path of %d bytes, instead of %d",
+			ErrInvalidTree, path, len(inner.prefix), inner.prefixLen, stored)
+	}
+
+	depth := len(path)
+	minKey := n.Minimum().leaf().key
+	if depth+int(inner.prefixLen) >= len(minKey) {
+		return 0, fmt.Errorf("%w: compressed path of inner node at %q runs past the key %q", ErrInvalidTree, path, minKey)
+	}
+
+	if !bytes.Equal(inner.prefix, minKey[depth:depth+stored]) {
+		return 0, fmt.Errorf("%w: compressed path of inner node at %q is %q, but its leaves continue with %q",
+			ErrInvalidTree, path, inner.prefix, minKey[depth:depth+stored])
+	}
+
+	path = append(path, minKey[depth:depth+int(inner.prefixLen)]...)
+
+	var count uint32
+	var maxScore uint64
+	var err error
+	n.eachChild(func(key byte, child *ArtNode) {
+		if err != nil {
+			return
+		}
+
+		var below uint32
+		below, err = v.validate(child, append(path[:len(path):len(path)], key))
+		count += below
+		maxScore = max(maxScore, child.subtreeScore())
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if inner.count != count {
+		return 0, fmt.Errorf("%w: inner node at %q counts %d leaves below it, but has %d", ErrInvalidTree, path, inner.count, count)
+	}
+
+	if inner.maxScore != maxScore {
+		return 0, fmt.Errorf("%w: inner node at %q keeps a highest score of %d, but its leaves have %d", ErrInvalidTree, path, inner.maxScore, maxScore)
+	}
+
+	return count, v.validateAugment(n, path)
+}
+
+// Checks that the children of the passed in inner node are stored under distinct keys,
+// in order for nodes that keep their keys sorted, and that the node counts them correctly.
+func (v *validator) validateChildren(n *ArtNode, path []byte) error {
+	inner := n.inner()
+	children := 0
+
+	switch n.nodeType {
+	case NODE4, NODE16:
+		keys := n.keys()
+		for i := 0; i < int(inner.size); i++ {
+			if n.children()[i] == nil {
+				return fmt.Errorf("%w: inner node at %q has no child under key %d", ErrInvalidTree, path, keys[i])
+			}
+
+			if i > 0 && keys[i-1] >= keys[i] {
+				return fmt.Errorf("%w: inner node at %q has keys out of order: %v", ErrInvalidTree, path, keys[:inner.size])
+			}
+
+			children++
+		}
+
+		for i := int(inner.size); i < len(n.children()); i++ {
+			if n.children()[i] != nil {
+				return fmt.Errorf("%w: inner node at %q has a child past its size", ErrInvalidTree, path)
+			}
+		}
+
+	case NODE48:
+		n48 := n.node48()
+		used := [NODE48MAX]bool{}
+		for key, index := range n48.keys {
+			if index == 0 {
+				continue
+			}
+
+			if int(index) > NODE48MAX || n48.children[index-1] == nil || used[index-1] {
+				return fmt.Errorf("%w: inner node at %q has an invalid index %d under key %d", ErrInvalidTree, path, index, key)
+			}
+
+			used[index-1] = true
+			children++
+		}
+
+	default:
+		for _, child := range n.node256().children {
+			if child != nil {
+				children++
+			}
+		}
+	}
+
+	if children != int(inner.size) {
+		return fmt.Errorf("%w: inner node at %q has a size of %d, but %d children", ErrInvalidTree, path, inner.size, children)
+	}
+
+	return nil
+}
+
+// Checks that the passed in leaf, whose key must start with the passed in path, follows the last leaf visited,
+// and counts it towards the indexes of the tree.
+func (v *validator) validateLeaf(n *ArtNode, path []byte) error {
+	key := n.leaf().key
+
+	if !bytes.HasPrefix(key, path) {
+		return fmt.Errorf("%w: leaf %q is stored under %q", ErrInvalidTree, key, path)
+	}
+
+	if v.last != nil && bytes.Compare(v.last, key) >= 0 {
+		return fmt.Errorf("%w: leaf %q follows leaf %q", ErrInvalidTree, key, v.last)
+	}
+
+	v.last = key
+	v.leaves++
+
+	if n.expiry() != 0 {
+		v.expiring++
+	}
+
+	if v.tree.bounded() {
+		if n.usage() == nil {
+			return fmt.Errorf("%w: leaf %q has no usage", ErrInvalidTree, key)
+		}
+
+		v.bytes += v.tree.entryBytes(key, n.leaf().value, n.ext())
+	}
+
+	return nil
+}
+
+// Checks that the passed in inner node carries the attributes computed from the leaves below it
+// if the tree keeps them, and that they match their children.
+func (v *validator) validateAugment(n *ArtNode, path []byte) error {
+	augment := n.augment()
+	if !v.tree.augmented() {
+		return nil
+	}
+
+	if augment == nil {
+		return fmt.Errorf("%w: inner node at %q does not carry an aggregate or hash", ErrInvalidTree, path)
+	}
+
+	if agg := v.tree.options.Aggregator; agg != nil && !reflect.DeepEqual(augment.aggregate, n.childAggregate(agg)) {
+		return fmt.Errorf("%w: inner node at %q keeps an aggregate of %v, but its children have %v",
+			ErrInvalidTree, path, augment.aggregate, n.childAggregate(agg))
+	}
+
+	if hashValue := v.tree.options.HashValue; hashValue != nil && augment.hash != n.childHash(hashValue) {
+		return fmt.Errorf("%w: inner node at %q keeps a stale hash", ErrInvalidTree, path)
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package art
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// Fails the passed in test if the passed in tree breaks any of its invariants.
+func mustValidate(t testing.TB, tree *ArtTree) {
+	t.Helper()
+
+	if err := tree.Validate(); err != nil {
+		t.Fatalf("Tree is invalid: %v", err)
+	}
+}
+
+// Trees should stay valid after every insertion and removal, however they are configured.
+func TestValidateAfterEveryMutation(t *testing.T) {
+	words := loadAsset(t, "test/assets/words.txt")[:600]
+	hashValue := func(value interface{}) []byte { return []byte{byte(value.(int))} }
+
+	configurations := map[string]Options{
+		"default":     {},
+		"arena":       {Arena: true},
+		"optimistic":  {PrefixMode: PREFIX_OPTIMISTIC},
+		"pessimistic": {PrefixMode: PREFIX_PESSIMISTIC},
+		"short":       {MaxPrefixLen: 2},
+		"augmented":   {Aggregator: SumAggregator{}, HashValue: hashValue},
+		"bounded":     {MaxEntries: 200, Eviction: EVICT_LFU},
+	}
+
+	for name, options := range configurations {
+		clock := newFakeClock()
+		options.Clock = clock.now
+		tree := NewArtTreeWithOptions(options)
+		r := rand.New(rand.NewSource(50))
+
+		for i := 0; i < 3000; i++ {
+			key := append([]byte("shared/"), words[r.Intn(len(words))]...)
+
+			switch r.Intn(8) {
+			case 0, 1, 2:
+				tree.Remove(key)
+			case 3:
+				tree.InsertWithScore(key, i, float64(r.Intn(100)))
+			case 4:
+				tree.InsertWithTTL(key, i, time.Duration(r.Intn(10))*time.Second)
+			case 5:
+				clock.advance(time.Second)
+				tree.Sweep()
+			default:
+				tree.Insert(key, i)
+			}
+
+			if err := tree.Validate(); err != nil {
+				t.Fatalf("%s: invalid after %d mutations: %v", name, i+1, err)
+			}
+		}
+	}
+}
+
+// Validate should report broken invariants.
+func TestValidateDetectsCorruption(t *testing.T) {
+	corruptions := map[string]func(tree *ArtTree){
+		"size": func(tree *ArtTree) {
+			tree.size++
+		},
+		"count": func(tree *ArtTree) {
+			tree.root.inner().count--
+		},
+		"unsorted keys": func(tree *ArtTree) {
+			n := firstInnerNode(tree.root, NODE4)
+			keys, children := n.keys(), n.children()
+			keys[0], keys[1] = keys[1], keys[0]
+			children[0], children[1] = children[1], children[0]
+		},
+		"node48 index": func(tree *ArtTree) {
+			n48 := firstInnerNode(tree.root, NODE48).node48()
+			for key, index := range n48.keys {
+				if index == 0 {
+					n48.keys[key] = NODE48MAX + 1
+					return
+				}
+			}
+		},
+		"node size": func(tree *ArtTree) {
+			n := firstInnerNode(tree.root, NODE16)
+			n.inner().size = NODE16MIN - 1
+		},
+		"prefix": func(tree *ArtTree) {
+			n := firstPrefixedNode(tree.root)
+			n.inner().prefix[0]++
+		},
+		"leaf order": func(tree *ArtTree) {
+			n := firstInnerNode(tree.root, NODE4)
+			n.children()[0], n.children()[1] = n.children()[1], n.children()[0]
+		},
+	}
+
+	for name, corrupt := range corruptions {
+		tree := NewArtTree()
+		for _, word := range loadAsset(t, "test/assets/words.txt")[:5000] {
+			tree.Insert(word, word)
+		}
+
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("Expected a valid tree, got %v", err)
+		}
+
+		corrupt(tree)
+		if err := tree.Validate(); !errors.Is(err, ErrInvalidTree) {
+			t.Errorf("%s: expected an invalid tree, got %v", name, err)
+		}
+	}
+}
+
+// Returns the first inner node of the passed in type below the passed in node, in key order, or nil if there is none.
+func firstInnerNode(n *ArtNode, nodeType uint8) *ArtNode {
+	if n.IsLeaf() {
+		return nil
+	}
+
+	if n.nodeType == nodeType {
+		return n
+	}
+
+	var found *ArtNode
+	n.eachChild(func(key byte, child *ArtNode) {
+		if found == nil {
+			found = firstInnerNode(child, nodeType)
+		}
+	})
+
+	return found
+}
+
+// Returns the first inner node below the passed in node, in key order, that stores part of its compressed path.
+func firstPrefixedNode(n *ArtNode) *ArtNode {
+	if n.IsLeaf() {
+		return nil
+	}
+
+	if len(n.inner().prefix) > 0 {
+		return n
+	}
+
+	var found *ArtNode
+	n.eachChild(func(key byte, child *ArtNode) {
+		if found == nil {
+			found = firstPrefixedNode(child)
+		}
+	})
+
+	return found
+}
@@ -0,0 +1,104 @@
+package art
+
+// Defines the kinds of changes that are reported to the subscriptions of Watch.
+type WatchEventType uint8
+
+const (
+	// A key that was not in the tree was inserted.
+	WATCH_INSERT WatchEventType = iota
+
+	// The value of a key that was already in the tree was replaced.
+	WATCH_UPDATE
+
+	// A key was removed from the tree.
+	WATCH_DELETE
+)
+
+// Describes a change to a key of a tree, as reported to the subscriptions of Watch.
+type WatchEvent struct {
+	Type WatchEventType
+
+	// The key that changed, as it was passed to Insert, before the KeyTransform of the tree was applied.
+	// Deletions report the key the removed leaf was last inserted with.
+	Key []byte
+
+	// The value of the key before the change, which is nil for insertions,
+	// and after the change, which is nil for deletions.
+	OldValue interface{}
+	NewValue interface{}
+}
+
+// Defines a subscription to the changes to the keys under a prefix, as returned by Watch.
+type Subscription struct {
+	tree     *ArtTree
+	prefix   []byte
+	callback func(event WatchEvent)
+}
+
+// Calls the passed in callback for every change to a key that starts with the passed in prefix,
+// until the returned subscription is cancelled.  The KeyTransform of the tree is applied to the prefix.
+//
+// Callbacks are called synchronously by the insertion or removal that made the change, once it has completed,
+// and must not modify the tree themselves.  Subscriptions are kept in a radix tree of their own, indexed by
+// their prefixes, so reporting a change only visits the subscriptions on the path to its key.
+func (t *ArtTree) Watch(prefix []byte, callback func(event WatchEvent)) *Subscription {
+	if t.options.KeyTransform != nil {
+		prefix = t.options.KeyTransform(prefix)
+	}
+
+	s := &Subscription{tree: t, prefix: append([]byte{}, prefix...), callback: callback}
+
+	if t.watchers == nil {
+		t.watchers = NewArtTree()
+	}
+
+	subscriptions, _ := t.watchers.Search(s.prefix).([]*Subscription)
+	t.watchers.Insert(s.prefix, append(subscriptions[:len(subscriptions):len(subscriptions)], s))
+
+	return s
+}
+
+// Stops the subscription from being called with any further changes.
+// Cancelling a subscription more than once has no effect.
+func (s *Subscription) Cancel() {
+	watchers := s.tree.watchers
+	subscriptions, _ := watchers.Search(s.prefix).([]*Subscription)
+
+	remaining := []*Subscription{}
+	for _, other := range subscriptions {
+		if other != s {
+			remaining = append(remaining, other)
+		}
+	}
+
+	if len(remaining) == 0 {
+		watchers.Remove(s.prefix)
+	} else {
+		watchers.Insert(s.prefix, remaining)
+	}
+}
+
+// Returns whether or not there are any subscriptions to report changes to.
+func (t *ArtTree) watched() bool {
+	return t.watchers != nil && t.watchers.size > 0
+}
+
+// Calls every subscription whose prefix the passed in indexed key starts with, from the shortest prefix to the longest,
+// with the passed in event for the key.  The event reports the passed in original key of the leaf, if it is not nil.
+// The subscriptions are collected before any of them are called, since they may cancel themselves or each other.
+func (t *ArtTree) notify(key []byte, originalKey []byte, event WatchEvent) {
+	if originalKey != nil {
+		event.Key = append([]byte{}, originalKey...)
+	} else {
+		event.Key = append([]byte{}, unescapeKey(key)...)
+	}
+
+	subscriptions := []*Subscription{}
+	t.watchers.eachPrefixOf(key, func(n *ArtNode) {
+		subscriptions = append(subscriptions, n.leaf().value.([]*Subscription)...)
+	})
+
+	for _, s := range subscriptions {
+		s.callback(event)
+	}
+}
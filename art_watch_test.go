@@ -0,0 +1,124 @@
+package art
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// Every subscription should receive exactly the changes to the keys under its prefix, in order,
+// with the values before and after each change.
+func TestWatchReportsChangesUnderPrefix(t *testing.T) {
+	words := loadAsset(t, "test/assets/words.txt")[:5000]
+	r := rand.New(rand.NewSource(46))
+	tree := NewArtTree()
+
+	prefixes := [][]byte{[]byte(""), []byte("a"), []byte("ab"), []byte("ab"), []byte("b"), []byte("zzz")}
+	received := make([][]WatchEvent, len(prefixes))
+	for i, prefix := range prefixes {
+		i := i
+		tree.Watch(prefix, func(event WatchEvent) {
+			received[i] = append(received[i], event)
+		})
+	}
+
+	expected := make([][]WatchEvent, len(prefixes))
+	values := map[string]interface{}{}
+	for i := 0; i < 20000; i++ {
+		word := words[r.Intn(len(words))]
+		old, present := values[string(word)]
+
+		var event WatchEvent
+		if r.Intn(3) == 0 {
+			tree.Remove(word)
+			if !present {
+				continue
+			}
+
+			delete(values, string(word))
+			event = WatchEvent{Type: WATCH_DELETE, Key: word, OldValue: old}
+		} else {
+			tree.Insert(word, i)
+			values[string(word)] = i
+
+			event = WatchEvent{Type: WATCH_INSERT, Key: word, NewValue: i}
+			if present {
+				event.Type, event.OldValue = WATCH_UPDATE, old
+			}
+		}
+
+		for j, prefix := range prefixes {
+			if bytes.HasPrefix(word, prefix) {
+				expected[j] = append(expected[j], event)
+			}
+		}
+	}
+
+	for i := range prefixes {
+		if len(received[i]) != len(expected[i]) {
+			t.Errorf("Expected %d events under %q, got %d", len(expected[i]), prefixes[i], len(received[i]))
+			continue
+		}
+
+		for j := range expected[i] {
+			if !reflect.DeepEqual(received[i][j], expected[i][j]) {
+				t.Errorf("Expected %v under %q, got %v", expected[i][j], prefixes[i], received[i][j])
+				break
+			}
+		}
+	}
+}
+
+func TestWatchCancel(t *testing.T) {
+	tree := NewArtTree()
+	count := 0
+
+	var first, second *Subscription
+	first = tree.Watch([]byte("app"), func(event WatchEvent) {
+		count++
+		first.Cancel()
+	})
+	second = tree.Watch([]byte("app"), func(event WatchEvent) {
+		count++
+	})
+
+	tree.Insert([]byte("apple"), 1)
+	tree.Insert([]byte("apple"), 2)
+
+	if count != 3 {
+		t.Errorf("Expected a subscription cancelled during a change to still see it, and no later ones, got %d events", count)
+	}
+
+	second.Cancel()
+	second.Cancel()
+	tree.Remove([]byte("apple"))
+	tree.Insert([]byte("application"), 3)
+
+	if count != 3 || tree.watchers.size != 0 {
+		t.Errorf("Expected no events after cancelling every subscription, got %d", count)
+	}
+}
+
+// Prefixes are transformed like the keys they are compared with.
+func TestWatchAppliesKeyTransform(t *testing.T) {
+	tree := NewArtTreeWithOptions(Options{KeyTransform: bytes.ToLower})
+
+	events := []WatchEvent{}
+	tree.Watch([]byte("HEL"), func(event WatchEvent) {
+		events = append(events, event)
+	})
+
+	tree.Insert([]byte("Hello"), 1)
+	tree.Remove([]byte("HELLO"))
+	tree.Remove([]byte("HELLO"))
+
+	if len(events) != 2 || events[0].Type != WATCH_INSERT || events[1].Type != WATCH_DELETE {
+		t.Errorf("Unexpected events: %v", events)
+	}
+
+	// Events report the key the leaf was inserted with, however it is removed.
+	if string(events[0].Key) != "Hello" || string(events[1].Key) != "Hello" {
+		t.Errorf("Unexpected events: %v", events)
+	}
+}
@@ -0,0 +1,152 @@
+package keys
+
+import (
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Defines how Collate transforms string keys into the keys a tree is ordered by.
+type CollateOptions struct {
+	// Fold the case of every rune, so that keys that only differ in case are equal.
+	FoldCase bool
+
+	// Remove combining marks after decomposition, so that keys that only differ in accents are equal.
+	StripAccents bool
+
+	// Normalizes keys before they are folded, such as with golang.org/x/text/unicode/norm.NFD.String.
+	// Defaults to nil, which decomposes precomposed Latin, Greek and Cyrillic runes using
+	// a built in table.
+	Normalize func(key string) string
+}
+
+// Defines a range of runes that share a canonical combining class.
+type combiningClassRange struct {
+	lo    rune
+	hi    rune
+	class uint8
+}
+
+// Returns a transform that can be passed as the KeyTransform of an ArtTree.
+// Keys are decomposed, so that composed and decomposed forms of the same text are equal,
+// and then case folded and stripped of their accents as specified by the passed in options.
+// Invalid UTF-8 bytes are passed through unchanged.
+//
+// Without a Normalize function, this is a subset of Unicode canonical decomposition (NFD):
+// only precomposed Latin, Greek and Cyrillic runes and letterlike symbols are decomposed,
+// and sequences of combining marks are put in canonical order by their combining classes,
+// which are only known for the Combining Diacritical Marks blocks.  Keys are never composed (NFC),
+// and compatibility decompositions (NFKD), such as of ligatures, are not applied.
+// Keys in other scripts, such as precomposed Hangul syllables, are therefore only equal to themselves.
+//
+// The transform of a prefix of a key is a prefix of the transform of that key, as ArtTree.ScanPrefix requires,
+// as long as the prefix does not end within a sequence of combining marks that is reordered.  Stripping accents
+// removes every mark that is reordered, so it preserves every prefix.  This does not hold in general for
+// a Normalize function, whose output for a prefix may differ from its output for the whole key,
+// as it does for composing normalization forms such as NFC.
+func Collate(options CollateOptions) func(key []byte) []byte {
+	return func(key []byte) []byte {
+		if options.Normalize != nil {
+			key = []byte(options.Normalize(string(key)))
+		}
+
+		c := &collator{options: options, key: make([]byte, 0, len(key))}
+		for len(key) > 0 {
+			r, size := utf8.DecodeRune(key)
+			if r == utf8.RuneError && size <= 1 {
+				c.flushMarks()
+				c.key = append(c.key, key[0])
+				key = key[1:]
+				continue
+			}
+
+			key = key[size:]
+
+			if decomposition, ok := decompositions[r]; ok && options.Normalize == nil {
+				for _, d := range decomposition {
+					c.appendRune(d)
+				}
+			} else {
+				c.appendRune(r)
+			}
+		}
+
+		c.flushMarks()
+		return c.key
+	}
+}
+
+// Defines the state of a single Collate transform, which holds back a sequence of combining marks
+// until it has ended, so that the marks can be put in canonical order.
+type collator struct {
+	options CollateOptions
+	key     []byte
+	marks   []rune
+}
+
+// Appends the passed in decomposed rune to the collated key.
+func (c *collator) appendRune(r rune) {
+	if combiningClass(r) != 0 {
+		c.marks = append(c.marks, r)
+		return
+	}
+
+	c.flushMarks()
+	c.key = appendCollatedRune(c.key, r, c.options)
+}
+
+// Appends the combining marks that were held back to the collated key, sorted by their combining classes.
+// Marks of the same class keep their relative order, since swapping them changes the meaning of the text.
+func (c *collator) flushMarks() {
+	sort.SliceStable(c.marks, func(i, j int) bool {
+		return combiningClass(c.marks[i]) < combiningClass(c.marks[j])
+	})
+
+	for _, r := range c.marks {
+		c.key = appendCollatedRune(c.key, r, c.options)
+	}
+
+	c.marks = c.marks[:0]
+}
+
+// Returns the canonical combining class of the passed in rune, or zero if it is not a known combining mark.
+func combiningClass(r rune) uint8 {
+	i := sort.Search(len(combiningClasses), func(i int) bool { return combiningClasses[i].hi >= r })
+	if i < len(combiningClasses) && combiningClasses[i].lo <= r {
+		return combiningClasses[i].class
+	}
+
+	return 0
+}
+
+// Appends the passed in rune to the passed in key, case folded and stripped of accents as specified
+// by the passed in options, and returns the extended key.
+func appendCollatedRune(key []byte, r rune, options CollateOptions) []byte {
+	if options.StripAccents && unicode.Is(unicode.Mn, r) {
+		return key
+	}
+
+	if options.FoldCase {
+		// The sharp s is the only common rune whose case folding is longer than itself.
+		if r == 'ß' || r == 'ẞ' {
+			return append(key, 's', 's')
+		}
+
+		r = foldRune(r)
+	}
+
+	return utf8.AppendRune(key, r)
+}
+
+// Returns the canonical member of the case folding orbit of the passed in rune,
+// so that every rune that folds to the same rune returns the same result.
+func foldRune(r rune) rune {
+	folded := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < folded {
+			folded = f
+		}
+	}
+
+	return unicode.ToLower(folded)
+}
@@ -0,0 +1,897 @@
+package keys
+
+// Maps precomposed runes to their canonical decompositions, which are a base rune followed by
+// one or more combining marks in canonical order.  The table is derived from the Unicode 14.0.0
+// character database, and covers the Latin, Greek, Cyrillic and letterlike symbol blocks.
+var decompositions = map[rune]string{
+	0x00C0: "A\u0300",                  // latin capital letter a with grave
+	0x00C1: "A\u0301",                  // latin capital letter a with acute
+	0x00C2: "A\u0302",                  // latin capital letter a with circumflex
+	0x00C3: "A\u0303",                  // latin capital letter a with tilde
+	0x00C4: "A\u0308",                  // latin capital letter a with diaeresis
+	0x00C5: "A\u030a",                  // latin capital letter a with ring above
+	0x00C7: "C\u0327",                  // latin capital letter c with cedilla
+	0x00C8: "E\u0300",                  // latin capital letter e with grave
+	0x00C9: "E\u0301",                  // latin capital letter e with acute
+	0x00CA: "E\u0302",                  // latin capital letter e with circumflex
+	0x00CB: "E\u0308",                  // latin capital letter e with diaeresis
+	0x00CC: "I\u0300",                  // latin capital letter i with grave
+	0x00CD: "I\u0301",                  // latin capital letter i with acute
+	0x00CE: "I\u0302",                  // latin capital letter i with circumflex
+	0x00CF: "I\u0308",                  // latin capital letter i with diaeresis
+	0x00D1: "N\u0303",                  // latin capital letter n with tilde
+	0x00D2: "O\u0300",                  // latin capital letter o with grave
+	0x00D3: "O\u0301",                  // latin capital letter o with acute
+	0x00D4: "O\u0302",                  // latin capital letter o with circumflex
+	0x00D5: "O\u0303",                  // latin capital letter o with tilde
+	0x00D6: "O\u0308",                  // latin capital letter o with diaeresis
+	0x00D9: "U\u0300",                  // latin capital letter u with grave
+	0x00DA: "U\u0301",                  // latin capital letter u with acute
+	0x00DB: "U\u0302",                  // latin capital letter u with circumflex
+	0x00DC: "U\u0308",                  // latin capital letter u with diaeresis
+	0x00DD: "Y\u0301",                  // latin capital letter y with acute
+	0x00E0: "a\u0300",                  // latin small letter a with grave
+	0x00E1: "a\u0301",                  // latin small letter a with acute
+	0x00E2: "a\u0302",                  // latin small letter a with circumflex
+	0x00E3: "a\u0303",                  // latin small letter a with tilde
+	0x00E4: "a\u0308",                  // latin small letter a with diaeresis
+	0x00E5: "a\u030a",                  // latin small letter a with ring above
+	0x00E7: "c\u0327",                  // latin small letter c with cedilla
+	0x00E8: "e\u0300",                  // latin small letter e with grave
+	0x00E9: "e\u0301",                  // latin small letter e with acute
+	0x00EA: "e\u0302",                  // latin small letter e with circumflex
+	0x00EB: "e\u0308",                  // latin small letter e with diaeresis
+	0x00EC: "i\u0300",                  // latin small letter i with grave
+	0x00ED: "i\u0301",                  // latin small letter i with acute
+	0x00EE: "i\u0302",                  // latin small letter i with circumflex
+	0x00EF: "i\u0308",                  // latin small letter i with diaeresis
+	0x00F1: "n\u0303",                  // latin small letter n with tilde
+	0x00F2: "o\u0300",                  // latin small letter o with grave
+	0x00F3: "o\u0301",                  // latin small letter o with acute
+	0x00F4: "o\u0302",                  // latin small letter o with circumflex
+	0x00F5: "o\u0303",                  // latin small letter o with tilde
+	0x00F6: "o\u0308",                  // latin small letter o with diaeresis
+	0x00F9: "u\u0300",                  // latin small letter u with grave
+	0x00FA: "u\u0301",                  // latin small letter u with acute
+	0x00FB: "u\u0302",                  // latin small letter u with circumflex
+	0x00FC: "u\u0308",                  // latin small letter u with diaeresis
+	0x00FD: "y\u0301",                  // latin small letter y with acute
+	0x00FF: "y\u0308",                  // latin small letter y with diaeresis
+	0x0100: "A\u0304",                  // latin capital letter a with macron
+	0x0101: "a\u0304",                  // latin small letter a with macron
+	0x0102: "A\u0306",                  // latin capital letter a with breve
+	0x0103: "a\u0306",                  // latin small letter a with breve
+	0x0104: "A\u0328",                  // latin capital letter a with ogonek
+	0x0105: "a\u0328",                  // latin small letter a with ogonek
+	0x0106: "C\u0301",                  // latin capital letter c with acute
+	0x0107: "c\u0301",                  // latin small letter c with acute
+	0x0108: "C\u0302",                  // latin capital letter c with circumflex
+	0x0109: "c\u0302",                  // latin small letter c with circumflex
+	0x010A: "C\u0307",                  // latin capital letter c with dot above
+	0x010B: "c\u0307",                  // latin small letter c with dot above
+	0x010C: "C\u030c",                  // latin capital letter c with caron
+	0x010D: "c\u030c",                  // latin small letter c with caron
+	0x010E: "D\u030c",                  // latin capital letter d with caron
+	0x010F: "d\u030c",                  // latin small letter d with caron
+	0x0112: "E\u0304",                  // latin capital letter e with macron
+	0x0113: "e\u0304",                  // latin small letter e with macron
+	0x0114: "E\u0306",                  // latin capital letter e with breve
+	0x0115: "e\u0306",                  // latin small letter e with breve
+	0x0116: "E\u0307",                  // latin capital letter e with dot above
+	0x0117: "e\u0307",                  // latin small letter e with dot above
+	0x0118: "E\u0328",                  // latin capital letter e with ogonek
+	0x0119: "e\u0328",                  // latin small letter e with ogonek
+	0x011A: "E\u030c",                  // latin capital letter e with caron
+	0x011B: "e\u030c",                  // latin small letter e with caron
+	0x011C: "G\u0302",                  // latin capital letter g with circumflex
+	0x011D: "g\u0302",                  // latin small letter g with circumflex
+	0x011E: "G\u0306",                  // latin capital letter g with breve
+	0x011F: "g\u0306",                  // latin small letter g with breve
+	0x0120: "G\u0307",                  // latin capital letter g with dot above
+	0x0121: "g\u0307",                  // latin small letter g with dot above
+	0x0122: "G\u0327",                  // latin capital letter g with cedilla
+	0x0123: "g\u0327",                  // latin small letter g with cedilla
+	0x0124: "H\u0302",                  // latin capital letter h with circumflex
+	0x0125: "h\u0302",                  // latin small letter h with circumflex
+	0x0128: "I\u0303",                  // latin capital letter i with tilde
+	0x0129: "i\u0303",                  // latin small letter i with tilde
+	0x012A: "I\u0304",                  // latin capital letter i with macron
+	0x012B: "i\u0304",                  // latin small letter i with macron
+	0x012C: "I\u0306",                  // latin capital letter i with breve
+	0x012D: "i\u0306",                  // latin small letter i with breve
+	0x012E: "I\u0328",                  // latin capital letter i with ogonek
+	0x012F: "i\u0328",                  // latin small letter i with ogonek
+	0x0130: "I\u0307",                  // latin capital letter i with dot above
+	0x0134: "J\u0302",                  // latin capital letter j with circumflex
+	0x0135: "j\u0302",                  // latin small letter j with circumflex
+	0x0136: "K\u0327",                  // latin capital letter k with cedilla
+	0x0137: "k\u0327",                  // latin small letter k with cedilla
+	0x0139: "L\u0301",                  // latin capital letter l with acute
+	0x013A: "l\u0301",                  // latin small letter l with acute
+	0x013B: "L\u0327",                  // latin capital letter l with cedilla
+	0x013C: "l\u0327",                  // latin small letter l with cedilla
+	0x013D: "L\u030c",                  // latin capital letter l with caron
+	0x013E: "l\u030c",                  // latin small letter l with caron
+	0x0143: "N\u0301",                  // latin capital letter n with acute
+	0x0144: "n\u0301",                  // latin small letter n with acute
+	0x0145: "N\u0327",                  // latin capital letter n with cedilla
+	0x0146: "n\u0327",                  // latin small letter n with cedilla
+	0x0147: "N\u030c",                  // latin capital letter n with caron
+	0x0148: "n\u030c",                  // latin small letter n with caron
+	0x014C: "O\u0304",                  // latin capital letter o with macron
+	0x014D: "o\u0304",                  // latin small letter o with macron
+	0x014E: "O\u0306",                  // latin capital letter o with breve
+	0x014F: "o\u0306",                  // latin small letter o with breve
+	0x0150: "O\u030b",                  // latin capital letter o with double acute
+	0x0151: "o\u030b",                  // latin small letter o with double acute
+	0x0154: "R\u0301",                  // latin capital letter r with acute
+	0x0155: "r\u0301",                  // latin small letter r with acute
+	0x0156: "R\u0327",                  // latin capital letter r with cedilla
+	0x0157: "r\u0327",                  // latin small letter r with cedilla
+	0x0158: "R\u030c",                  // latin capital letter r with caron
+	0x0159: "r\u030c",                  // latin small letter r with caron
+	0x015A: "S\u0301",                  // latin capital letter s with acute
+	0x015B: "s\u0301",                  // latin small letter s with acute
+	0x015C: "S\u0302",                  // latin capital letter s with circumflex
+	0x015D: "s\u0302",                  // latin small letter s with circumflex
+	0x015E: "S\u0327",                  // latin capital letter s with cedilla
+	0x015F: "s\u0327",                  // latin small letter s with cedilla
+	0x0160: "S\u030c",                  // latin capital letter s with caron
+	0x0161: "s\u030c",                  // latin small letter s with caron
+	0x0162: "T\u0327",                  // latin capital letter t with cedilla
+	0x0163: "t\u0327",                  // latin small letter t with cedilla
+	0x0164: "T\u030c",                  // latin capital letter t with caron
+	0x0165: "t\u030c",                  // latin small letter t with caron
+	0x0168: "U\u0303",                  // latin capital letter u with tilde
+	0x0169: "u\u0303",                  // latin small letter u with tilde
+	0x016A: "U\u0304",                  // latin capital letter u with macron
+	0x016B: "u\u0304",                  // latin small letter u with macron
+	0x016C: "U\u0306",                  // latin capital letter u with breve
+	0x016D: "u\u0306",                  // latin small letter u with breve
+	0x016E: "U\u030a",                  // latin capital letter u with ring above
+	0x016F: "u\u030a",                  // latin small letter u with ring above
+	0x0170: "U\u030b",                  // latin capital letter u with double acute
+	0x0171: "u\u030b",                  // latin small letter u with double acute
+	0x0172: "U\u0328",                  // latin capital letter u with ogonek
+	0x0173: "u\u0328",                  // latin small letter u with ogonek
+	0x0174: "W\u0302",                  // latin capital letter w with circumflex
+	0x0175: "w\u0302",                  // latin small letter w with circumflex
+	0x0176: "Y\u0302",                  // latin capital letter y with circumflex
+	0x0177: "y\u0302",                  // latin small letter y with circumflex
+	0x0178: "Y\u0308",                  // latin capital letter y with diaeresis
+	0x0179: "Z\u0301",                  // latin capital letter z with acute
+	0x017A: "z\u0301",                  // latin small letter z with acute
+	0x017B: "Z\u0307",                  // latin capital letter z with dot above
+	0x017C: "z\u0307",                  // latin small letter z with dot above
+	0x017D: "Z\u030c",                  // latin capital letter z with caron
+	0x017E: "z\u030c",                  // latin small letter z with caron
+	0x01A0: "O\u031b",                  // latin capital letter o with horn
+	0x01A1: "o\u031b",                  // latin small letter o with horn
+	0x01AF: "U\u031b",                  // latin capital letter u with horn
+	0x01B0: "u\u031b",                  // latin small letter u with horn
+	0x01CD: "A\u030c",                  // latin capital letter a with caron
+	0x01CE: "a\u030c",                  // latin small letter a with caron
+	0x01CF: "I\u030c",                  // latin capital letter i with caron
+	0x01D0: "i\u030c",                  // latin small letter i with caron
+	0x01D1: "O\u030c",                  // latin capital letter o with caron
+	0x01D2: "o\u030c",                  // latin small letter o with caron
+	0x01D3: "U\u030c",                  // latin capital letter u with caron
+	0x01D4: "u\u030c",                  // latin small letter u with caron
+	0x01D5: "U\u0308\u0304",            // latin capital letter u with diaeresis and macron
+	0x01D6: "u\u0308\u0304",            // latin small letter u with diaeresis and macron
+	0x01D7: "U\u0308\u0301",            // latin capital letter u with diaeresis and acute
+	0x01D8: "u\u0308\u0301",            // latin small letter u with diaeresis and acute
+	0x01D9: "U\u0308\u030c",            // latin capital letter u with diaeresis and caron
+	0x01DA: "u\u0308\u030c",            // latin small letter u with diaeresis and caron
+	0x01DB: "U\u0308\u0300",            // latin capital letter u with diaeresis and grave
+	0x01DC: "u\u0308\u0300",            // latin small letter u with diaeresis and grave
+	0x01DE: "A\u0308\u0304",            // latin capital letter a with diaeresis and macron
+	0x01DF: "a\u0308\u0304",            // latin small letter a with diaeresis and macron
+	0x01E0: "A\u0307\u0304",            // latin capital letter a with dot above and macron
+	0x01E1: "a\u0307\u0304",            // latin small letter a with dot above and macron
+	0x01E2: "\u00c6\u0304",             // latin capital letter ae with macron
+	0x01E3: "\u00e6\u0304",             // latin small letter ae with macron
+	0x01E6: "G\u030c",                  // latin capital letter g with caron
+	0x01E7: "g\u030c",                  // latin small letter g with caron
+	0x01E8: "K\u030c",                  // latin capital letter k with caron
+	0x01E9: "k\u030c",                  // latin small letter k with caron
+	0x01EA: "O\u0328",                  // latin capital letter o with ogonek
+	0x01EB: "o\u0328",                  // latin small letter o with ogonek
+	0x01EC: "O\u0328\u0304",            // latin capital letter o with ogonek and macron
+	0x01ED: "o\u0328\u0304",            // latin small letter o with ogonek and macron
+	0x01EE: "\u01b7\u030c",             // latin capital letter ezh with caron
+	0x01EF: "\u0292\u030c",             // latin small letter ezh with caron
+	0x01F0: "j\u030c",                  // latin small letter j with caron
+	0x01F4: "G\u0301",                  // latin capital letter g with acute
+	0x01F5: "g\u0301",                  // latin small letter g with acute
+	0x01F8: "N\u0300",                  // latin capital letter n with grave
+	0x01F9: "n\u0300",                  // latin small letter n with grave
+	0x01FA: "A\u030a\u0301",            // latin capital letter a with ring above and acute
+	0x01FB: "a\u030a\u0301",            // latin small letter a with ring above and acute
+	0x01FC: "\u00c6\u0301",             // latin capital letter ae with acute
+	0x01FD: "\u00e6\u0301",             // latin small letter ae with acute
+	0x01FE: "\u00d8\u0301",             // latin capital letter o with stroke and acute
+	0x01FF: "\u00f8\u0301",             // latin small letter o with stroke and acute
+	0x0200: "A\u030f",                  // latin capital letter a with double grave
+	0x0201: "a\u030f",                  // latin small letter a with double grave
+	0x0202: "A\u0311",                  // latin capital letter a with inverted breve
+	0x0203: "a\u0311",                  // latin small letter a with inverted breve
+	0x0204: "E\u030f",                  // latin capital letter e with double grave
+	0x0205: "e\u030f",                  // latin small letter e with double grave
+	0x0206: "E\u0311",                  // latin capital letter e with inverted breve
+	0x0207: "e\u0311",                  // latin small letter e with inverted breve
+	0x0208: "I\u030f",                  // latin capital letter i with double grave
+	0x0209: "i\u030f",                  // latin small letter i with double grave
+	0x020A: "I\u0311",                  // latin capital letter i with inverted breve
+	0x020B: "i\u0311",                  // latin small letter i with inverted breve
+	0x020C: "O\u030f",                  // latin capital letter o with double grave
+	0x020D: "o\u030f",                  // latin small letter o with double grave
+	0x020E: "O\u0311",                  // latin capital letter o with inverted breve
+	0x020F: "o\u0311",                  // latin small letter o with inverted breve
+	0x0210: "R\u030f",                  // latin capital letter r with double grave
+	0x0211: "r\u030f",                  // latin small letter r with double grave
+	0x0212: "R\u0311",                  // latin capital letter r with inverted breve
+	0x0213: "r\u0311",                  // latin small letter r with inverted breve
+	0x0214: "U\u030f",                  // latin capital letter u with double grave
+	0x0215: "u\u030f",                  // latin small letter u with double grave
+	0x0216: "U\u0311",                  // latin capital letter u with inverted breve
+	0x0217: "u\u0311",                  // latin small letter u with inverted breve
+	0x0218: "S\u0326",                  // latin capital letter s with comma below
+	0x0219: "s\u0326",                  // latin small letter s with comma below
+	0x021A: "T\u0326",                  // latin capital letter t with comma below
+	0x021B: "t\u0326",                  // latin small letter t with comma below
+	0x021E: "H\u030c",                  // latin capital letter h with caron
+	0x021F: "h\u030c",                  // latin small letter h with caron
+	0x0226: "A\u0307",                  // latin capital letter a with dot above
+	0x0227: "a\u0307",                  // latin small letter a with dot above
+	0x0228: "E\u0327",                  // latin capital letter e with cedilla
+	0x0229: "e\u0327",                  // latin small letter e with cedilla
+	0x022A: "O\u0308\u0304",            // latin capital letter o with diaeresis and macron
+	0x022B: "o\u0308\u0304",            // latin small letter o with diaeresis and macron
+	0x022C: "O\u0303\u0304",            // latin capital letter o with tilde and macron
+	0x022D: "o\u0303\u0304",            // latin small letter o with tilde and macron
+	0x022E: "O\u0307",                  // latin capital letter o with dot above
+	0x022F: "o\u0307",                  // latin small letter o with dot above
+	0x0230: "O\u0307\u0304",            // latin capital letter o with dot above and macron
+	0x0231: "o\u0307\u0304",            // latin small letter o with dot above and macron
+	0x0232: "Y\u0304",                  // latin capital letter y with macron
+	0x0233: "y\u0304",                  // latin small letter y with macron
+	0x0374: "\u02b9",                   // greek numeral sign
+	0x037E: ";",                        // greek question mark
+	0x0385: "\u00a8\u0301",             // greek dialytika tonos
+	0x0386: "\u0391\u0301",             // greek capital letter alpha with tonos
+	0x0387: "\u00b7",                   // greek ano teleia
+	0x0388: "\u0395\u0301",             // greek capital letter epsilon with tonos
+	0x0389: "\u0397\u0301",             // greek capital letter eta with tonos
+	0x038A: "\u0399\u0301",             // greek capital letter iota with tonos
+	0x038C: "\u039f\u0301",             // greek capital letter omicron with tonos
+	0x038E: "\u03a5\u0301",             // greek capital letter upsilon with tonos
+	0x038F: "\u03a9\u0301",             // greek capital letter omega with tonos
+	0x0390: "\u03b9\u0308\u0301",       // greek small letter iota with dialytika and tonos
+	0x03AA: "\u0399\u0308",             // greek capital letter iota with dialytika
+	0x03AB: "\u03a5\u0308",             // greek capital letter upsilon with dialytika
+	0x03AC: "\u03b1\u0301",             // greek small letter alpha with tonos
+	0x03AD: "\u03b5\u0301",             // greek small letter epsilon with tonos
+	0x03AE: "\u03b7\u0301",             // greek small letter eta with tonos
+	0x03AF: "\u03b9\u0301",             // greek small letter iota with tonos
+	0x03B0: "\u03c5\u0308\u0301",       // greek small letter upsilon with dialytika and tonos
+	0x03CA: "\u03b9\u0308",             // greek small letter iota with dialytika
+	0x03CB: "\u03c5\u0308",             // greek small letter upsilon with dialytika
+	0x03CC: "\u03bf\u0301",             // greek small letter omicron with tonos
+	0x03CD: "\u03c5\u0301",             // greek small letter upsilon with tonos
+	0x03CE: "\u03c9\u0301",             // greek small letter omega with tonos
+	0x03D3: "\u03d2\u0301",             // greek upsilon with acute and hook symbol
+	0x03D4: "\u03d2\u0308",             // greek upsilon with diaeresis and hook symbol
+	0x0400: "\u0415\u0300",             // cyrillic capital letter ie with grave
+	0x0401: "\u0415\u0308",             // cyrillic capital letter io
+	0x0403: "\u0413\u0301",             // cyrillic capital letter gje
+	0x0407: "\u0406\u0308",             // cyrillic capital letter yi
+	0x040C: "\u041a\u0301",             // cyrillic capital letter kje
+	0x040D: "\u0418\u0300",             // cyrillic capital letter i with grave
+	0x040E: "\u0423\u0306",             // cyrillic capital letter short u
+	0x0419: "\u0418\u0306",             // cyrillic capital letter short i
+	0x0439: "\u0438\u0306",             // cyrillic small letter short i
+	0x0450: "\u0435\u0300",             // cyrillic small letter ie with grave
+	0x0451: "\u0435\u0308",             // cyrillic small letter io
+	0x0453: "\u0433\u0301",             // cyrillic small letter gje
+	0x0457: "\u0456\u0308",             // cyrillic small letter yi
+	0x045C: "\u043a\u0301",             // cyrillic small letter kje
+	0x045D: "\u0438\u0300",             // cyrillic small letter i with grave
+	0x045E: "\u0443\u0306",             // cyrillic small letter short u
+	0x0476: "\u0474\u030f",             // cyrillic capital letter izhitsa with double grave accent
+	0x0477: "\u0475\u030f",             // cyrillic small letter izhitsa with double grave accent
+	0x04C1: "\u0416\u0306",             // cyrillic capital letter zhe with breve
+	0x04C2: "\u0436\u0306",             // cyrillic small letter zhe with breve
+	0x04D0: "\u0410\u0306",             // cyrillic capital letter a with breve
+	0x04D1: "\u0430\u0306",             // cyrillic small letter a with breve
+	0x04D2: "\u0410\u0308",             // cyrillic capital letter a with diaeresis
+	0x04D3: "\u0430\u0308",             // cyrillic small letter a with diaeresis
+	0x04D6: "\u0415\u0306",             // cyrillic capital letter ie with breve
+	0x04D7: "\u0435\u0306",             // cyrillic small letter ie with breve
+	0x04DA: "\u04d8\u0308",             // cyrillic capital letter schwa with diaeresis
+	0x04DB: "\u04d9\u0308",             // cyrillic small letter schwa with diaeresis
+	0x04DC: "\u0416\u0308",             // cyrillic capital letter zhe with diaeresis
+	0x04DD: "\u0436\u0308",             // cyrillic small letter zhe with diaeresis
+	0x04DE: "\u0417\u0308",             // cyrillic capital letter ze with diaeresis
+	0x04DF: "\u0437\u0308",             // cyrillic small letter ze with diaeresis
+	0x04E2: "\u0418\u0304",             // cyrillic capital letter i with macron
+	0x04E3: "\u0438\u0304",             // cyrillic small letter i with macron
+	0x04E4: "\u0418\u0308",             // cyrillic capital letter i with diaeresis
+	0x04E5: "\u0438\u0308",             // cyrillic small letter i with diaeresis
+	0x04E6: "\u041e\u0308",             // cyrillic capital letter o with diaeresis
+	0x04E7: "\u043e\u0308",             // cyrillic small letter o with diaeresis
+	0x04EA: "\u04e8\u0308",             // cyrillic capital letter barred o with diaeresis
+	0x04EB: "\u04e9\u0308",             // cyrillic small letter barred o with diaeresis
+	0x04EC: "\u042d\u0308",             // cyrillic capital letter e with diaeresis
+	0x04ED: "\u044d\u0308",             // cyrillic small letter e with diaeresis
+	0x04EE: "\u0423\u0304",             // cyrillic capital letter u with macron
+	0x04EF: "\u0443\u0304",             // cyrillic small letter u with macron
+	0x04F0: "\u0423\u0308",             // cyrillic capital letter u with diaeresis
+	0x04F1: "\u0443\u0308",             // cyrillic small letter u with diaeresis
+	0x04F2: "\u0423\u030b",             // cyrillic capital letter u with double acute
+	0x04F3: "\u0443\u030b",             // cyrillic small letter u with double acute
+	0x04F4: "\u0427\u0308",             // cyrillic capital letter che with diaeresis
+	0x04F5: "\u0447\u0308",             // cyrillic small letter che with diaeresis
+	0x04F8: "\u042b\u0308",             // cyrillic capital letter yeru with diaeresis
+	0x04F9: "\u044b\u0308",             // cyrillic small letter yeru with diaeresis
+	0x1E00: "A\u0325",                  // latin capital letter a with ring below
+	0x1E01: "a\u0325",                  // latin small letter a with ring below
+	0x1E02: "B\u0307",                  // latin capital letter b with dot above
+	0x1E03: "b\u0307",                  // latin small letter b with dot above
+	0x1E04: "B\u0323",                  // latin capital letter b with dot below
+	0x1E05: "b\u0323",                  // latin small letter b with dot below
+	0x1E06: "B\u0331",                  // latin capital letter b with line below
+	0x1E07: "b\u0331",                  // latin small letter b with line below
+	0x1E08: "C\u0327\u0301",            // latin capital letter c with cedilla and acute
+	0x1E09: "c\u0327\u0301",            // latin small letter c with cedilla and acute
+	0x1E0A: "D\u0307",                  // latin capital letter d with dot above
+	0x1E0B: "d\u0307",                  // latin small letter d with dot above
+	0x1E0C: "D\u0323",                  // latin capital letter d with dot below
+	0x1E0D: "d\u0323",                  // latin small letter d with dot below
+	0x1E0E: "D\u0331",                  // latin capital letter d with line below
+	0x1E0F: "d\u0331",                  // latin small letter d with line below
+	0x1E10: "D\u0327",                  // latin capital letter d with cedilla
+	0x1E11: "d\u0327",                  // latin small letter d with cedilla
+	0x1E12: "D\u032d",                  // latin capital letter d with circumflex below
+	0x1E13: "d\u032d",                  // latin small letter d with circumflex below
+	0x1E14: "E\u0304\u0300",            // latin capital letter e with macron and grave
+	0x1E15: "e\u0304\u0300",            // latin small letter e with macron and grave
+	0x1E16: "E\u0304\u0301",            // latin capital letter e with macron and acute
+	0x1E17: "e\u0304\u0301",            // latin small letter e with macron and acute
+	0x1E18: "E\u032d",                  // latin capital letter e with circumflex below
+	0x1E19: "e\u032d",                  // latin small letter e with circumflex below
+	0x1E1A: "E\u0330",                  // latin capital letter e with tilde below
+	0x1E1B: "e\u0330",                  // latin small letter e with tilde below
+	0x1E1C: "E\u0327\u0306",            // latin capital letter e with cedilla and breve
+	0x1E1D: "e\u0327\u0306",            // latin small letter e with cedilla and breve
+	0x1E1E: "F\u0307",                  // latin capital letter f with dot above
+	0x1E1F: "f\u0307",                  // latin small letter f with dot above
+	0x1E20: "G\u0304",                  // latin capital letter g with macron
+	0x1E21: "g\u0304",                  // latin small letter g with macron
+	0x1E22: "H\u0307",                  // latin capital letter h with dot above
+	0x1E23: "h\u0307",                  // latin small letter h with dot above
+	0x1E24: "H\u0323",                  // latin capital letter h with dot below
+	0x1E25: "h\u0323",                  // latin small letter h with dot below
+	0x1E26: "H\u0308",                  // latin capital letter h with diaeresis
+	0x1E27: "h\u0308",                  // latin small letter h with diaeresis
+	0x1E28: "H\u0327",                  // latin capital letter h with cedilla
+	0x1E29: "h\u0327",                  // latin small letter h with cedilla
+	0x1E2A: "H\u032e",                  // latin capital letter h with breve below
+	0x1E2B: "h\u032e",                  // latin small letter h with breve below
+	0x1E2C: "I\u0330",                  // latin capital letter i with tilde below
+	0x1E2D: "i\u0330",                  // latin small letter i with tilde below
+	0x1E2E: "I\u0308\u0301",            // latin capital letter i with diaeresis and acute
+	0x1E2F: "i\u0308\u0301",            // latin small letter i with diaeresis and acute
+	0x1E30: "K\u0301",                  // latin capital letter k with acute
+	0x1E31: "k\u0301",                  // latin small letter k with acute
+	0x1E32: "K\u0323",                  // latin capital letter k with dot below
+	0x1E33: "k\u0323",                  // latin small letter k with dot below
+	0x1E34: "K\u0331",                  // latin capital letter k with line below
+	0x1E35: "k\u0331",                  // latin small letter k with line below
+	0x1E36: "L\u0323",                  // latin capital letter l with dot below
+	0x1E37: "l\u0323",                  // latin small letter l with dot below
+	0x1E38: "L\u0323\u0304",            // latin capital letter l with dot below and macron
+	0x1E39: "l\u0323\u0304",            // latin small letter l with dot below and macron
+	0x1E3A: "L\u0331",                  // latin capital letter l with line below
+	0x1E3B: "l\u0331",                  // latin small letter l with line below
+	0x1E3C: "L\u032d",                  // latin capital letter l with circumflex below
+	0x1E3D: "l\u032d",                  // latin small letter l with circumflex below
+	0x1E3E: "M\u0301",                  // latin capital letter m with acute
+	0x1E3F: "m\u0301",                  // latin small letter m with acute
+	0x1E40: "M\u0307",                  // latin capital letter m with dot above
+	0x1E41: "m\u0307",                  // latin small letter m with dot above
+	0x1E42: "M\u0323",                  // latin capital letter m with dot below
+	0x1E43: "m\u0323",                  // latin small letter m with dot below
+	0x1E44: "N\u0307",                  // latin capital letter n with dot above
+	0x1E45: "n\u0307",                  // latin small letter n with dot above
+	0x1E46: "N\u0323",                  // latin capital letter n with dot below
+	0x1E47: "n\u0323",                  // latin small letter n with dot below
+	0x1E48: "N\u0331",                  // latin capital letter n with line below
+	0x1E49: "n\u0331",                  // latin small letter n with line below
+	0x1E4A: "N\u032d",                  // latin capital letter n with circumflex below
+	0x1E4B: "n\u032d",                  // latin small letter n with circumflex below
+	0x1E4C: "O\u0303\u0301",            // latin capital letter o with tilde and acute
+	0x1E4D: "o\u0303\u0301",            // latin small letter o with tilde and acute
+	0x1E4E: "O\u0303\u0308",            // latin capital letter o with tilde and diaeresis
+	0x1E4F: "o\u0303\u0308",            // latin small letter o with tilde and diaeresis
+	0x1E50: "O\u0304\u0300",            // latin capital letter o with macron and grave
+	0x1E51: "o\u0304\u0300",            // latin small letter o with macron and grave
+	0x1E52: "O\u0304\u0301",            // latin capital letter o with macron and acute
+	0x1E53: "o\u0304\u0301",            // latin small letter o with macron and acute
+	0x1E54: "P\u0301",                  // latin capital letter p with acute
+	0x1E55: "p\u0301",                  // latin small letter p with acute
+	0x1E56: "P\u0307",                  // latin capital letter p with dot above
+	0x1E57: "p\u0307",                  // latin small letter p with dot above
+	0x1E58: "R\u0307",                  // latin capital letter r with dot above
+	0x1E59: "r\u0307",                  // latin small letter r with dot above
+	0x1E5A: "R\u0323",                  // latin capital letter r with dot below
+	0x1E5B: "r\u0323",                  // latin small letter r with dot below
+	0x1E5C: "R\u0323\u0304",            // latin capital letter r with dot below and macron
+	0x1E5D: "r\u0323\u0304",            // latin small letter r with dot below and macron
+	0x1E5E: "R\u0331",                  // latin capital letter r with line below
+	0x1E5F: "r\u0331",                  // latin small letter r with line below
+	0x1E60: "S\u0307",                  // latin capital letter s with dot above
+	0x1E61: "s\u0307",                  // latin small letter s with dot above
+	0x1E62: "S\u0323",                  // latin capital letter s with dot below
+	0x1E63: "s\u0323",                  // latin small letter s with dot below
+	0x1E64: "S\u0301\u0307",            // latin capital letter s with acute and dot above
+	0x1E65: "s\u0301\u0307",            // latin small letter s with acute and dot above
+	0x1E66: "S\u030c\u0307",            // latin capital letter s with caron and dot above
+	0x1E67: "s\u030c\u0307",            // latin small letter s with caron and dot above
+	0x1E68: "S\u0323\u0307",            // latin capital letter s with dot below and dot above
+	0x1E69: "s\u0323\u0307",            // latin small letter s with dot below and dot above
+	0x1E6A: "T\u0307",                  // latin capital letter t with dot above
+	0x1E6B: "t\u0307",                  // latin small letter t with dot above
+	0x1E6C: "T\u0323",                  // latin capital letter t with dot below
+	0x1E6D: "t\u0323",                  // latin small letter t with dot below
+	0x1E6E: "T\u0331",                  // latin capital letter t with line below
+	0x1E6F: "t\u0331",                  // latin small letter t with line below
+	0x1E70: "T\u032d",                  // latin capital letter t with circumflex below
+	0x1E71: "t\u032d",                  // latin small letter t with circumflex below
+	0x1E72: "U\u0324",                  // latin capital letter u with diaeresis below
+	0x1E73: "u\u0324",                  // latin small letter u with diaeresis below
+	0x1E74: "U\u0330",                  // latin capital letter u with tilde below
+	0x1E75: "u\u0330",                  // latin small letter u with tilde below
+	0x1E76: "U\u032d",                  // latin capital letter u with circumflex below
+	0x1E77: "u\u032d",                  // latin small letter u with circumflex below
+	0x1E78: "U\u0303\u0301",            // latin capital letter u with tilde and acute
+	0x1E79: "u\u0303\u0301",            // latin small letter u with tilde and acute
+	0x1E7A: "U\u0304\u0308",            // latin capital letter u with macron and diaeresis
+	0x1E7B: "u\u0304\u0308",            // latin small letter u with macron and diaeresis
+	0x1E7C: "V\u0303",                  // latin capital letter v with tilde
+	0x1E7D: "v\u0303",                  // latin small letter v with tilde
+	0x1E7E: "V\u0323",                  // latin capital letter v with dot below
+	0x1E7F: "v\u0323",                  // latin small letter v with dot below
+	0x1E80: "W\u0300",                  // latin capital letter w with grave
+	0x1E81: "w\u0300",                  // latin small letter w with grave
+	0x1E82: "W\u0301",                  // latin capital letter w with acute
+	0x1E83: "w\u0301",                  // latin small letter w with acute
+	0x1E84: "W\u0308",                  // latin capital letter w with diaeresis
+	0x1E85: "w\u0308",                  // latin small letter w with diaeresis
+	0x1E86: "W\u0307",                  // latin capital letter w with dot above
+	0x1E87: "w\u0307",                  // latin small letter w with dot above
+	0x1E88: "W\u0323",                  // latin capital letter w with dot below
+	0x1E89: "w\u0323",                  // latin small letter w with dot below
+	0x1E8A: "X\u0307",                  // latin capital letter x with dot above
+	0x1E8B: "x\u0307",                  // latin small letter x with dot above
+	0x1E8C: "X\u0308",                  // latin capital letter x with diaeresis
+	0x1E8D: "x\u0308",                  // latin small letter x with diaeresis
+	0x1E8E: "Y\u0307",                  // latin capital letter y with dot above
+	0x1E8F: "y\u0307",                  // latin small letter y with dot above
+	0x1E90: "Z\u0302",                  // latin capital letter z with circumflex
+	0x1E91: "z\u0302",                  // latin small letter z with circumflex
+	0x1E92: "Z\u0323",                  // latin capital letter z with dot below
+	0x1E93: "z\u0323",                  // latin small letter z with dot below
+	0x1E94: "Z\u0331",                  // latin capital letter z with line below
+	0x1E95: "z\u0331",                  // latin small letter z with line below
+	0x1E96: "h\u0331",                  // latin small letter h with line below
+	0x1E97: "t\u0308",                  // latin small letter t with diaeresis
+	0x1E98: "w\u030a",                  // latin small letter w with ring above
+	0x1E99: "y\u030a",                  // latin small letter y with ring above
+	0x1E9B: "\u017f\u0307",             // latin small letter long s with dot above
+	0x1EA0: "A\u0323",                  // latin capital letter a with dot below
+	0x1EA1: "a\u0323",                  // latin small letter a with dot below
+	0x1EA2: "A\u0309",                  // latin capital letter a with hook above
+	0x1EA3: "a\u0309",                  // latin small letter a with hook above
+	0x1EA4: "A\u0302\u0301",            // latin capital letter a with circumflex and acute
+	0x1EA5: "a\u0302\u0301",            // latin small letter a with circumflex and acute
+	0x1EA6: "A\u0302\u0300",            // latin capital letter a with circumflex and grave
+	0x1EA7: "a\u0302\u0300",            // latin small letter a with circumflex and grave
+	0x1EA8: "A\u0302\u0309",            // latin capital letter a with circumflex and hook above
+	0x1EA9: "a\u0302\u0309",            // latin small letter a with circumflex and hook above
+	0x1EAA: "A\u0302\u0303",            // latin capital letter a with circumflex and tilde
+	0x1EAB: "a\u0302\u0303",            // latin small letter a with circumflex and tilde
+	0x1EAC: "A\u0323\u0302",            // latin capital letter a with circumflex and dot below
+	0x1EAD: "a\u0323\u0302",            // latin small letter a with circumflex and dot below
+	0x1EAE: "A\u0306\u0301",            // latin capital letter a with breve and acute
+	0x1EAF: "a\u0306\u0301",            // latin small letter a with breve and acute
+	0x1EB0: "A\u0306\u0300",            // latin capital letter a with breve and grave
+	0x1EB1: "a\u0306\u0300",            // latin small letter a with breve and grave
+	0x1EB2: "A\u0306\u0309",            // latin capital letter a with breve and hook above
+	0x1EB3: "a\u0306\u0309",            // latin small letter a with breve and hook above
+	0x1EB4: "A\u0306\u0303",            // latin capital letter a with breve and tilde
+	0x1EB5: "a\u0306\u0303",            // latin small letter a with breve and tilde
+	0x1EB6: "A\u0323\u0306",            // latin capital letter a with breve and dot below
+	0x1EB7: "a\u0323\u0306",            // latin small letter a with breve and dot below
+	0x1EB8: "E\u0323",                  // latin capital letter e with dot below
+	0x1EB9: "e\u0323",                  // latin small letter e with dot below
+	0x1EBA: "E\u0309",                  // latin capital letter e with hook above
+	0x1EBB: "e\u0309",                  // latin small letter e with hook above
+	0x1EBC: "E\u0303",                  // latin capital letter e with tilde
+	0x1EBD: "e\u0303",                  // latin small letter e with tilde
+	0x1EBE: "E\u0302\u0301",            // latin capital letter e with circumflex and acute
+	0x1EBF: "e\u0302\u0301",            // latin small letter e with circumflex and acute
+	0x1EC0: "E\u0302\u0300",            // latin capital letter e with circumflex and grave
+	0x1EC1: "e\u0302\u0300",            // latin small letter e with circumflex and grave
+	0x1EC2: "E\u0302\u0309",            // latin capital letter e with circumflex and hook above
+	0x1EC3: "e\u0302\u0309",            // latin small letter e with circumflex and hook above
+	0x1EC4: "E\u0302\u0303",            // latin capital letter e with circumflex and tilde
+	0x1EC5: "e\u0302\u0303",            // latin small letter e with circumflex and tilde
+	0x1EC6: "E\u0323\u0302",            // latin capital letter e with circumflex and dot below
+	0x1EC7: "e\u0323\u0302",            // latin small letter e with circumflex and dot below
+	0x1EC8: "I\u0309",                  // latin capital letter i with hook above
+	0x1EC9: "i\u0309",                  // latin small letter i with hook above
+	0x1ECA: "I\u0323",                  // latin capital letter i with dot below
+	0x1ECB: "i\u0323",                  // latin small letter i with dot below
+	0x1ECC: "O\u0323",                  // latin capital letter o with dot below
+	0x1ECD: "o\u0323",                  // latin small letter o with dot below
+	0x1ECE: "O\u0309",                  // latin capital letter o with hook above
+	0x1ECF: "o\u0309",                  // latin small letter o with hook above
+	0x1ED0: "O\u0302\u0301",            // latin capital letter o with circumflex and acute
+	0x1ED1: "o\u0302\u0301",            // latin small letter o with circumflex and acute
+	0x1ED2: "O\u0302\u0300",            // latin capital letter o with circumflex and grave
+	0x1ED3: "o\u0302\u0300",            // latin small letter o with circumflex and grave
+	0x1ED4: "O\u0302\u0309",            // latin capital letter o with circumflex and hook above
+	0x1ED5: "o\u0302\u0309",            // latin small letter o with circumflex and hook above
+	0x1ED6: "O\u0302\u0303",            // latin capital letter o with circumflex and tilde
+	0x1ED7: "o\u0302\u0303",            // latin small letter o with circumflex and tilde
+	0x1ED8: "O\u0323\u0302",            // latin capital letter o with circumflex and dot below
+	0x1ED9: "o\u0323\u0302",            // latin small letter o with circumflex and dot below
+	0x1EDA: "O\u031b\u0301",            // latin capital letter o with horn and acute
+	0x1EDB: "o\u031b\u0301",            // latin small letter o with horn and acute
+	0x1EDC: "O\u031b\u0300",            // latin capital letter o with horn and grave
+	0x1EDD: "o\u031b\u0300",            // latin small letter o with horn and grave
+	0x1EDE: "O\u031b\u0309",            // latin capital letter o with horn and hook above
+	0x1EDF: "o\u031b\u0309",            // latin small letter o with horn and hook above
+	0x1EE0: "O\u031b\u0303",            // latin capital letter o with horn and tilde
+	0x1EE1: "o\u031b\u0303",            // latin small letter o with horn and tilde
+	0x1EE2: "O\u031b\u0323",            // latin capital letter o with horn and dot below
+	0x1EE3: "o\u031b\u0323",            // latin small letter o with horn and dot below
+	0x1EE4: "U\u0323",                  // latin capital letter u with dot below
+	0x1EE5: "u\u0323",                  // latin small letter u with dot below
+	0x1EE6: "U\u0309",                  // latin capital letter u with hook above
+	0x1EE7: "u\u0309",                  // latin small letter u with hook above
+	0x1EE8: "U\u031b\u0301",            // latin capital letter u with horn and acute
+	0x1EE9: "u\u031b\u0301",            // latin small letter u with horn and acute
+	0x1EEA: "U\u031b\u0300",            // latin capital letter u with horn and grave
+	0x1EEB: "u\u031b\u0300",            // latin small letter u with horn and grave
+	0x1EEC: "U\u031b\u0309",            // latin capital letter u with horn and hook above
+	0x1EED: "u\u031b\u0309",            // latin small letter u with horn and hook above
+	0x1EEE: "U\u031b\u0303",            // latin capital letter u with horn and tilde
+	0x1EEF: "u\u031b\u0303",            // latin small letter u with horn and tilde
+	0x1EF0: "U\u031b\u0323",            // latin capital letter u with horn and dot below
+	0x1EF1: "u\u031b\u0323",            // latin small letter u with horn and dot below
+	0x1EF2: "Y\u0300",                  // latin capital letter y with grave
+	0x1EF3: "y\u0300",                  // latin small letter y with grave
+	0x1EF4: "Y\u0323",                  // latin capital letter y with dot below
+	0x1EF5: "y\u0323",                  // latin small letter y with dot below
+	0x1EF6: "Y\u0309",                  // latin capital letter y with hook above
+	0x1EF7: "y\u0309",                  // latin small letter y with hook above
+	0x1EF8: "Y\u0303",                  // latin capital letter y with tilde
+	0x1EF9: "y\u0303",                  // latin small letter y with tilde
+	0x1F00: "\u03b1\u0313",             // greek small letter alpha with psili
+	0x1F01: "\u03b1\u0314",             // greek small letter alpha with dasia
+	0x1F02: "\u03b1\u0313\u0300",       // greek small letter alpha with psili and varia
+	0x1F03: "\u03b1\u0314\u0300",       // greek small letter alpha with dasia and varia
+	0x1F04: "\u03b1\u0313\u0301",       // greek small letter alpha with psili and oxia
+	0x1F05: "\u03b1\u0314\u0301",       // greek small letter alpha with dasia and oxia
+	0x1F06: "\u03b1\u0313\u0342",       // greek small letter alpha with psili and perispomeni
+	0x1F07: "\u03b1\u0314\u0342",       // greek small letter alpha with dasia and perispomeni
+	0x1F08: "\u0391\u0313",             // greek capital letter alpha with psili
+	0x1F09: "\u0391\u0314",             // greek capital letter alpha with dasia
+	0x1F0A: "\u0391\u0313\u0300",       // greek capital letter alpha with psili and varia
+	0x1F0B: "\u0391\u0314\u0300",       // greek capital letter alpha with dasia and varia
+	0x1F0C: "\u0391\u0313\u0301",       // greek capital letter alpha with psili and oxia
+	0x1F0D: "\u0391\u0314\u0301",       // greek capital letter alpha with dasia and oxia
+	0x1F0E: "\u0391\u0313\u0342",       // greek capital letter alpha with psili and perispomeni
+	0x1F0F: "\u0391\u0314\u0342",       // greek capital letter alpha with dasia and perispomeni
+	0x1F10: "\u03b5\u0313",             // greek small letter epsilon with psili
+	0x1F11: "\u03b5\u0314",             // greek small letter epsilon with dasia
+	0x1F12: "\u03b5\u0313\u0300",       // greek small letter epsilon with psili and varia
+	0x1F13: "\u03b5\u0314\u0300",       // greek small letter epsilon with dasia and varia
+	0x1F14: "\u03b5\u0313\u0301",       // greek small letter epsilon with psili and oxia
+	0x1F15: "\u03b5\u0314\u0301",       // greek small letter epsilon with dasia and oxia
+	0x1F18: "\u0395\u0313",             // greek capital letter epsilon with psili
+	0x1F19: "\u0395\u0314",             // greek capital letter epsilon with dasia
+	0x1F1A: "\u0395\u0313\u0300",       // greek capital letter epsilon with psili and varia
+	0x1F1B: "\u0395\u0314\u0300",       // greek capital letter epsilon with dasia and varia
+	0x1F1C: "\u0395\u0313\u0301",       // greek capital letter epsilon with psili and oxia
+	0x1F1D: "\u0395\u0314\u0301",       // greek capital letter epsilon with dasia and oxia
+	0x1F20: "\u03b7\u0313",             // greek small letter eta with psili
+	0x1F21: "\u03b7\u0314",             // greek small letter eta with dasia
+	0x1F22: "\u03b7\u0313\u0300",       // greek small letter eta with psili and varia
+	0x1F23: "\u03b7\u0314\u0300",       // greek small letter eta with dasia and varia
+	0x1F24: "\u03b7\u0313\u0301",       // greek small letter eta with psili and oxia
+	0x1F25: "\u03b7\u0314\u0301",       // greek small letter eta with dasia and oxia
+	0x1F26: "\u03b7\u0313\u0342",       // greek small letter eta with psili and perispomeni
+	0x1F27: "\u03b7\u0314\u0342",       // greek small letter eta with dasia and perispomeni
+	0x1F28: "\u0397\u0313",             // greek capital letter eta with psili
+	0x1F29: "\u0397\u0314",             // greek capital letter eta with dasia
+	0x1F2A: "\u0397\u0313\u0300",       // greek capital letter eta with psili and varia
+	0x1F2B: "\u0397\u0314\u0300",       // greek capital letter eta with dasia and varia
+	0x1F2C: "\u0397\u0313\u0301",       // greek capital letter eta with psili and oxia
+	0x1F2D: "\u0397\u0314\u0301",       // greek capital letter eta with dasia and oxia
+	0x1F2E: "\u0397\u0313\u0342",       // greek capital letter eta with psili and perispomeni
+	0x1F2F: "\u0397\u0314\u0342",       // greek capital letter eta with dasia and perispomeni
+	0x1F30: "\u03b9\u0313",             // greek small letter iota with psili
+	0x1F31: "\u03b9\u0314",             // greek small letter iota with dasia
+	0x1F32: "\u03b9\u0313\u0300",       // greek small letter iota with psili and varia
+	0x1F33: "\u03b9\u0314\u0300",       // greek small letter iota with dasia and varia
+	0x1F34: "\u03b9\u0313\u0301",       // greek small letter iota with psili and oxia
+	0x1F35: "\u03b9\u0314\u0301",       // greek small letter iota with dasia and oxia
+	0x1F36: "\u03b9\u0313\u0342",       // greek small letter iota with psili and perispomeni
+	0x1F37: "\u03b9\u0314\u0342",       // greek small letter iota with dasia and perispomeni
+	0x1F38: "\u0399\u0313",             // greek capital letter iota with psili
+	0x1F39: "\u0399\u0314",             // greek capital letter iota with dasia
+	0x1F3A: "\u0399\u0313\u0300",       // greek capital letter iota with psili and varia
+	0x1F3B: "\u0399\u0314\u0300",       // greek capital letter iota with dasia and varia
+	0x1F3C: "\u0399\u0313\u0301",       // greek capital letter iota with psili and oxia
+	0x1F3D: "\u0399\u0314\u0301",       // greek capital letter iota with dasia and oxia
+	0x1F3E: "\u0399\u0313\u0342",       // greek capital letter iota with psili and perispomeni
+	0x1F3F: "\u0399\u0314\u0342",       // greek capital letter iota with dasia and perispomeni
+	0x1F40: "\u03bf\u0313",             // greek small letter omicron with psili
+	0x1F41: "\u03bf\u0314",             // greek small letter omicron with dasia
+	0x1F42: "\u03bf\u0313\u0300",       // greek small letter omicron with psili and varia
+	0x1F43: "\u03bf\u0314\u0300",       // greek small letter omicron with dasia and varia
+	0x1F44: "\u03bf\u0313\u0301",       // greek small letter omicron with psili and oxia
+	0x1F45: "\u03bf\u0314\u0301",       // greek small letter omicron with dasia and oxia
+	0x1F48: "\u039f\u0313",             // greek capital letter omicron with psili
+	0x1F49: "\u039f\u0314",             // greek capital letter omicron with dasia
+	0x1F4A: "\u039f\u0313\u0300",       // greek capital letter omicron with psili and varia
+	0x1F4B: "\u039f\u0314\u0300",       // greek capital letter omicron with dasia and varia
+	0x1F4C: "\u039f\u0313\u0301",       // greek capital letter omicron with psili and oxia
+	0x1F4D: "\u039f\u0314\u0301",       // greek capital letter omicron with dasia and oxia
+	0x1F50: "\u03c5\u0313",             // greek small letter upsilon with psili
+	0x1F51: "\u03c5\u0314",             // greek small letter upsilon with dasia
+	0x1F52: "\u03c5\u0313\u0300",       // greek small letter upsilon with psili and varia
+	0x1F53: "\u03c5\u0314\u0300",       // greek small letter upsilon with dasia and varia
+	0x1F54: "\u03c5\u0313\u0301",       // greek small letter upsilon with psili and oxia
+	0x1F55: "\u03c5\u0314\u0301",       // greek small letter upsilon with dasia and oxia
+	0x1F56: "\u03c5\u0313\u0342",       // greek small letter upsilon with psili and perispomeni
+	0x1F57: "\u03c5\u0314\u0342",       // greek small letter upsilon with dasia and perispomeni
+	0x1F59: "\u03a5\u0314",             // greek capital letter upsilon with dasia
+	0x1F5B: "\u03a5\u0314\u0300",       // greek capital letter upsilon with dasia and varia
+	0x1F5D: "\u03a5\u0314\u0301",       // greek capital letter upsilon with dasia and oxia
+	0x1F5F: "\u03a5\u0314\u0342",       // greek capital letter upsilon with dasia and perispomeni
+	0x1F60: "\u03c9\u0313",             // greek small letter omega with psili
+	0x1F61: "\u03c9\u0314",             // greek small letter omega with dasia
+	0x1F62: "\u03c9\u0313\u0300",       // greek small letter omega with psili and varia
+	0x1F63: "\u03c9\u0314\u0300",       // greek small letter omega with dasia and varia
+	0x1F64: "\u03c9\u0313\u0301",       // greek small letter omega with psili and oxia
+	0x1F65: "\u03c9\u0314\u0301",       // greek small letter omega with dasia and oxia
+	0x1F66: "\u03c9\u0313\u0342",       // greek small letter omega with psili and perispomeni
+	0x1F67: "\u03c9\u0314\u0342",       // greek small letter omega with dasia and perispomeni
+	0x1F68: "\u03a9\u0313",             // greek capital letter omega with psili
+	0x1F69: "\u03a9\u0314",             // greek capital letter omega with dasia
+	0x1F6A: "\u03a9\u0313\u0300",       // greek capital letter omega with psili and varia
+	0x1F6B: "\u03a9\u0314\u0300",       // greek capital letter omega with dasia and varia
+	0x1F6C: "\u03a9\u0313\u0301",       // greek capital letter omega with psili and oxia
+	0x1F6D: "\u03a9\u0314\u0301",       // greek capital letter omega with dasia and oxia
+	0x1F6E: "\u03a9\u0313\u0342",       // greek capital letter omega with psili and perispomeni
+	0x1F6F: "\u03a9\u0314\u0342",       // greek capital letter omega with dasia and perispomeni
+	0x1F70: "\u03b1\u0300",             // greek small letter alpha with varia
+	0x1F71: "\u03b1\u0301",             // greek small letter alpha with oxia
+	0x1F72: "\u03b5\u0300",             // greek small letter epsilon with varia
+	0x1F73: "\u03b5\u0301",             // greek small letter epsilon with oxia
+	0x1F74: "\u03b7\u0300",             // greek small letter eta with varia
+	0x1F75: "\u03b7\u0301",             // greek small letter eta with oxia
+	0x1F76: "\u03b9\u0300",             // greek small letter iota with varia
+	0x1F77: "\u03b9\u0301",             // greek small letter iota with oxia
+	0x1F78: "\u03bf\u0300",             // greek small letter omicron with varia
+	0x1F79: "\u03bf\u0301",             // greek small letter omicron with oxia
+	0x1F7A: "\u03c5\u0300",             // greek small letter upsilon with varia
+	0x1F7B: "\u03c5\u0301",             // greek small letter upsilon with oxia
+	0x1F7C: "\u03c9\u0300",             // greek small letter omega with varia
+	0x1F7D: "\u03c9\u0301",             // greek small letter omega with oxia
+	0x1F80: "\u03b1\u0313\u0345",       // greek small letter alpha with psili and ypogegrammeni
+	0x1F81: "\u03b1\u0314\u0345",       // greek small letter alpha with dasia and ypogegrammeni
+	0x1F82: "\u03b1\u0313\u0300\u0345", // greek small letter alpha with psili and varia and ypogegrammeni
+	0x1F83: "\u03b1\u0314\u0300\u0345", // greek small letter alpha with dasia and varia and ypogegrammeni
+	0x1F84: "\u03b1\u0313\u0301\u0345", // greek small letter alpha with psili and oxia and ypogegrammeni
+	0x1F85: "\u03b1\u0314\u0301\u0345", // greek small letter alpha with dasia and oxia and ypogegrammeni
+	0x1F86: "\u03b1\u0313\u0342\u0345", // greek small letter alpha with psili and perispomeni and ypogegrammeni
+	0x1F87: "\u03b1\u0314\u0342\u0345", // greek small letter alpha with dasia and perispomeni and ypogegrammeni
+	0x1F88: "\u0391\u0313\u0345",       // greek capital letter alpha with psili and prosgegrammeni
+	0x1F89: "\u0391\u0314\u0345",       // greek capital letter alpha with dasia and prosgegrammeni
+	0x1F8A: "\u0391\u0313\u0300\u0345", // greek capital letter alpha with psili and varia and prosgegrammeni
+	0x1F8B: "\u0391\u0314\u0300\u0345", // greek capital letter alpha with dasia and varia and prosgegrammeni
+	0x1F8C: "\u0391\u0313\u0301\u0345", // greek capital letter alpha with psili and oxia and prosgegrammeni
+	0x1F8D: "\u0391\u0314\u0301\u0345", // greek capital letter alpha with dasia and oxia and prosgegrammeni
+	0x1F8E: "\u0391\u0313\u0342\u0345", // greek capital letter alpha with psili and perispomeni and prosgegrammeni
+	0x1F8F: "\u0391\u0314\u0342\u0345", // greek capital letter alpha with dasia and perispomeni and prosgegrammeni
+	0x1F90: "\u03b7\u0313\u0345",       // greek small letter eta with psili and ypogegrammeni
+	0x1F91: "\u03b7\u0314\u0345",       // greek small letter eta with dasia and ypogegrammeni
+	0x1F92: "\u03b7\u0313\u0300\u0345", // greek small letter eta with psili and varia and ypogegrammeni
+	0x1F93: "\u03b7\u0314\u0300\u0345", // greek small letter eta with dasia and varia and ypogegrammeni
+	0x1F94: "\u03b7\u0313\u0301\u0345", // greek small letter eta with psili and oxia and ypogegrammeni
+	0x1F95: "\u03b7\u0314\u0301\u0345", // greek small letter eta with dasia and oxia and ypogegrammeni
+	0x1F96: "\u03b7\u0313\u0342\u0345", // greek small letter eta with psili and perispomeni and ypogegrammeni
+	0x1F97: "\u03b7\u0314\u0342\u0345", // greek small letter eta with dasia and perispomeni and ypogegrammeni
+	0x1F98: "\u0397\u0313\u0345",       // greek capital letter eta with psili and prosgegrammeni
+	0x1F99: "\u0397\u0314\u0345",       // greek capital letter eta with dasia and prosgegrammeni
+	0x1F9A: "\u0397\u0313\u0300\u0345", // greek capital letter eta with psili and varia and prosgegrammeni
+	0x1F9B: "\u0397\u0314\u0300\u0345", // greek capital letter eta with dasia and varia and prosgegrammeni
+	0x1F9C: "\u0397\u0313\u0301\u0345", // greek capital letter eta with psili and oxia and prosgegrammeni
+	0x1F9D: "\u0397\u0314\u0301\u0345", // greek capital letter eta with dasia and oxia and prosgegrammeni
+	0x1F9E: "\u0397\u0313\u0342\u0345", // greek capital letter eta with psili and perispomeni and prosgegrammeni
+	0x1F9F: "\u0397\u0314\u0342\u0345", // greek capital letter eta with dasia and perispomeni and prosgegrammeni
+	0x1FA0: "\u03c9\u0313\u0345",       // greek small letter omega with psili and ypogegrammeni
+	0x1FA1: "\u03c9\u0314\u0345",       // greek small letter omega with dasia and ypogegrammeni
+	0x1FA2: "\u03c9\u0313\u0300\u0345", // greek small letter omega with psili and varia and ypogegrammeni
+	0x1FA3: "\u03c9\u0314\u0300\u0345", // greek small letter omega with dasia and varia and ypogegrammeni
+	0x1FA4: "\u03c9\u0313\u0301\u0345", // greek small letter omega with psili and oxia and ypogegrammeni
+	0x1FA5: "\u03c9\u0314\u0301\u0345", // greek small letter omega with dasia and oxia and ypogegrammeni
+	0x1FA6: "\u03c9\u0313\u0342\u0345", // greek small letter omega with psili and perispomeni and ypogegrammeni
+	0x1FA7: "\u03c9\u0314\u0342\u0345", // greek small letter omega with dasia and perispomeni and ypogegrammeni
+	0x1FA8: "\u03a9\u0313\u0345",       // greek capital letter omega with psili and prosgegrammeni
+	0x1FA9: "\u03a9\u0314\u0345",       // greek capital letter omega with dasia and prosgegrammeni
+	0x1FAA: "\u03a9\u0313\u0300\u0345", // greek capital letter omega with psili and varia and prosgegrammeni
+	0x1FAB: "\u03a9\u0314\u0300\u0345", // greek capital letter omega with dasia and varia and prosgegrammeni
+	0x1FAC: "\u03a9\u0313\u0301\u0345", // greek capital letter omega with psili and oxia and prosgegrammeni
+	0x1FAD: "\u03a9\u0314\u0301\u0345", // greek capital letter omega with dasia and oxia and prosgegrammeni
+	0x1FAE: "\u03a9\u0313\u0342\u0345", // greek capital letter omega with psili and perispomeni and prosgegrammeni
+	0x1FAF: "\u03a9\u0314\u0342\u0345", // greek capital letter omega with dasia and perispomeni and prosgegrammeni
+	0x1FB0: "\u03b1\u0306",             // greek small letter alpha with vrachy
+	0x1FB1: "\u03b1\u0304",             // greek small letter alpha with macron
+	0x1FB2: "\u03b1\u0300\u0345",       // greek small letter alpha with varia and ypogegrammeni
+	0x1FB3: "\u03b1\u0345",             // greek small letter alpha with ypogegrammeni
+	0x1FB4: "\u03b1\u0301\u0345",       // greek small letter alpha with oxia and ypogegrammeni
+	0x1FB6: "\u03b1\u0342",             // greek small letter alpha with perispomeni
+	0x1FB7: "\u03b1\u0342\u0345",       // greek small letter alpha with perispomeni and ypogegrammeni
+	0x1FB8: "\u0391\u0306",             // greek capital letter alpha with vrachy
+	0x1FB9: "\u0391\u0304",             // greek capital letter alpha with macron
+	0x1FBA: "\u0391\u0300",             // greek capital letter alpha with varia
+	0x1FBB: "\u0391\u0301",             // greek capital letter alpha with oxia
+	0x1FBC: "\u0391\u0345",             // greek capital letter alpha with prosgegrammeni
+	0x1FBE: "\u03b9",                   // greek prosgegrammeni
+	0x1FC1: "\u00a8\u0342",             // greek dialytika and perispomeni
+	0x1FC2: "\u03b7\u0300\u0345",       // greek small letter eta with varia and ypogegrammeni
+	0x1FC3: "\u03b7\u0345",             // greek small letter eta with ypogegrammeni
+	0x1FC4: "\u03b7\u0301\u0345",       // greek small letter eta with oxia and ypogegrammeni
+	0x1FC6: "\u03b7\u0342",             // greek small letter eta with perispomeni
+	0x1FC7: "\u03b7\u0342\u0345",       // greek small letter eta with perispomeni and ypogegrammeni
+	0x1FC8: "\u0395\u0300",             // greek capital letter epsilon with varia
+	0x1FC9: "\u0395\u0301",             // greek capital letter epsilon with oxia
+	0x1FCA: "\u0397\u0300",             // greek capital letter eta with varia
+	0x1FCB: "\u0397\u0301",             // greek capital letter eta with oxia
+	0x1FCC: "\u0397\u0345",             // greek capital letter eta with prosgegrammeni
+	0x1FCD: "\u1fbf\u0300",             // greek psili and varia
+	0x1FCE: "\u1fbf\u0301",             // greek psili and oxia
+	0x1FCF: "\u1fbf\u0342",             // greek psili and perispomeni
+	0x1FD0: "\u03b9\u0306",             // greek small letter iota with vrachy
+	0x1FD1: "\u03b9\u0304",             // greek small letter iota with macron
+	0x1FD2: "\u03b9\u0308\u0300",       // greek small letter iota with dialytika and varia
+	0x1FD3: "\u03b9\u0308\u0301",       // greek small letter iota with dialytika and oxia
+	0x1FD6: "\u03b9\u0342",             // greek small letter iota with perispomeni
+	0x1FD7: "\u03b9\u0308\u0342",       // greek small letter iota with dialytika and perispomeni
+	0x1FD8: "\u0399\u0306",             // greek capital letter iota with vrachy
+	0x1FD9: "\u0399\u0304",             // greek capital letter iota with macron
+	0x1FDA: "\u0399\u0300",             // greek capital letter iota with varia
+	0x1FDB: "\u0399\u0301",             // greek capital letter iota with oxia
+	0x1FDD: "\u1ffe\u0300",             // greek dasia and varia
+	0x1FDE: "\u1ffe\u0301",             // greek dasia and oxia
+	0x1FDF: "\u1ffe\u0342",             // greek dasia and perispomeni
+	0x1FE0: "\u03c5\u0306",             // greek small letter upsilon with vrachy
+	0x1FE1: "\u03c5\u0304",             // greek small letter upsilon with macron
+	0x1FE2: "\u03c5\u0308\u0300",       // greek small letter upsilon with dialytika and varia
+	0x1FE3: "\u03c5\u0308\u0301",       // greek small letter upsilon with dialytika and oxia
+	0x1FE4: "\u03c1\u0313",             // greek small letter rho with psili
+	0x1FE5: "\u03c1\u0314",             // greek small letter rho with dasia
+	0x1FE6: "\u03c5\u0342",             // greek small letter upsilon with perispomeni
+	0x1FE7: "\u03c5\u0308\u0342",       // greek small letter upsilon with dialytika and perispomeni
+	0x1FE8: "\u03a5\u0306",             // greek capital letter upsilon with vrachy
+	0x1FE9: "\u03a5\u0304",             // greek capital letter upsilon with macron
+	0x1FEA: "\u03a5\u0300",             // greek capital letter upsilon with varia
+	0x1FEB: "\u03a5\u0301",             // greek capital letter upsilon with oxia
+	0x1FEC: "\u03a1\u0314",             // greek capital letter rho with dasia
+	0x1FED: "\u00a8\u0300",             // greek dialytika and varia
+	0x1FEE: "\u00a8\u0301",             // greek dialytika and oxia
+	0x1FEF: "`",                        // greek varia
+	0x1FF2: "\u03c9\u0300\u0345",       // greek small letter omega with varia and ypogegrammeni
+	0x1FF3: "\u03c9\u0345",             // greek small letter omega with ypogegrammeni
+	0x1FF4: "\u03c9\u0301\u0345",       // greek small letter omega with oxia and ypogegrammeni
+	0x1FF6: "\u03c9\u0342",             // greek small letter omega with perispomeni
+	0x1FF7: "\u03c9\u0342\u0345",       // greek small letter omega with perispomeni and ypogegrammeni
+	0x1FF8: "\u039f\u0300",             // greek capital letter omicron with varia
+	0x1FF9: "\u039f\u0301",             // greek capital letter omicron with oxia
+	0x1FFA: "\u03a9\u0300",             // greek capital letter omega with varia
+	0x1FFB: "\u03a9\u0301",             // greek capital letter omega with oxia
+	0x1FFC: "\u03a9\u0345",             // greek capital letter omega with prosgegrammeni
+	0x1FFD: "\u00b4",                   // greek oxia
+	0x2126: "\u03a9",                   // ohm sign
+	0x212A: "K",                        // kelvin sign
+	0x212B: "A\u030a",                  // angstrom sign
+}
+
+// Lists the canonical combining classes of the combining marks in the Combining Diacritical Marks blocks,
+// as ranges of runes that share a class, in ascending order.  Runes outside of these ranges are treated
+// as having a combining class of zero, so they are never reordered.  The classes are taken from
+// the same version of the Unicode character database as the decompositions.
+var combiningClasses = []combiningClassRange{
+	{0x0300, 0x0314, 230},
+	{0x0315, 0x0315, 232},
+	{0x0316, 0x0319, 220},
+	{0x031A, 0x031A, 232},
+	{0x031B, 0x031B, 216},
+	{0x031C, 0x0320, 220},
+	{0x0321, 0x0322, 202},
+	{0x0323, 0x0326, 220},
+	{0x0327, 0x0328, 202},
+	{0x0329, 0x0333, 220},
+	{0x0334, 0x0338, 1},
+	{0x0339, 0x033C, 220},
+	{0x033D, 0x0344, 230},
+	{0x0345, 0x0345, 240},
+	{0x0346, 0x0346, 230},
+	{0x0347, 0x0349, 220},
+	{0x034A, 0x034C, 230},
+	{0x034D, 0x034E, 220},
+	{0x0350, 0x0352, 230},
+	{0x0353, 0x0356, 220},
+	{0x0357, 0x0357, 230},
+	{0x0358, 0x0358, 232},
+	{0x0359, 0x035A, 220},
+	{0x035B, 0x035B, 230},
+	{0x035C, 0x035C, 233},
+	{0x035D, 0x035E, 234},
+	{0x035F, 0x035F, 233},
+	{0x0360, 0x0361, 234},
+	{0x0362, 0x0362, 233},
+	{0x0363, 0x036F, 230},
+	{0x0483, 0x0487, 230},
+	{0x1AB0, 0x1AB4, 230},
+	{0x1AB5, 0x1ABA, 220},
+	{0x1ABB, 0x1ABC, 230},
+	{0x1ABD, 0x1ABD, 220},
+	{0x1ABF, 0x1AC0, 220},
+	{0x1AC1, 0x1AC2, 230},
+	{0x1AC3, 0x1AC4, 220},
+	{0x1AC5, 0x1AC9, 230},
+	{0x1ACA, 0x1ACA, 220},
+	{0x1ACB, 0x1ACE, 230},
+	{0x1DC0, 0x1DC1, 230},
+	{0x1DC2, 0x1DC2, 220},
+	{0x1DC3, 0x1DC9, 230},
+	{0x1DCA, 0x1DCA, 220},
+	{0x1DCB, 0x1DCC, 230},
+	{0x1DCD, 0x1DCD, 234},
+	{0x1DCE, 0x1DCE, 214},
+	{0x1DCF, 0x1DCF, 220},
+	{0x1DD0, 0x1DD0, 202},
+	{0x1DD1, 0x1DF5, 230},
+	{0x1DF6, 0x1DF6, 232},
+	{0x1DF7, 0x1DF8, 228},
+	{0x1DF9, 0x1DF9, 220},
+	{0x1DFA, 0x1DFA, 218},
+	{0x1DFB, 0x1DFB, 230},
+	{0x1DFC, 0x1DFC, 233},
+	{0x1DFD, 0x1DFD, 220},
+	{0x1DFE, 0x1DFE, 230},
+	{0x1DFF, 0x1DFF, 220},
+	{0x20D0, 0x20D1, 230},
+	{0x20D2, 0x20D3, 1},
+	{0x20D4, 0x20D7, 230},
+	{0x20D8, 0x20DA, 1},
+	{0x20DB, 0x20DC, 230},
+	{0x20E1, 0x20E1, 230},
+	{0x20E5, 0x20E6, 1},
+	{0x20E7, 0x20E7, 230},
+	{0x20E8, 0x20E8, 220},
+	{0x20E9, 0x20E9, 230},
+	{0x20EA, 0x20EB, 1},
+	{0x20EC, 0x20EF, 220},
+	{0x20F0, 0x20F0, 230},
+	{0xFE20, 0xFE26, 230},
+	{0xFE27, 0xFE2D, 220},
+	{0xFE2E, 0xFE2F, 230},
+}
@@ -0,0 +1,94 @@
+package keys
+
+import (
+	"testing"
+
+	"github.com/kellydunn/go-art"
+)
+
+// Collated keys should be equal exactly when they only differ in the ways the options ignore.
+func TestCollateEquivalence(t *testing.T) {
+	foldAndStrip := Collate(CollateOptions{FoldCase: true, StripAccents: true})
+	fold := Collate(CollateOptions{FoldCase: true})
+	plain := Collate(CollateOptions{})
+
+	cases := []struct {
+		transform func([]byte) []byte
+		a, b      string
+		equal     bool
+	}{
+		{plain, "café", "café", true},
+		{plain, "Café", "café", false},
+		{fold, "CAFÉ", "café", true},
+		{fold, "café", "cafe", false},
+		{foldAndStrip, "Crème Brûlée", "creme brulee", true},
+		{foldAndStrip, "STRASSE", "straße", true},
+		{foldAndStrip, "Å", "a", true},
+		{foldAndStrip, "Σοφία", "σοφια", true},
+		{foldAndStrip, "Й", "и", true},
+		{foldAndStrip, "apple", "apples", false},
+
+		// Combining marks of different classes are equal in any order, and whether or not they are precomposed,
+		// while marks of the same class are not reordered.
+		{plain, "a\u0301\u0323", "a\u0323\u0301", true},
+		{plain, "\u00e1\u0323", "\u1ea1\u0301", true},
+		{plain, "\u1ec7", "e\u0302\u0323", true},
+		{plain, "q\u0307\u0323\u031b", "q\u031b\u0323\u0307", true},
+		{plain, "a\u0301\u0300", "a\u0300\u0301", false},
+		{fold, "\u1ea0\u0301x", "a\u0323\u0301X", true},
+		{foldAndStrip, "\u1ec6", "e", true},
+	}
+
+	for _, c := range cases {
+		a, b := string(c.transform([]byte(c.a))), string(c.transform([]byte(c.b)))
+		if (a == b) != c.equal {
+			t.Errorf("Expected %q and %q to be equal: %v, got %q and %q", c.a, c.b, c.equal, a, b)
+		}
+	}
+}
+
+// Collate should pass invalid UTF-8 through, and use a custom normalizer when one is supplied.
+func TestCollateInvalidUTF8AndCustomNormalize(t *testing.T) {
+	transform := Collate(CollateOptions{FoldCase: true})
+	if got := string(transform([]byte("A\xffB"))); got != "a\xffb" {
+		t.Errorf("Unexpected transform of invalid UTF-8: %q", got)
+	}
+
+	transform = Collate(CollateOptions{Normalize: func(key string) string { return "normalized" }})
+	if got := string(transform([]byte("key"))); got != "normalized" {
+		t.Errorf("Expected the custom normalizer to be used, got %q", got)
+	}
+}
+
+// A tree with a collating KeyTransform should find keys regardless of case and accents,
+// while keeping the original keys for display.
+func TestCollatedTree(t *testing.T) {
+	tree := art.NewArtTreeWithOptions(art.Options{
+		KeyTransform: Collate(CollateOptions{FoldCase: true, StripAccents: true}),
+	})
+
+	words := []string{"Café", "cafeteria", "Crème brûlée", "Zoë"}
+	for _, word := range words {
+		tree.Insert([]byte(word), word)
+	}
+
+	for _, query := range []string{"cafe", "CAFÉ", "cafÉ"} {
+		if tree.Search([]byte(query)) != "Café" {
+			t.Errorf("Expected to find Café for %q", query)
+		}
+	}
+
+	original := []string{}
+	tree.ScanPrefix([]byte("CAF"), func(n *art.ArtNode) {
+		original = append(original, string(n.OriginalKey()))
+	})
+
+	if len(original) != 2 || original[0] != "Café" || original[1] != "cafeteria" {
+		t.Errorf("Unexpected original keys for prefix CAF: %q", original)
+	}
+
+	tree.Remove([]byte("ZOE"))
+	if tree.Search([]byte("Zoë")) != nil {
+		t.Error("Expected Zoë to be removed")
+	}
+}
@@ -0,0 +1,219 @@
+// Package keys provides order-preserving encoders and decoders for typed keys,
+// so that values can be stored in an art.ArtTree and iterated in their natural order.
+//
+// Every encoder produces byte slices whose lexicographical order matches the natural order
+// of the encoded values.  Encodings of the same type are also prefix-free:
+// no encoded value is a prefix of another, which is required for keys stored in the same ArtTree.
+package keys
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+)
+
+const (
+	// The sizes of the fixed-width encodings.
+	UINT64_SIZE  = 8
+	INT64_SIZE   = 8
+	FLOAT64_SIZE = 8
+	BOOL_SIZE    = 1
+	TIME_SIZE    = 12
+
+	// Flips the sign bit of a 64 bit value.
+	signBit = 1 << 63
+)
+
+var (
+	// Returned when decoding a key that is too short or too long for its type.
+	ErrInvalidLength = errors.New("keys: invalid length for encoded key")
+
+	// Returned when decoding a string key that is not properly escaped or terminated.
+	ErrInvalidString = errors.New("keys: invalid encoded string")
+)
+
+// ArtTree appends a null byte to keys that do not already contain one.
+// Returns the passed in fixed-width key without that null byte, if present.
+func trimTerminator(key []byte, size int) []byte {
+	if len(key) == size+1 && key[size] == 0 {
+		return key[:size]
+	}
+
+	return key
+}
+
+// Returns the big-endian encoding of the passed in unsigned integer.
+func EncodeUint64(v uint64) []byte {
+	key := make([]byte, UINT64_SIZE)
+	binary.BigEndian.PutUint64(key, v)
+	return key
+}
+
+// Returns the unsigned integer encoded in the passed in key.
+func DecodeUint64(key []byte) (uint64, error) {
+	key = trimTerminator(key, UINT64_SIZE)
+	if len(key) != UINT64_SIZE {
+		return 0, ErrInvalidLength
+	}
+
+	return binary.BigEndian.Uint64(key), nil
+}
+
+// Returns the encoding of the passed in signed integer.
+// The sign bit is flipped so that negative numbers sort before positive numbers,
+// and the result is encoded as a big-endian unsigned integer.
+func EncodeInt64(v int64) []byte {
+	return EncodeUint64(uint64(v) ^ signBit)
+}
+
+// Returns the signed integer encoded in the passed in key.
+func DecodeInt64(key []byte) (int64, error) {
+	v, err := DecodeUint64(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(v ^ signBit), nil
+}
+
+// Returns the encoding of the passed in floating point number.
+// The IEEE-754 bits of positive numbers have their sign bit flipped so that they sort after
+// every negative number, while every bit of negative numbers is flipped so that
+// numbers of a larger magnitude sort first.
+//
+// Negative zero sorts immediately before positive zero, and NaNs sort after positive infinity
+// or before negative infinity depending on their sign bit.
+func EncodeFloat64(v float64) []byte {
+	bits := math.Float64bits(v)
+	if bits&signBit != 0 {
+		bits = ^bits
+	} else {
+		bits |= signBit
+	}
+
+	return EncodeUint64(bits)
+}
+
+// Returns the floating point number encoded in the passed in key.
+func DecodeFloat64(key []byte) (float64, error) {
+	bits, err := DecodeUint64(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if bits&signBit != 0 {
+		bits &^= signBit
+	} else {
+		bits = ^bits
+	}
+
+	return math.Float64frombits(bits), nil
+}
+
+// Returns the encoding of the passed in boolean, where false sorts before true.
+func EncodeBool(v bool) []byte {
+	if v {
+		return []byte{1}
+	}
+
+	return []byte{0}
+}
+
+// Returns the boolean encoded in the passed in key.
+func DecodeBool(key []byte) (bool, error) {
+	key = trimTerminator(key, BOOL_SIZE)
+	if len(key) != BOOL_SIZE || key[0] > 1 {
+		return false, ErrInvalidLength
+	}
+
+	return key[0] == 1, nil
+}
+
+// Returns the encoding of the passed in time as its signed number of seconds since the Unix epoch,
+// followed by its nanoseconds within that second.
+// The location of the time is not encoded, so decoded times are in UTC.
+func EncodeTime(v time.Time) []byte {
+	key := make([]byte, TIME_SIZE)
+	binary.BigEndian.PutUint64(key, uint64(v.Unix())^signBit)
+	binary.BigEndian.PutUint32(key[INT64_SIZE:], uint32(v.Nanosecond()))
+	return key
+}
+
+// Returns the time encoded in the passed in key, in UTC.
+func DecodeTime(key []byte) (time.Time, error) {
+	key = trimTerminator(key, TIME_SIZE)
+	if len(key) != TIME_SIZE {
+		return time.Time{}, ErrInvalidLength
+	}
+
+	seconds := int64(binary.BigEndian.Uint64(key) ^ signBit)
+	nanoseconds := int64(binary.BigEndian.Uint32(key[INT64_SIZE:]))
+
+	return time.Unix(seconds, nanoseconds).UTC(), nil
+}
+
+// Returns the encoding of the passed in string.
+// Null bytes within the string are escaped as 0x00 0xFF, and the string is terminated by 0x00 0x01.
+// Since the terminator sorts before any escaped null byte or any other byte,
+// shorter strings sort before the longer strings that they are a prefix of,
+// and no encoded string is a prefix of another.
+func EncodeString(v string) []byte {
+	key := make([]byte, 0, len(v)+2)
+	return AppendString(key, v)
+}
+
+// Appends the encoding of the passed in string to the passed in key, and returns the extended key.
+func AppendString(key []byte, v string) []byte {
+	for i := 0; i < len(v); i++ {
+		if v[i] == 0 {
+			key = append(key, 0, 0xFF)
+		} else {
+			key = append(key, v[i])
+		}
+	}
+
+	return append(key, 0, 1)
+}
+
+// Returns the string encoded in the passed in key.
+func DecodeString(key []byte) (string, error) {
+	v, rest, err := decodeString(key)
+	if err != nil {
+		return "", err
+	}
+
+	if len(rest) != 0 {
+		return "", ErrInvalidString
+	}
+
+	return v, nil
+}
+
+// Returns the string encoded at the start of the passed in key, followed by the rest of the key.
+func decodeString(key []byte) (string, []byte, error) {
+	v := make([]byte, 0, len(key))
+
+	for i := 0; i < len(key); i++ {
+		if key[i] != 0 {
+			v = append(v, key[i])
+			continue
+		}
+
+		if i+1 >= len(key) {
+			break
+		}
+
+		switch key[i+1] {
+		case 0xFF:
+			v = append(v, 0)
+			i++
+		case 1:
+			return string(v), key[i+2:], nil
+		default:
+			return "", nil, ErrInvalidString
+		}
+	}
+
+	return "", nil, ErrInvalidString
+}
@@ -0,0 +1,179 @@
+package keys
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+// Returns -1, 0 or 1 depending on whether a is less than, equal to or greater than b.
+func compareOrder(less bool, equal bool) int {
+	if equal {
+		return 0
+	}
+
+	if less {
+		return -1
+	}
+
+	return 1
+}
+
+// Encoded unsigned integers should sort in their natural order and decode to their original value.
+func TestUint64OrderAndRoundTrip(t *testing.T) {
+	f := func(a, b uint64) bool {
+		decoded, err := DecodeUint64(EncodeUint64(a))
+		if err != nil || decoded != a {
+			return false
+		}
+
+		return bytes.Compare(EncodeUint64(a), EncodeUint64(b)) == compareOrder(a < b, a == b)
+	}
+
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// Encoded signed integers should sort in their natural order and decode to their original value.
+func TestInt64OrderAndRoundTrip(t *testing.T) {
+	f := func(a, b int64) bool {
+		decoded, err := DecodeInt64(EncodeInt64(a))
+		if err != nil || decoded != a {
+			return false
+		}
+
+		return bytes.Compare(EncodeInt64(a), EncodeInt64(b)) == compareOrder(a < b, a == b)
+	}
+
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+
+	edges := []int64{math.MinInt64, -1, 0, 1, math.MaxInt64}
+	for i := 1; i < len(edges); i++ {
+		if bytes.Compare(EncodeInt64(edges[i-1]), EncodeInt64(edges[i])) >= 0 {
+			t.Errorf("Expected %d to sort before %d", edges[i-1], edges[i])
+		}
+	}
+}
+
+// Encoded floating point numbers should sort in their natural order and decode to their original value.
+func TestFloat64OrderAndRoundTrip(t *testing.T) {
+	f := func(a, b float64) bool {
+		decoded, err := DecodeFloat64(EncodeFloat64(a))
+		if err != nil || math.Float64bits(decoded) != math.Float64bits(a) {
+			return false
+		}
+
+		return bytes.Compare(EncodeFloat64(a), EncodeFloat64(b)) == compareOrder(a < b, a == b)
+	}
+
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+
+	edges := []float64{
+		math.Inf(-1), -math.MaxFloat64, -1, -math.SmallestNonzeroFloat64, math.Copysign(0, -1),
+		0, math.SmallestNonzeroFloat64, 1, math.MaxFloat64, math.Inf(1), math.NaN(),
+	}
+	for i := 1; i < len(edges); i++ {
+		if bytes.Compare(EncodeFloat64(edges[i-1]), EncodeFloat64(edges[i])) >= 0 {
+			t.Errorf("Expected %v to sort before %v", edges[i-1], edges[i])
+		}
+	}
+}
+
+// Encoded booleans should sort false before true and decode to their original value.
+func TestBoolOrderAndRoundTrip(t *testing.T) {
+	if bytes.Compare(EncodeBool(false), EncodeBool(true)) >= 0 {
+		t.Error("Expected false to sort before true")
+	}
+
+	for _, v := range []bool{false, true} {
+		decoded, err := DecodeBool(EncodeBool(v))
+		if err != nil || decoded != v {
+			t.Errorf("Unexpected decoded value %v for %v", decoded, v)
+		}
+	}
+}
+
+// Encoded times should sort in chronological order and decode to the same instant.
+func TestTimeOrderAndRoundTrip(t *testing.T) {
+	f := func(aSeconds, bSeconds int64, aNanoseconds, bNanoseconds uint32) bool {
+		a := time.Unix(aSeconds, int64(aNanoseconds%1e9))
+		b := time.Unix(bSeconds, int64(bNanoseconds%1e9))
+
+		decoded, err := DecodeTime(EncodeTime(a))
+		if err != nil || !decoded.Equal(a) {
+			return false
+		}
+
+		return bytes.Compare(EncodeTime(a), EncodeTime(b)) == compareOrder(a.Before(b), a.Equal(b))
+	}
+
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// Encoded strings should sort in their natural order, decode to their original value,
+// and never be a prefix of one another.
+func TestStringOrderRoundTripAndPrefixFree(t *testing.T) {
+	f := func(a, b string) bool {
+		decoded, err := DecodeString(EncodeString(a))
+		if err != nil || decoded != a {
+			return false
+		}
+
+		encodedA, encodedB := EncodeString(a), EncodeString(b)
+		if a != b && (bytes.HasPrefix(encodedA, encodedB) || bytes.HasPrefix(encodedB, encodedA)) {
+			return false
+		}
+
+		return bytes.Compare(encodedA, encodedB) == compareOrder(a < b, a == b)
+	}
+
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+
+	edges := []string{"", "\x00", "\x00\x00", "\x00\x01", "a", "a\x00", "a\x00b", "a\x01", "ab"}
+	for i := 1; i < len(edges); i++ {
+		if bytes.Compare(EncodeString(edges[i-1]), EncodeString(edges[i])) >= 0 {
+			t.Errorf("Expected %q to sort before %q", edges[i-1], edges[i])
+		}
+	}
+}
+
+// Decoders should reject keys that are not valid encodings of their type.
+func TestDecodeInvalidKeys(t *testing.T) {
+	if _, err := DecodeUint64([]byte{1, 2, 3}); err != ErrInvalidLength {
+		t.Errorf("Expected ErrInvalidLength, got %v", err)
+	}
+
+	if _, err := DecodeBool([]byte{2}); err != ErrInvalidLength {
+		t.Errorf("Expected ErrInvalidLength, got %v", err)
+	}
+
+	if _, err := DecodeTime(EncodeUint64(0)); err != ErrInvalidLength {
+		t.Errorf("Expected ErrInvalidLength, got %v", err)
+	}
+
+	invalid := [][]byte{[]byte("abc"), {'a', 0}, {'a', 0, 2}, {'a', 0, 1, 'b'}}
+	for _, key := range invalid {
+		if _, err := DecodeString(key); err != ErrInvalidString {
+			t.Errorf("Expected ErrInvalidString for %v, got %v", key, err)
+		}
+	}
+}
+
+// Decoders should accept fixed-width keys with the null byte that ArtTree appends to them.
+func TestDecodeNullTerminatedKeys(t *testing.T) {
+	key := append(EncodeUint64(42), 0)
+	if v, err := DecodeUint64(key); err != nil || v != 42 {
+		t.Errorf("Unexpected decoded value %d, %v", v, err)
+	}
+}
@@ -0,0 +1,199 @@
+package keys
+
+import (
+	"time"
+
+	"github.com/kellydunn/go-art"
+)
+
+// Defines an ArtTree whose keys are unsigned integers, iterated in ascending order.
+type Uint64Tree struct {
+	tree *art.ArtTree
+}
+
+// Creates and returns a new, empty Uint64Tree.
+func NewUint64Tree() *Uint64Tree {
+	return &Uint64Tree{tree: art.NewArtTree()}
+}
+
+// Inserts the passed in value under the passed in key.
+func (t *Uint64Tree) Insert(key uint64, value interface{}) {
+	t.tree.Insert(EncodeUint64(key), value)
+}
+
+// Returns the value stored under the passed in key, or nil if there is none.
+func (t *Uint64Tree) Search(key uint64) interface{} {
+	return t.tree.Search(EncodeUint64(key))
+}
+
+// Removes the passed in key from the tree.
+func (t *Uint64Tree) Remove(key uint64) {
+	t.tree.Remove(EncodeUint64(key))
+}
+
+// Calls the passed in callback for every key and value in the tree, in ascending key order.
+func (t *Uint64Tree) Each(callback func(key uint64, value interface{})) {
+	t.tree.Each(func(n *art.ArtNode) {
+		if !n.IsLeaf() {
+			return
+		}
+
+		if key, err := DecodeUint64(n.Key()); err == nil {
+			callback(key, n.Value())
+		}
+	})
+}
+
+// Defines an ArtTree whose keys are signed integers, iterated in ascending order.
+type Int64Tree struct {
+	tree *art.ArtTree
+}
+
+// Creates and returns a new, empty Int64Tree.
+func NewInt64Tree() *Int64Tree {
+	return &Int64Tree{tree: art.NewArtTree()}
+}
+
+// Inserts the passed in value under the passed in key.
+func (t *Int64Tree) Insert(key int64, value interface{}) {
+	t.tree.Insert(EncodeInt64(key), value)
+}
+
+// Returns the value stored under the passed in key, or nil if there is none.
+func (t *Int64Tree) Search(key int64) interface{} {
+	return t.tree.Search(EncodeInt64(key))
+}
+
+// Removes the passed in key from the tree.
+func (t *Int64Tree) Remove(key int64) {
+	t.tree.Remove(EncodeInt64(key))
+}
+
+// Calls the passed in callback for every key and value in the tree, in ascending key order.
+func (t *Int64Tree) Each(callback func(key int64, value interface{})) {
+	t.tree.Each(func(n *art.ArtNode) {
+		if !n.IsLeaf() {
+			return
+		}
+
+		if key, err := DecodeInt64(n.Key()); err == nil {
+			callback(key, n.Value())
+		}
+	})
+}
+
+// Defines an ArtTree whose keys are floating point numbers, iterated in ascending order.
+type Float64Tree struct {
+	tree *art.ArtTree
+}
+
+// Creates and returns a new, empty Float64Tree.
+func NewFloat64Tree() *Float64Tree {
+	return &Float64Tree{tree: art.NewArtTree()}
+}
+
+// Inserts the passed in value under the passed in key.
+func (t *Float64Tree) Insert(key float64, value interface{}) {
+	t.tree.Insert(EncodeFloat64(key), value)
+}
+
+// Returns the value stored under the passed in key, or nil if there is none.
+func (t *Float64Tree) Search(key float64) interface{} {
+	return t.tree.Search(EncodeFloat64(key))
+}
+
+// Removes the passed in key from the tree.
+func (t *Float64Tree) Remove(key float64) {
+	t.tree.Remove(EncodeFloat64(key))
+}
+
+// Calls the passed in callback for every key and value in the tree, in ascending key order.
+func (t *Float64Tree) Each(callback func(key float64, value interface{})) {
+	t.tree.Each(func(n *art.ArtNode) {
+		if !n.IsLeaf() {
+			return
+		}
+
+		if key, err := DecodeFloat64(n.Key()); err == nil {
+			callback(key, n.Value())
+		}
+	})
+}
+
+// Defines an ArtTree whose keys are times, iterated in chronological order.
+type TimeTree struct {
+	tree *art.ArtTree
+}
+
+// Creates and returns a new, empty TimeTree.
+func NewTimeTree() *TimeTree {
+	return &TimeTree{tree: art.NewArtTree()}
+}
+
+// Inserts the passed in value under the passed in key.
+func (t *TimeTree) Insert(key time.Time, value interface{}) {
+	t.tree.Insert(EncodeTime(key), value)
+}
+
+// Returns the value stored under the passed in key, or nil if there is none.
+func (t *TimeTree) Search(key time.Time) interface{} {
+	return t.tree.Search(EncodeTime(key))
+}
+
+// Removes the passed in key from the tree.
+func (t *TimeTree) Remove(key time.Time) {
+	t.tree.Remove(EncodeTime(key))
+}
+
+// Calls the passed in callback for every key and value in the tree, in chronological order.
+// Keys are passed to the callback in UTC.
+func (t *TimeTree) Each(callback func(key time.Time, value interface{})) {
+	t.tree.Each(func(n *art.ArtNode) {
+		if !n.IsLeaf() {
+			return
+		}
+
+		if key, err := DecodeTime(n.Key()); err == nil {
+			callback(key, n.Value())
+		}
+	})
+}
+
+// Defines an ArtTree whose keys are strings, iterated in ascending byte order.
+// Unlike the raw ArtTree, keys may contain null bytes.
+type StringTree struct {
+	tree *art.ArtTree
+}
+
+// Creates and returns a new, empty StringTree.
+func NewStringTree() *StringTree {
+	return &StringTree{tree: art.NewArtTree()}
+}
+
+// Inserts the passed in value under the passed in key.
+func (t *StringTree) Insert(key string, value interface{}) {
+	t.tree.Insert(EncodeString(key), value)
+}
+
+// Returns the value stored under the passed in key, or nil if there is none.
+func (t *StringTree) Search(key string) interface{} {
+	return t.tree.Search(EncodeString(key))
+}
+
+// Removes the passed in key from the tree.
+func (t *StringTree) Remove(key string) {
+	t.tree.Remove(EncodeString(key))
+}
+
+// Calls the passed in callback for every key and value in the tree, in ascending key order.
+func (t *StringTree) Each(callback func(key string, value interface{})) {
+	t.tree.Each(func(n *art.ArtNode) {
+		if !n.IsLeaf() {
+			return
+		}
+
+		if key, err := DecodeString(n.Key()); err == nil {
+			callback(key, n.Value())
+		}
+	})
+}
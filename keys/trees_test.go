@@ -0,0 +1,159 @@
+package keys
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+// A Uint64Tree should iterate its keys in ascending numerical order.
+func TestUint64TreeEachInOrder(t *testing.T) {
+	tree := NewUint64Tree()
+	r := rand.New(rand.NewSource(1))
+
+	expected := make([]uint64, 0, 1000)
+	seen := make(map[uint64]bool)
+	for len(expected) < 1000 {
+		v := r.Uint64() >> uint(r.Intn(64))
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		expected = append(expected, v)
+		tree.Insert(v, v)
+	}
+	sort.Slice(expected, func(i, j int) bool { return expected[i] < expected[j] })
+
+	var actual []uint64
+	tree.Each(func(key uint64, value interface{}) {
+		if value.(uint64) != key {
+			t.Errorf("Unexpected value %v for key %d", value, key)
+		}
+		actual = append(actual, key)
+	})
+
+	if len(actual) != len(expected) {
+		t.Fatalf("Expected %d keys, got %d", len(expected), len(actual))
+	}
+
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Fatalf("Unexpected key %d at position %d, expected %d", actual[i], i, expected[i])
+		}
+	}
+
+	for _, v := range expected[:500] {
+		tree.Remove(v)
+	}
+
+	for i, v := range expected {
+		found := tree.Search(v)
+		if i < 500 && found != nil {
+			t.Errorf("Expected %d to be removed", v)
+		}
+		if i >= 500 && found != v {
+			t.Errorf("Expected to find %d", v)
+		}
+	}
+}
+
+// An Int64Tree should iterate negative keys before positive keys.
+func TestInt64TreeEachInOrder(t *testing.T) {
+	tree := NewInt64Tree()
+	values := []int64{5, -3, 0, 1 << 40, -(1 << 40), -1, 1}
+	for _, v := range values {
+		tree.Insert(v, v)
+	}
+
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	i := 0
+	tree.Each(func(key int64, value interface{}) {
+		if key != values[i] {
+			t.Errorf("Unexpected key %d at position %d, expected %d", key, i, values[i])
+		}
+		i++
+	})
+
+	if i != len(values) {
+		t.Errorf("Expected %d keys, got %d", len(values), i)
+	}
+}
+
+// A Float64Tree should iterate its keys in ascending numerical order.
+func TestFloat64TreeEachInOrder(t *testing.T) {
+	tree := NewFloat64Tree()
+	values := []float64{3.5, -2.25, 0, 1e300, -1e-300, 42, -42}
+	for _, v := range values {
+		tree.Insert(v, v)
+	}
+
+	sort.Float64s(values)
+
+	i := 0
+	tree.Each(func(key float64, value interface{}) {
+		if key != values[i] {
+			t.Errorf("Unexpected key %v at position %d, expected %v", key, i, values[i])
+		}
+		i++
+	})
+
+	if tree.Search(-2.25) != -2.25 {
+		t.Error("Expected to find -2.25")
+	}
+}
+
+// A TimeTree should iterate its keys in chronological order.
+func TestTimeTreeEachInOrder(t *testing.T) {
+	tree := NewTimeTree()
+	base := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	values := []time.Time{base.Add(time.Hour), base.Add(-time.Hour), base, base.Add(time.Nanosecond), time.Unix(0, 0)}
+	for _, v := range values {
+		tree.Insert(v, v)
+	}
+
+	sort.Slice(values, func(i, j int) bool { return values[i].Before(values[j]) })
+
+	i := 0
+	tree.Each(func(key time.Time, value interface{}) {
+		if !key.Equal(values[i]) {
+			t.Errorf("Unexpected key %v at position %d, expected %v", key, i, values[i])
+		}
+		i++
+	})
+
+	if tree.Search(base) == nil {
+		t.Error("Expected to find the base time")
+	}
+}
+
+// A StringTree should store keys that contain null bytes or are prefixes of each other.
+func TestStringTreeNullBytesAndPrefixes(t *testing.T) {
+	tree := NewStringTree()
+	values := []string{"a\x00b", "a", "", "ab", "a\x00", "\x00"}
+	for _, v := range values {
+		tree.Insert(v, v)
+	}
+
+	for _, v := range values {
+		if tree.Search(v) != v {
+			t.Errorf("Expected to find %q", v)
+		}
+	}
+
+	sort.Strings(values)
+
+	i := 0
+	tree.Each(func(key string, value interface{}) {
+		if key != values[i] {
+			t.Errorf("Unexpected key %q at position %d, expected %q", key, i, values[i])
+		}
+		i++
+	})
+
+	tree.Remove("a")
+	if tree.Search("a") != nil || tree.Search("ab") != "ab" {
+		t.Error("Unexpected result after removing a")
+	}
+}
@@ -0,0 +1,276 @@
+package keys
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// Defines the direction in which a single tuple component sorts.
+type Order uint8
+
+const (
+	ASCENDING Order = iota
+	DESCENDING
+)
+
+// The type codes that precede every ascending tuple component.
+// Descending components are stored with every byte inverted, including their type code,
+// so their type codes are always greater than any ascending type code.
+// The end of a tuple is marked by TUPLE_END, which sorts before every type code,
+// so that a tuple sorts before every longer tuple that it is a prefix of.
+const (
+	TUPLE_END    = 0x00
+	TUPLE_NIL    = 0x01
+	TUPLE_BOOL   = 0x02
+	TUPLE_INT    = 0x03
+	TUPLE_UINT   = 0x04
+	TUPLE_FLOAT  = 0x05
+	TUPLE_TIME   = 0x06
+	TUPLE_BYTES  = 0x07
+	TUPLE_STRING = 0x08
+)
+
+var (
+	// Returned when encoding a tuple component of a type that has no tuple encoding.
+	ErrUnsupportedType = errors.New("keys: unsupported tuple component type")
+
+	// Returned when decoding a key that is not a valid tuple encoding.
+	ErrInvalidTuple = errors.New("keys: invalid encoded tuple")
+)
+
+// Defines a single component of a tuple key, along with the direction it sorts in.
+// Supported values are nil, bools, signed and unsigned integers, float32 and float64,
+// time.Time, []byte and strings.  Signed integers decode as int64, unsigned integers as uint64,
+// and floats as float64.
+type TupleElement struct {
+	Value interface{}
+	Order Order
+}
+
+// Returns a tuple component that sorts in ascending order.
+func Asc(v interface{}) TupleElement {
+	return TupleElement{Value: v, Order: ASCENDING}
+}
+
+// Returns a tuple component that sorts in descending order.
+func Desc(v interface{}) TupleElement {
+	return TupleElement{Value: v, Order: DESCENDING}
+}
+
+// Returns the encoding of a complete tuple made up of the passed in components.
+// Tuples sort component by component, and no encoded tuple is a prefix of another,
+// so they can be stored in the same ArtTree regardless of their length.
+func EncodeTuple(elements ...TupleElement) ([]byte, error) {
+	key, err := EncodeTuplePrefix(elements...)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(key, TUPLE_END), nil
+}
+
+// Returns the encoding of the leading components of a tuple, without the end marker.
+// The result is a prefix of the encoding of every complete tuple that starts with
+// the passed in components, and can be passed to ArtTree.ScanPrefix.
+// Since every component is self-delimiting, a partial component never matches:
+// the prefix ("ab") does not match the tuple ("abc").
+func EncodeTuplePrefix(elements ...TupleElement) ([]byte, error) {
+	key := []byte{}
+
+	for _, element := range elements {
+		start := len(key)
+
+		var err error
+		key, err = appendTupleValue(key, element.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		if element.Order == DESCENDING {
+			for i := start; i < len(key); i++ {
+				key[i] = ^key[i]
+			}
+		}
+	}
+
+	return key, nil
+}
+
+// Appends the type code and ascending encoding of the passed in value to the passed in key.
+func appendTupleValue(key []byte, value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return append(key, TUPLE_NIL), nil
+	case bool:
+		return append(append(key, TUPLE_BOOL), EncodeBool(v)...), nil
+	case int:
+		return append(append(key, TUPLE_INT), EncodeInt64(int64(v))...), nil
+	case int8:
+		return append(append(key, TUPLE_INT), EncodeInt64(int64(v))...), nil
+	case int16:
+		return append(append(key, TUPLE_INT), EncodeInt64(int64(v))...), nil
+	case int32:
+		return append(append(key, TUPLE_INT), EncodeInt64(int64(v))...), nil
+	case int64:
+		return append(append(key, TUPLE_INT), EncodeInt64(v)...), nil
+	case uint:
+		return append(append(key, TUPLE_UINT), EncodeUint64(uint64(v))...), nil
+	case uint8:
+		return append(append(key, TUPLE_UINT), EncodeUint64(uint64(v))...), nil
+	case uint16:
+		return append(append(key, TUPLE_UINT), EncodeUint64(uint64(v))...), nil
+	case uint32:
+		return append(append(key, TUPLE_UINT), EncodeUint64(uint64(v))...), nil
+	case uint64:
+		return append(append(key, TUPLE_UINT), EncodeUint64(v)...), nil
+	case float32:
+		return append(append(key, TUPLE_FLOAT), EncodeFloat64(float64(v))...), nil
+	case float64:
+		return append(append(key, TUPLE_FLOAT), EncodeFloat64(v)...), nil
+	case time.Time:
+		return append(append(key, TUPLE_TIME), EncodeTime(v)...), nil
+	case []byte:
+		return AppendString(append(key, TUPLE_BYTES), string(v)), nil
+	case string:
+		return AppendString(append(key, TUPLE_STRING), v), nil
+	default:
+	}
+
+	return nil, ErrUnsupportedType
+}
+
+// Returns the components of the tuple encoded in the passed in key.
+// Keys read back from an ArtTree may carry the null byte the tree appends to them.
+func DecodeTuple(key []byte) ([]TupleElement, error) {
+	elements := []TupleElement{}
+
+	for len(key) > 0 {
+		if key[0] == TUPLE_END {
+			// Allow for the null byte that ArtTree may have appended.
+			if len(key) == 1 || (len(key) == 2 && key[1] == 0) {
+				return elements, nil
+			}
+
+			return nil, ErrInvalidTuple
+		}
+
+		order := ASCENDING
+		if key[0] > math.MaxInt8 {
+			order = DESCENDING
+		}
+
+		value, rest, err := decodeTupleValue(key, order)
+		if err != nil {
+			return nil, err
+		}
+
+		elements = append(elements, TupleElement{Value: value, Order: order})
+		key = rest
+	}
+
+	return nil, ErrInvalidTuple
+}
+
+// Returns the value of the component at the start of the passed in key, followed by the rest of the key.
+func decodeTupleValue(key []byte, order Order) (interface{}, []byte, error) {
+	code := key[0]
+	if order == DESCENDING {
+		code = ^code
+	}
+
+	size := 0
+	switch code {
+	case TUPLE_NIL:
+		return nil, key[1:], nil
+	case TUPLE_BOOL:
+		size = BOOL_SIZE
+	case TUPLE_INT, TUPLE_UINT, TUPLE_FLOAT:
+		size = UINT64_SIZE
+	case TUPLE_TIME:
+		size = TIME_SIZE
+	case TUPLE_BYTES, TUPLE_STRING:
+		return decodeTupleString(key, code, order)
+	default:
+		return nil, nil, ErrInvalidTuple
+	}
+
+	if len(key) < 1+size {
+		return nil, nil, ErrInvalidTuple
+	}
+
+	encoded := make([]byte, size)
+	copy(encoded, key[1:1+size])
+	if order == DESCENDING {
+		invert(encoded)
+	}
+
+	var value interface{}
+	var err error
+
+	switch code {
+	case TUPLE_BOOL:
+		value, err = DecodeBool(encoded)
+	case TUPLE_INT:
+		value, err = DecodeInt64(encoded)
+	case TUPLE_UINT:
+		value, err = DecodeUint64(encoded)
+	case TUPLE_FLOAT:
+		value, err = DecodeFloat64(encoded)
+	case TUPLE_TIME:
+		value, err = DecodeTime(encoded)
+	default:
+	}
+
+	if err != nil {
+		return nil, nil, ErrInvalidTuple
+	}
+
+	return value, key[1+size:], nil
+}
+
+// Returns the string or byte slice component at the start of the passed in key, followed by the rest of the key.
+func decodeTupleString(key []byte, code byte, order Order) (interface{}, []byte, error) {
+	terminator := []byte{0, 1}
+	if order == DESCENDING {
+		terminator = []byte{0xFF, 0xFE}
+	}
+
+	// The terminator can not appear inside an escaped string,
+	// so the first occurrence ends the component.
+	end := -1
+	for i := 1; i+1 < len(key); i++ {
+		if key[i] == terminator[0] && key[i+1] == terminator[1] {
+			end = i + 2
+			break
+		}
+	}
+
+	if end < 0 {
+		return nil, nil, ErrInvalidTuple
+	}
+
+	encoded := make([]byte, end-1)
+	copy(encoded, key[1:end])
+	if order == DESCENDING {
+		invert(encoded)
+	}
+
+	v, err := DecodeString(encoded)
+	if err != nil {
+		return nil, nil, ErrInvalidTuple
+	}
+
+	if code == TUPLE_BYTES {
+		return []byte(v), key[end:], nil
+	}
+
+	return v, key[end:], nil
+}
+
+// Inverts every bit of the passed in bytes in place.
+func invert(b []byte) {
+	for i := range b {
+		b[i] = ^b[i]
+	}
+}
@@ -0,0 +1,154 @@
+package keys
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/kellydunn/go-art"
+)
+
+// Tuples should decode to the components they were encoded from, in either order.
+func TestTupleRoundTrip(t *testing.T) {
+	now := time.Unix(1420070400, 123).UTC()
+	elements := []TupleElement{
+		Asc(nil), Desc(nil), Asc(true), Desc(false), Asc(int64(-7)), Desc(int64(7)),
+		Asc(uint64(9)), Desc(uint64(1 << 60)), Asc(-1.5), Desc(2.5), Asc(now), Desc(now),
+		Asc([]byte{0, 1, 0xFF}), Desc([]byte{0xFF, 0, 1}), Asc("a\x00b"), Desc("\xff\x00\x01"), Asc(""), Desc(""),
+	}
+
+	key, err := EncodeTuple(elements...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeTuple(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(decoded, elements) {
+		t.Errorf("Unexpected decoded tuple %v", decoded)
+	}
+
+	decoded, err = DecodeTuple(append(key, 0))
+	if err != nil || len(decoded) != len(elements) {
+		t.Errorf("Expected a null terminated tuple to decode, got %v", err)
+	}
+}
+
+// Tuples made up of ascending and descending components should sort component by component.
+func TestTupleOrder(t *testing.T) {
+	f := func(a1 string, a2 int64, a3 string, b1 string, b2 int64, b3 string) bool {
+		a, _ := EncodeTuple(Asc(a1), Desc(a2), Asc(a3))
+		b, _ := EncodeTuple(Asc(b1), Desc(b2), Asc(b3))
+
+		expected := 0
+		switch {
+		case a1 != b1:
+			expected = compareOrder(a1 < b1, false)
+		case a2 != b2:
+			expected = compareOrder(a2 > b2, false)
+		case a3 != b3:
+			expected = compareOrder(a3 < b3, false)
+		default:
+		}
+
+		return bytes.Compare(a, b) == expected
+	}
+
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+
+	g := func(a, b string) bool {
+		encodedA, _ := EncodeTuple(Desc(a))
+		encodedB, _ := EncodeTuple(Desc(b))
+		return bytes.Compare(encodedA, encodedB) == compareOrder(a > b, a == b)
+	}
+
+	if err := quick.Check(g, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// Shorter tuples should sort before the longer tuples they are a prefix of, without being a prefix of them.
+func TestTuplePrefixFree(t *testing.T) {
+	short, _ := EncodeTuple(Asc("tenant"))
+	long, _ := EncodeTuple(Asc("tenant"), Desc(int64(1)))
+	longer, _ := EncodeTuple(Asc("tenantx"))
+
+	if bytes.HasPrefix(long, short) || bytes.HasPrefix(longer, short) {
+		t.Error("Expected tuples not to be prefixes of each other")
+	}
+
+	if bytes.Compare(short, long) >= 0 || bytes.Compare(short, longer) >= 0 {
+		t.Error("Expected the shorter tuple to sort first")
+	}
+}
+
+// Unsupported component types and malformed keys should be rejected.
+func TestTupleErrors(t *testing.T) {
+	if _, err := EncodeTuple(Asc(struct{}{})); err != ErrUnsupportedType {
+		t.Errorf("Expected ErrUnsupportedType, got %v", err)
+	}
+
+	invalid := [][]byte{{}, {TUPLE_INT, 1, 2}, {TUPLE_STRING, 'a'}, {0x42}, {TUPLE_NIL, TUPLE_END, TUPLE_NIL}}
+	for _, key := range invalid {
+		if _, err := DecodeTuple(key); err != ErrInvalidTuple {
+			t.Errorf("Expected ErrInvalidTuple for %v, got %v", key, err)
+		}
+	}
+}
+
+// ScanPrefix on the leading components of a tuple should return the matching tuples,
+// ordered by their remaining components.
+func TestTupleScanPrefix(t *testing.T) {
+	tree := art.NewArtTree()
+	base := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tenants := []string{"acme", "acme2", "globex"}
+	for _, tenant := range tenants {
+		for i := 0; i < 5; i++ {
+			for _, name := range []string{"b", "a"} {
+				key, _ := EncodeTuple(Asc(tenant), Desc(base.Add(time.Duration(i)*time.Hour)), Asc(name))
+				tree.Insert(key, tenant+name)
+			}
+		}
+	}
+
+	prefix, _ := EncodeTuplePrefix(Asc("acme"))
+
+	var times []time.Time
+	var names []string
+	tree.ScanPrefix(prefix, func(n *art.ArtNode) {
+		elements, err := DecodeTuple(n.Key())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if elements[0].Value != "acme" {
+			t.Errorf("Unexpected tenant %v", elements[0].Value)
+		}
+
+		times = append(times, elements[1].Value.(time.Time))
+		names = append(names, elements[2].Value.(string))
+	})
+
+	if len(times) != 10 {
+		t.Fatalf("Expected 10 tuples for the tenant, got %d", len(times))
+	}
+
+	if !sort.SliceIsSorted(times, func(i, j int) bool { return times[i].After(times[j]) }) {
+		t.Errorf("Expected timestamps in descending order, got %v", times)
+	}
+
+	for i := 0; i < len(names); i += 2 {
+		if names[i] != "a" || names[i+1] != "b" {
+			t.Errorf("Expected names in ascending order within a timestamp, got %v", names)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package netroute
+
+import (
+	"math/rand"
+	"net/netip"
+	"testing"
+
+	"github.com/kellydunn/go-art"
+)
+
+// Lookup should return the most specific prefix that contains the address.
+func TestLookupLongestMatch(t *testing.T) {
+	table := NewTable()
+	routes := []string{"0.0.0.0/0", "10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "10.1.2.3/32", "::/0", "2001:db8::/32", "2001:db8:1::/48"}
+	for _, r := range routes {
+		if err := table.Insert(netip.MustParsePrefix(r), r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := map[string]string{
+		"192.168.1.1":     "0.0.0.0/0",
+		"10.200.0.1":      "10.0.0.0/8",
+		"10.1.3.4":        "10.1.0.0/16",
+		"10.1.2.4":        "10.1.2.0/24",
+		"10.1.2.3":        "10.1.2.3/32",
+		"::ffff:10.1.2.3": "10.1.2.3/32",
+		"2001:db8:2::1":   "2001:db8::/32",
+		"2001:db8:1::1":   "2001:db8:1::/48",
+		"fe80::1":         "::/0",
+	}
+
+	for addr, expected := range cases {
+		prefix, value, ok := table.Lookup(netip.MustParseAddr(addr))
+		if !ok || prefix.String() != expected || value != expected {
+			t.Errorf("Unexpected route %v, %v for %s, expected %s", prefix, value, addr, expected)
+		}
+	}
+
+	if table.Size() != len(routes) {
+		t.Errorf("Expected a size of %d, got %d", len(routes), table.Size())
+	}
+}
+
+// Insert should replace existing values, Delete should remove exactly the passed in prefix,
+// and invalid prefixes should be rejected.
+func TestInsertReplaceDeleteAndInvalid(t *testing.T) {
+	table := NewTable()
+	table.Insert(netip.MustParsePrefix("10.0.0.0/8"), "a")
+	table.Insert(netip.MustParsePrefix("10.255.0.0/8"), "b")
+	table.Insert(netip.MustParsePrefix("10.0.0.0/16"), "c")
+
+	if value, ok := table.Get(netip.MustParsePrefix("10.0.0.0/8")); !ok || value != "b" || table.Size() != 2 {
+		t.Errorf("Expected the value to be replaced, got %v with a size of %d", value, table.Size())
+	}
+
+	table.Delete(netip.MustParsePrefix("10.0.0.0/8"))
+	table.Delete(netip.MustParsePrefix("10.0.0.0/8"))
+	if _, _, ok := table.Lookup(netip.MustParseAddr("10.1.0.0")); ok || table.Size() != 1 {
+		t.Errorf("Expected no route after deleting 10.0.0.0/8, with a size of %d", table.Size())
+	}
+
+	if _, _, ok := table.Lookup(netip.MustParseAddr("10.0.1.1")); !ok {
+		t.Error("Expected 10.0.0.0/16 to remain")
+	}
+
+	if err := table.Insert(netip.Prefix{}, nil); err != ErrInvalidPrefix {
+		t.Errorf("Expected ErrInvalidPrefix, got %v", err)
+	}
+
+	if _, _, ok := table.Lookup(netip.Addr{}); ok {
+		t.Error("Expected no route for an invalid address")
+	}
+}
+
+// Walk should visit IPv4 before IPv6, and every prefix before the prefixes it contains.
+func TestWalkOrder(t *testing.T) {
+	table := NewTable()
+	routes := []string{"2001:db8::/32", "10.1.0.0/16", "10.0.0.0/8", "::/0", "9.0.0.0/8", "10.1.0.0/24", "10.128.0.0/9"}
+	for _, r := range routes {
+		table.Insert(netip.MustParsePrefix(r), nil)
+	}
+
+	expected := []string{"9.0.0.0/8", "10.0.0.0/8", "10.1.0.0/16", "10.1.0.0/24", "10.128.0.0/9", "::/0", "2001:db8::/32"}
+	i := 0
+	table.Walk(func(prefix netip.Prefix, value interface{}) {
+		if i >= len(expected) || prefix.String() != expected[i] {
+			t.Errorf("Unexpected prefix %v at position %d", prefix, i)
+		}
+		i++
+	})
+
+	if i != len(expected) {
+		t.Errorf("Expected %d prefixes, got %d", len(expected), i)
+	}
+}
+
+// Lookup should agree with a linear scan over random IPv4 prefixes.
+func TestLookupMatchesLinearScan(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	table := NewTable()
+	prefixes := []netip.Prefix{}
+
+	randomAddr := func() netip.Addr {
+		return netip.AddrFrom4([4]byte{byte(r.Intn(4)), byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256))})
+	}
+
+	for i := 0; i < 2000; i++ {
+		prefix, _ := randomAddr().Prefix(r.Intn(33))
+		prefixes = append(prefixes, prefix)
+		table.Insert(prefix, prefix)
+	}
+
+	for i := 0; i < 2000; i++ {
+		addr := randomAddr()
+
+		var expected netip.Prefix
+		for _, prefix := range prefixes {
+			if prefix.Contains(addr) && (!expected.IsValid() || prefix.Bits() > expected.Bits()) {
+				expected = prefix
+			}
+		}
+
+		prefix, value, ok := table.Lookup(addr)
+		if ok != expected.IsValid() || prefix != expected || (ok && value != expected) {
+			t.Errorf("Unexpected route %v for %v, expected %v", prefix, addr, expected)
+		}
+	}
+}
+
+// Lookup should not allocate, whether or not it finds a route.
+func TestLookupDoesNotAllocate(t *testing.T) {
+	table := NewTable()
+	table.Insert(netip.MustParsePrefix("10.0.0.0/8"), nil)
+	table.Insert(netip.MustParsePrefix("10.1.0.0/16"), nil)
+	table.Insert(netip.MustParsePrefix("2001:db8::/32"), nil)
+
+	addrs := []string{"10.1.2.3", "10.2.3.4", "192.168.0.1", "::ffff:10.1.2.3", "2001:db8::1", "2001:db9::1"}
+	for _, s := range addrs {
+		addr := netip.MustParseAddr(s)
+		if allocs := testing.AllocsPerRun(100, func() { table.Lookup(addr) }); allocs != 0 {
+			t.Errorf("Expected looking up %v not to allocate, got %v allocations", addr, allocs)
+		}
+	}
+}
+
+func BenchmarkLookupIPv4(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	table := NewTable()
+	for i := 0; i < 100000; i++ {
+		prefix, _ := netip.AddrFrom4([4]byte{byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)), 0}).Prefix(8 + r.Intn(17))
+		table.Insert(prefix, nil)
+	}
+
+	addrs := make([]netip.Addr, 1024)
+	for i := range addrs {
+		addrs[i] = netip.AddrFrom4([4]byte{byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256))})
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		table.Lookup(addrs[i%len(addrs)])
+	}
+}
+
+// Returns the key of the passed in prefix with whole address bytes, followed by the masked final partial byte
+// and the number of bits of it, for comparison with the keys of a Table.
+func wholeByteKey(prefix netip.Prefix) []byte {
+	raw := prefix.Masked().Addr().AsSlice()
+	k := append([]byte{FAMILY_IPV4}, raw[:prefix.Bits()/8]...)
+	if bits := prefix.Bits() % 8; bits != 0 {
+		k = append(k, raw[prefix.Bits()/8], byte(bits))
+	}
+
+	return k
+}
+
+// Reports the average depth of the leaves and the heap bytes per route of a tree of IPv4 routes,
+// keyed by the keys of a Table, which store every bit of a prefix as a byte, and by whole address bytes,
+// which is shorter but cannot be searched with LongestPrefixMatch.
+func BenchmarkRouteKeyShapeIPv4(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	prefixes := []netip.Prefix{}
+	for i := 0; i < 100000; i++ {
+		prefix, _ := netip.AddrFrom4([4]byte{byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)), 0}).Prefix(8 + r.Intn(17))
+		prefixes = append(prefixes, prefix)
+	}
+
+	encodings := map[string]func(prefix netip.Prefix) []byte{
+		"BitPerByte": func(prefix netip.Prefix) []byte { return key(prefix.Addr(), prefix.Bits()) },
+		"WholeBytes": wholeByteKey,
+	}
+
+	for name, encode := range encodings {
+		b.Run(name, func(b *testing.B) {
+			var stats *art.TreeStats
+			for i := 0; i < b.N; i++ {
+				tree := art.NewArtTree()
+				for _, prefix := range prefixes {
+					tree.Insert(encode(prefix), nil)
+				}
+
+				stats = tree.Stats()
+			}
+
+			depth := int64(0)
+			for d, count := range stats.DepthHistogram {
+				depth += int64(d) * count
+			}
+
+			b.ReportMetric(float64(depth)/float64(stats.Leaves), "depth/route")
+			b.ReportMetric(float64(stats.HeapBytes)/float64(stats.Leaves), "bytes/route")
+		})
+	}
+}